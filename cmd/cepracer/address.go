@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Executa a busca reversa por endereço e exibe todos os CEPs encontrados
+func runAddressLookup(opts *options) int {
+	results, err := opts.client.SearchAddress(context.Background(), opts.address)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			slog.Error(tr("Timeout: o ViaCEP não respondeu a tempo"))
+			return 4
+		}
+		slog.Error(tr("Falha na busca por endereço"), "erro", err)
+		return 1
+	}
+	if len(results) == 0 {
+		slog.Warn(tr("Nenhum CEP encontrado para o endereço (a busca por endereço está disponível apenas no ViaCEP)"))
+		return 3
+	}
+
+	// Exibe apenas a página pedida; as demais são indicadas no cabeçalho
+	pageResults, pages := paginate(results, opts.page, opts.pageSize)
+	if opts.page > pages {
+		slog.Error(tr("Página inexistente"), "pagina", opts.page, "ceps", len(results), "paginas", pages)
+		return 1
+	}
+
+	for i, result := range pageResults {
+		switch opts.format {
+		case "json":
+			printJSON(result)
+		case "csv":
+			printCSV(result)
+		case "oneline":
+			fmt.Println(result.FormatAddress())
+		case "template":
+			printTemplate(result, opts.template)
+		default:
+			if i == 0 {
+				printPageHeader(len(results), opts.page, pages, len(pageResults), opts.pageSize)
+			}
+			fmt.Println("=============================")
+			printFields(result, opts.fields, "API")
+		}
+	}
+	if opts.format == "text" {
+		fmt.Println("=============================")
+		if opts.page < pages {
+			fmt.Print(tr("Próxima página: -page %d\n", opts.page+1))
+		}
+	}
+	return 0
+}
+
+// Retorna os resultados da página (a partir de 1) e o total de páginas.
+// Com size 0, todos os resultados ficam em uma única página.
+func paginate(results []*cep.Result, page, size int) ([]*cep.Result, int) {
+	if size == 0 {
+		return results, 1
+	}
+	pages := (len(results) + size - 1) / size
+	if page > pages {
+		return nil, pages
+	}
+	start := (page - 1) * size
+	return results[start:min(start+size, len(results))], pages
+}
+
+// Exibe quantos CEPs foram encontrados e quais estão na página
+func printPageHeader(total, page, pages, count, size int) {
+	if pages == 1 {
+		fmt.Print(tr("%d CEP(s) encontrado(s) no ViaCEP\n", total))
+		return
+	}
+	first := (page-1)*size + 1
+	fmt.Print(tr("%d CEP(s) encontrado(s) no ViaCEP, exibindo %d a %d (página %d de %d)\n", total, first, first+count-1, page, pages))
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Colunas da saída em CSV da busca reversa em lote: o endereço consultado
+// seguido das colunas de csvHeader, uma linha por CEP encontrado
+var addressCSVHeader = append([]string{"endereco"}, csvHeader...)
+
+// Endereço lido do arquivo de -address-file, com o erro de validação das
+// linhas que não formam um endereço válido (ver cep.NewAddress)
+type addressRow struct {
+	line    int    // Linha do arquivo (ou posição do item no JSON), a partir de 1
+	text    string // Endereço como informado, exibido nas linhas inválidas
+	address cep.Address
+	err     error
+}
+
+// Resultado da busca reversa de uma linha do arquivo
+type addressGroup struct {
+	row     addressRow
+	results []*cep.Result
+	err     error
+}
+
+// Saída em JSON de uma linha do arquivo: um objeto por endereço, com a lista
+// de CEPs (vazia quando nenhum foi encontrado) ou o erro
+type jsonAddressGroup struct {
+	Linha    int          `json:"linha"`
+	Endereco string       `json:"endereco"`
+	CEPs     []jsonResult `json:"ceps"`
+	Erro     string       `json:"erro,omitempty"`
+}
+
+// Lê os endereços de -address-file no formato de -input-format: plain (um
+// UF/Cidade/Logradouro por linha), csv (com as colunas uf, cidade e
+// logradouro, ou rua, no cabeçalho) ou json (array de strings
+// UF/Cidade/Logradouro ou de objetos com os campos uf, cidade e logradouro)
+func readAddressFile(path, format string) ([]addressRow, error) {
+	in := io.Reader(os.Stdin)
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao abrir o arquivo de endereços: %v", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	switch format {
+	case "csv":
+		return readAddressCSV(in)
+	case "json":
+		return readAddressJSON(in)
+	}
+	return readAddressPlain(in)
+}
+
+func readAddressPlain(in io.Reader) ([]addressRow, error) {
+	var rows []addressRow
+	scanner := bufio.NewScanner(in)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		a, err := cep.ParseAddress(text)
+		rows = append(rows, addressRow{line: line, text: text, address: a, err: err})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao ler o arquivo de endereços: %v", err)
+	}
+	return rows, nil
+}
+
+func readAddressCSV(in io.Reader) ([]addressRow, error) {
+	r, err := newInputCSVReader(in)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler o arquivo de endereços: %v", err)
+	}
+	names, err := r.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler o arquivo de endereços: %v", err)
+	}
+	var columns [3]int
+	for i, column := range []string{"uf", "cidade", "logradouro"} {
+		index, err := csvColumnIndex(names, column)
+		if err != nil && column == "logradouro" {
+			index, err = csvColumnIndex(names, "rua")
+		}
+		if err != nil {
+			return nil, err
+		}
+		columns[i] = index
+	}
+
+	var rows []addressRow
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler o arquivo de endereços: %v", err)
+		}
+		line, _ := r.FieldPos(0)
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		var parts [3]string
+		for i, index := range columns {
+			if index < len(record) {
+				parts[i] = record[index]
+			}
+		}
+		a, err := cep.NewAddress(parts[0], parts[1], parts[2])
+		rows = append(rows, addressRow{line: line, text: strings.Join(parts[:], "/"), address: a, err: err})
+	}
+	return rows, nil
+}
+
+func readAddressJSON(in io.Reader) ([]addressRow, error) {
+	var items []json.RawMessage
+	if err := json.NewDecoder(in).Decode(&items); err != nil {
+		return nil, fmt.Errorf("erro ao ler o arquivo de endereços: esperado um array JSON de endereços UF/Cidade/Logradouro ou de objetos com os campos uf, cidade e logradouro: %v", err)
+	}
+
+	rows := make([]addressRow, 0, len(items))
+	for i, item := range items {
+		row := addressRow{line: i + 1}
+		switch text := string(bytes.TrimSpace(item)); {
+		case strings.HasPrefix(text, `"`):
+			if row.err = json.Unmarshal(item, &row.text); row.err == nil {
+				row.address, row.err = cep.ParseAddress(row.text)
+			}
+		case strings.HasPrefix(text, "{"):
+			var fields struct {
+				UF         string `json:"uf"`
+				Cidade     string `json:"cidade"`
+				Logradouro string `json:"logradouro"`
+				Rua        string `json:"rua"`
+			}
+			if row.err = json.Unmarshal(item, &fields); row.err == nil {
+				if fields.Logradouro == "" {
+					fields.Logradouro = fields.Rua
+				}
+				row.text = fields.UF + "/" + fields.Cidade + "/" + fields.Logradouro
+				row.address, row.err = cep.NewAddress(fields.UF, fields.Cidade, fields.Logradouro)
+			}
+		default:
+			row.text = text
+			row.err = errors.New(tr("item deve ser um endereço UF/Cidade/Logradouro ou um objeto com os campos uf, cidade e logradouro"))
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Executa a busca reversa de cada endereço de -address-file, no máximo
+// opts.concurrency ao mesmo tempo e dentro do -rate-limit do ViaCEP,
+// exibindo um grupo de CEPs por linha na ordem do arquivo. As linhas
+// inválidas e as falhas aparecem no grupo da linha, sem interromper as
+// demais, e são resumidas no log ao final.
+func runAddressFile(opts *options) int {
+	rows, err := readAddressFile(opts.addressFile, opts.inputFormat)
+	if err != nil {
+		slog.Error(tr("Falha ao ler o arquivo de endereços"), "erro", err)
+		return 1
+	}
+
+	// Um canal por linha preserva a ordem de exibição do arquivo
+	groups := make([]chan addressGroup, len(rows))
+	for i := range groups {
+		groups[i] = make(chan addressGroup, 1)
+	}
+
+	sem := make(chan struct{}, opts.concurrency)
+	var wg sync.WaitGroup
+	go func() {
+		for i, row := range rows {
+			if row.err != nil {
+				groups[i] <- addressGroup{row: row, err: row.err}
+				continue
+			}
+			sem <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				results, err := opts.client.SearchAddress(context.Background(), row.address)
+				groups[i] <- addressGroup{row: row, results: results, err: err}
+			}()
+		}
+	}()
+
+	var failed []addressGroup
+	empty := 0
+	for i, ch := range groups {
+		group := <-ch
+		switch {
+		case group.err != nil:
+			failed = append(failed, group)
+		case len(group.results) == 0:
+			empty++
+		}
+		displayAddressGroup(group, i, opts)
+	}
+	wg.Wait()
+
+	slog.Info(tr("Busca por endereços concluída"), "enderecos", len(rows), "com_ceps", len(rows)-empty-len(failed), "sem_ceps", empty, "falhas", len(failed))
+	if len(failed) == 0 {
+		return 0
+	}
+	for _, group := range failed {
+		slog.Error(tr("Falha na busca por endereço"), "linha", group.row.line, "endereco", group.row.text, "erro", addressErrorText(group.err))
+	}
+	return 1
+}
+
+// Exibe os CEPs encontrados para uma linha do arquivo, delimitados das
+// demais linhas: em texto, um cabeçalho com o endereço; em JSON, um objeto
+// por linha; em CSV, o endereço na primeira coluna; com oneline, uma linha
+// com todos os CEPs
+func displayAddressGroup(group addressGroup, index int, opts *options) {
+	name := group.row.text
+	if group.row.err == nil {
+		name = group.row.address.String()
+	}
+
+	switch opts.format {
+	case "json":
+		out := jsonAddressGroup{Linha: group.row.line, Endereco: name, CEPs: []jsonResult{}}
+		if group.err != nil {
+			out.Erro = addressErrorText(group.err)
+		}
+		for _, result := range group.results {
+			out.CEPs = append(out.CEPs, newJSONResult(result))
+		}
+		if err := json.NewEncoder(os.Stdout).Encode(out); err != nil {
+			slog.Error(tr("Erro ao gerar a saída em JSON"), "erro", err)
+		}
+	case "csv":
+		switch {
+		case group.err != nil:
+			stdoutCSV.writeWithHeader(addressCSVHeader, []string{name, "", "", "", "", "", "", "", "", addressErrorText(group.err)})
+		case len(group.results) == 0:
+			stdoutCSV.writeWithHeader(addressCSVHeader, []string{name, "", "", "", "", "", "", "", "", tr("nenhum CEP encontrado")})
+		}
+		for _, result := range group.results {
+			stdoutCSV.writeWithHeader(addressCSVHeader, append([]string{name}, csvRecord(result)...))
+		}
+	case "oneline":
+		ceps := make([]string, len(group.results))
+		for i, result := range group.results {
+			ceps[i] = result.CEP
+		}
+		switch {
+		case group.err != nil:
+			fmt.Print(tr("%s: erro: %s\n", name, addressErrorText(group.err)))
+		case len(ceps) == 0:
+			fmt.Print(tr("%s: nenhum CEP encontrado\n", name))
+		default:
+			fmt.Printf("%s: %s\n", name, strings.Join(ceps, ", "))
+		}
+	default:
+		if index > 0 {
+			fmt.Println()
+		}
+		fmt.Print(tr("=== %s (linha %d) ===\n", name, group.row.line))
+		switch {
+		case group.err != nil:
+			fmt.Print(tr("erro: %s\n", addressErrorText(group.err)))
+		case len(group.results) == 0:
+			fmt.Println(tr("Nenhum CEP encontrado"))
+		default:
+			for _, result := range group.results {
+				fmt.Println(result.FormatAddress())
+			}
+			fmt.Print(tr("%d CEP(s) encontrado(s) no ViaCEP\n", len(group.results)))
+		}
+	}
+}
+
+// Resume o erro de uma linha do arquivo, com o timeout identificado como na
+// busca por um único endereço
+func addressErrorText(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return tr("o ViaCEP não respondeu a tempo")
+	}
+	return batchErrorText(err)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/template"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Rótulos do resultado mais rápido e do autoritativo nas saídas em JSON,
+// CSV e com template
+const (
+	labelFastest       = "mais_rapido"
+	labelAuthoritative = "autoritativo"
+)
+
+// Saída em JSON com -authoritative: um único objeto com o resultado mais
+// rápido e o da API autoritativa, nulo (com o motivo em
+// erro_autoritativo) quando ela não respondeu a tempo ou falhou
+type jsonAuthoritative struct {
+	MaisRapido       jsonResult  `json:"mais_rapido"`
+	Autoritativo     *jsonResult `json:"autoritativo"`
+	ErroAutoritativo string      `json:"erro_autoritativo,omitempty"`
+}
+
+// Resultado exibido com o template de -format junto ao rótulo
+// ("mais_rapido" ou "autoritativo"), acessível em {{.Resultado}}
+type labeledResult struct {
+	*cep.Result
+	Resultado string
+}
+
+// Aguarda a resposta da API autoritativa, limitada pelo timeout da consulta.
+// Retorna o erro quando ela não respondeu a tempo ou falhou, já registrado
+// no log.
+func awaitAuthoritative(r *cep.Race) (*cep.Result, error) {
+	result, err := r.Authoritative()
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		slog.Warn(tr("Timeout: a API autoritativa não respondeu a tempo"))
+		return nil, errors.New(tr("a API autoritativa não respondeu a tempo"))
+	case err != nil:
+		slog.Warn(tr("API autoritativa falhou"), "erro", err)
+		return nil, err
+	}
+	return result, nil
+}
+
+// Exibe o resultado mais rápido e o da API autoritativa. Em JSON, os dois
+// vão em um único objeto, escrito após a resposta (ou o fim do prazo) da
+// autoritativa; nos demais formatos, o mais rápido é exibido imediatamente.
+// Resultados do cache ou do fallback por município não consultam a
+// autoritativa.
+func displayWithAuthoritative(r *cep.Race, opts *options) {
+	awaited := !r.Result.SomenteMunicipio && !r.Result.Cached
+	if opts.format == "json" {
+		out := jsonAuthoritative{MaisRapido: newJSONResult(r.Result)}
+		if !awaited {
+			out.ErroAutoritativo = tr("resultado do cache ou do fallback por município, a API autoritativa não foi consultada")
+		} else if result, err := awaitAuthoritative(r); err != nil {
+			out.ErroAutoritativo = maskedErrorText(opts.cep, err, opts)
+		} else {
+			authoritative := newJSONResult(result)
+			out.Autoritativo = &authoritative
+		}
+		if err := json.NewEncoder(os.Stdout).Encode(out); err != nil {
+			slog.Error(tr("Erro ao gerar a saída em JSON"), "erro", err)
+		}
+		return
+	}
+
+	displayResult(r.Result, opts)
+	if !awaited {
+		return
+	}
+	result, err := awaitAuthoritative(r)
+	switch {
+	case err != nil && opts.format == "csv":
+		printLabeledCSVError(labelAuthoritative, maskedCEP(opts.cep, opts), maskedErrorText(opts.cep, err, opts))
+	case err == nil:
+		displayAuthoritative(result, opts)
+	}
+}
+
+// Exibe o resultado da API autoritativa, identificado separadamente do mais rápido
+func displayAuthoritative(result *cep.Result, opts *options) {
+	if opts.format == "csv" {
+		printLabeledCSV(labelAuthoritative, result)
+		return
+	}
+	if opts.format == "oneline" {
+		fmt.Print(tr("Autoritativo: %s (%s)\n", result.FormatAddress(), result.API))
+		return
+	}
+	if opts.format == "template" {
+		printLabeledTemplate(labelAuthoritative, result, opts.template)
+		return
+	}
+
+	fmt.Println()
+	fmt.Println(tr("Resultado da API autoritativa"))
+	fmt.Println("=============================")
+	printFields(result, opts.fields, tr("API autoritativa"))
+	fmt.Println("=============================")
+}
+
+// Exibe o resultado com o template de -format, precedido do rótulo no
+// autoritativo (como em -format oneline); o template acessa o rótulo em
+// {{.Resultado}}
+func printLabeledTemplate(label string, result *cep.Result, tmpl *template.Template) {
+	prefix := ""
+	if label == labelAuthoritative {
+		prefix = tr("Autoritativo: ")
+	}
+	printTemplateWithPrefix(prefix, labeledResult{Result: result, Resultado: label}, tmpl)
+}
@@ -0,0 +1,509 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Resultado da consulta de um CEP do lote
+type batchItem struct {
+	cep    string
+	input  []string // Colunas originais da linha do CEP no lote em CSV (-preserve-input-column)
+	result *cep.Result
+	err    error
+
+	unchanged bool // Resultado igual ao do cache, omitido com -resolve-only-if-changed
+}
+
+// CEPs do arquivo do lote, na ordem do arquivo. Com -input-format csv,
+// guarda também o cabeçalho e as colunas originais da linha de cada CEP,
+// ajustadas ao tamanho do cabeçalho.
+type batchFile struct {
+	ceps    []string
+	header  []string
+	columns [][]string
+	column  int // Posição da coluna do CEP no cabeçalho
+}
+
+// Formatos do arquivo do lote em -input-format
+var inputFormats = []string{"plain", "csv", "json"}
+
+// Lê o arquivo do lote ("-" para a entrada padrão) no formato de
+// -input-format:
+//   - plain: um CEP por linha; em exportações CSV, o CEP é a primeira coluna
+//     (separada por vírgula ou ponto e vírgula) e linhas com CEP inválido
+//     (como o cabeçalho) são descartadas com um aviso;
+//   - csv: CSV com cabeçalho, com o CEP na coluna column (nome do cabeçalho
+//     ou posição a partir de 1);
+//   - json: array de CEPs ou de objetos com o campo cep.
+//
+// Linhas em branco são ignoradas e as sem CEP válido são descartadas com um
+// aviso.
+func readBatchFile(path, format, column string) (*batchFile, error) {
+	in, name := io.Reader(os.Stdin), "stdin"
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao abrir o arquivo de CEPs: %v", err)
+		}
+		defer f.Close()
+		in, name = f, path
+	}
+
+	switch format {
+	case "csv":
+		return readBatchCSV(in, name, column)
+	case "json":
+		return readBatchJSON(in, name)
+	}
+	return readBatchPlain(in, name)
+}
+
+// Lê um CEP por linha, na primeira coluna das linhas com vírgula ou ponto e
+// vírgula
+func readBatchPlain(in io.Reader, name string) (*batchFile, error) {
+	var ceps []string
+	scanner := bufio.NewScanner(in)
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(strings.ReplaceAll(scanner.Text(), ";", ","), ",")
+		text = strings.Trim(strings.TrimSpace(text), `"`)
+		if text == "" {
+			continue
+		}
+		code, err := cep.Normalize(text)
+		if err != nil {
+			slog.Warn(tr("Linha ignorada"), "arquivo", name, "linha", line, "erro", err)
+			continue
+		}
+		ceps = append(ceps, code)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao ler o arquivo de CEPs: %v", err)
+	}
+	return &batchFile{ceps: ceps}, nil
+}
+
+// Lê o CEP da coluna column de um CSV com cabeçalho, separado por vírgula ou
+// ponto e vírgula (detectado no cabeçalho)
+func readBatchCSV(in io.Reader, name, column string) (*batchFile, error) {
+	r, err := newInputCSVReader(in)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler o arquivo de CEPs: %v", err)
+	}
+	names, err := r.Read()
+	if err == io.EOF {
+		return &batchFile{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler o arquivo de CEPs: %v", err)
+	}
+	index, err := csvColumnIndex(names, column)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &batchFile{header: names, column: index}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler o arquivo de CEPs: %v", err)
+		}
+		line, _ := r.FieldPos(0)
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		if index >= len(record) || strings.TrimSpace(record[index]) == "" {
+			slog.Warn(tr("Linha ignorada"), "arquivo", name, "linha", line, "erro", tr("CEP ausente na coluna %q", names[index]))
+			continue
+		}
+		code, err := cep.Normalize(strings.TrimSpace(record[index]))
+		if err != nil {
+			slog.Warn(tr("Linha ignorada"), "arquivo", name, "linha", line, "erro", err)
+			continue
+		}
+		columns := make([]string, len(names))
+		copy(columns, record)
+		file.ceps = append(file.ceps, code)
+		file.columns = append(file.columns, columns)
+	}
+	return file, nil
+}
+
+// Leitor de um CSV de entrada, separado por vírgula ou ponto e vírgula
+// (detectado na primeira linha), com linhas de tamanhos variados
+func newInputCSVReader(in io.Reader) (*csv.Reader, error) {
+	br := bufio.NewReader(in)
+	first, err := br.Peek(br.Size())
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	header, _, _ := bytes.Cut(first, []byte("\n"))
+
+	r := csv.NewReader(br)
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		r.Comma = ';'
+	}
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	return r, nil
+}
+
+// Retorna a posição da coluna do CEP no cabeçalho do CSV, pelo nome (sem
+// diferenciar maiúsculas) ou pela posição a partir de 1
+func csvColumnIndex(names []string, column string) (int, error) {
+	if len(names) > 0 {
+		names[0] = strings.TrimPrefix(names[0], "\ufeff") // BOM das exportações para o Excel
+	}
+	if n, err := strconv.Atoi(column); err == nil {
+		if n < 1 || n > len(names) {
+			return 0, fmt.Errorf("coluna %d inexistente: o cabeçalho do CSV tem %d colunas", n, len(names))
+		}
+		return n - 1, nil
+	}
+	for i, name := range names {
+		if strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(column)) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("coluna %q não encontrada no cabeçalho do CSV (colunas: %s)", column, strings.Join(names, ", "))
+}
+
+// Lê um array JSON de CEPs, como strings ou objetos com o campo cep (string
+// ou número, como no modo stream)
+func readBatchJSON(in io.Reader, name string) (*batchFile, error) {
+	var items []json.RawMessage
+	if err := json.NewDecoder(in).Decode(&items); err != nil {
+		return nil, fmt.Errorf("erro ao ler o arquivo de CEPs: esperado um array JSON de CEPs ou de objetos com o campo cep: %v", err)
+	}
+
+	var ceps []string
+	for i, item := range items {
+		raw, err := "", error(nil)
+		switch text := string(bytes.TrimSpace(item)); {
+		case strings.HasPrefix(text, `"`):
+			err = json.Unmarshal(item, &raw)
+		case strings.HasPrefix(text, "{"):
+			_, raw, err = parseStreamLine(text)
+		default:
+			err = errors.New(tr("item deve ser um CEP ou um objeto com o campo cep"))
+		}
+		if err == nil && strings.TrimSpace(raw) == "" {
+			err = errors.New(tr("campo \"cep\" ausente"))
+		}
+		code := ""
+		if err == nil {
+			code, err = cep.Normalize(strings.TrimSpace(raw))
+		}
+		if err != nil {
+			slog.Warn(tr("Item ignorado"), "arquivo", name, "item", i+1, "erro", err)
+			continue
+		}
+		ceps = append(ceps, code)
+	}
+	return &batchFile{ceps: ceps}, nil
+}
+
+// Colunas originais da linha do i-ésimo CEP, nil fora do lote em CSV
+func (f *batchFile) input(i int) []string {
+	if i < len(f.columns) {
+		return f.columns[i]
+	}
+	return nil
+}
+
+// Cache do lote com -resolve-only-if-changed: as consultas ignoram o
+// resultado armazenado, para que o atual seja comparado a ele, e gravam o
+// novo resultado
+type refreshCache struct {
+	cep.CacheBackend
+}
+
+func (refreshCache) Get(context.Context, string) (*cep.Result, bool, error) {
+	return nil, false, nil
+}
+
+// Resultado do CEP no cache antes da consulta, nil se não houver
+func cachedResult(ctx context.Context, cache cep.CacheBackend, code string) *cep.Result {
+	result, ok, err := cache.Get(ctx, code)
+	if err != nil {
+		slog.Warn(tr("Falha ao ler o cache"), "cep", code, "erro", err)
+	}
+	if !ok {
+		return nil
+	}
+	return result
+}
+
+// Erro dos CEPs do lote não consultados (ou cancelados) após a primeira
+// falha com -abort-on-first-error
+var errBatchAborted = errors.New("lote interrompido na primeira falha")
+
+// Consulta todos os CEPs do arquivo, no máximo opts.concurrency ao mesmo
+// tempo, exibindo uma linha por CEP na ordem do arquivo. Falhas são
+// exibidas na linha do CEP sem interromper o lote e resumidas no log ao
+// final; com -abort-on-first-error, a primeira falha cancela as consultas em
+// andamento e as ainda não iniciadas, que não são exibidas. Com
+// -resolve-only-if-changed, os CEPs com o resultado igual ao do cache
+// (pelos campos principais de cep.Diff) também não são exibidos.
+func runBatch(opts *options) int {
+	file, err := readBatchFile(opts.file, opts.inputFormat, opts.inputColumn)
+	if err != nil {
+		slog.Error(tr("Falha ao ler o lote"), "erro", err)
+		return 1
+	}
+	ceps := file.ceps
+	cache := opts.client.Cache
+	if opts.resolveOnlyIfChanged {
+		opts.client.Cache = refreshCache{cache}
+		defer func() { opts.client.Cache = cache }()
+	}
+	var dir *outputDir
+	if opts.outputDir != "" {
+		if dir, err = newOutputDir(opts.outputDir, opts.noClobber); err != nil {
+			slog.Error(tr("Falha no lote"), "erro", err)
+			return 1
+		}
+	}
+
+	// Um canal por CEP preserva a ordem de exibição do arquivo
+	items := make([]chan batchItem, len(ceps))
+	for i := range items {
+		items[i] = make(chan batchItem, 1)
+	}
+
+	// Cancelado na primeira falha com -abort-on-first-error
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var abortOnce sync.Once
+	var abortedBy batchItem
+
+	sem := make(chan struct{}, opts.concurrency)
+	var wg sync.WaitGroup
+	go func() {
+		for i, code := range ceps {
+			sem <- struct{}{}
+			if ctx.Err() != nil {
+				<-sem
+				items[i] <- batchItem{cep: code, input: file.input(i), err: errBatchAborted}
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				var previous *cep.Result
+				if opts.resolveOnlyIfChanged {
+					previous = cachedResult(ctx, cache, code)
+				}
+				item := lookupBatchItem(ctx, code, opts)
+				item.input = file.input(i)
+				item.unchanged = previous != nil && item.err == nil && len(cep.Diff(previous, item.result)) == 0
+				switch {
+				case item.err == nil || !opts.abortOnFirstError:
+				case ctx.Err() != nil:
+					// Cancelada pela falha de outro CEP
+					item.err = errBatchAborted
+				default:
+					abortOnce.Do(func() {
+						abortedBy = item
+						cancel()
+					})
+				}
+				items[i] <- item
+			}()
+		}
+	}()
+
+	var failed []batchItem
+	var exported [][]string
+	skipped, outputErrs, unchanged := 0, 0, 0
+	for _, ch := range items {
+		item := <-ch
+		if errors.Is(item.err, errBatchAborted) {
+			skipped++
+			continue
+		}
+		if item.unchanged {
+			unchanged++
+			continue
+		}
+		if item.err != nil {
+			failed = append(failed, item)
+		}
+		if opts.export != "" {
+			row := exportRow(item)
+			if opts.preserveInputColumn {
+				row = preservedRow(item, row[len(row)-1])
+			}
+			exported = append(exported, row)
+		}
+		switch {
+		case dir != nil:
+			if err := dir.write(item); err != nil {
+				slog.Error(tr("Falha ao gravar o resultado do CEP"), "cep", maskedCEP(item.cep, opts), "erro", err)
+				outputErrs++
+			}
+		case opts.preserveInputColumn && opts.format == "csv":
+			printPreservedCSV(file, item, opts)
+		default:
+			displayBatchItem(item, opts)
+		}
+		if opts.webhook != nil {
+			opts.webhook.sendLookup(item.cep, item.result, item.err)
+		}
+	}
+	wg.Wait()
+	if opts.export != "" {
+		header := exportHeader
+		if opts.preserveInputColumn {
+			header = slices.Concat(file.header, preservedColumns)
+		}
+		if err := writeExport(opts.export, header, exported); err != nil {
+			slog.Error(tr("Falha na exportação do lote"), "erro", err)
+			return 1
+		}
+	}
+	processed := len(ceps) - skipped
+	if opts.resolveOnlyIfChanged {
+		slog.Info(tr("Alterações em relação ao cache"), "alterados", processed-unchanged-len(failed), "inalterados", unchanged, "falhas", len(failed))
+	}
+	if dir != nil {
+		slog.Info(tr("Arquivos do lote gravados"), "diretorio", dir.path, "gravados", dir.written, "mantidos", dir.kept, "falhas", outputErrs)
+	}
+	if opts.webhook != nil {
+		opts.webhook.enqueue(webhookEvent{Evento: "resumo", Resumo: &batchSummary{Total: processed, Encontrados: processed - len(failed), Falhas: len(failed)}})
+	}
+
+	if ctx.Err() != nil {
+		slog.Error(tr("Lote interrompido na primeira falha"), "cep", maskedCEP(abortedBy.cep, opts), "erro", maskedErrorText(abortedBy.cep, abortedBy.err, opts), "ignorados", skipped, "total", len(ceps))
+		return 1
+	}
+	if len(failed) == 0 {
+		if outputErrs > 0 {
+			return 1
+		}
+		return 0
+	}
+	slog.Error(tr("CEP(s) do lote falharam"), "falhas", len(failed), "total", len(ceps))
+	for _, item := range failed {
+		slog.Error(tr("Falha no lote"), "cep", maskedCEP(item.cep, opts), "erro", maskedErrorText(item.cep, item.err, opts))
+	}
+	return 1
+}
+
+// Consulta um CEP do lote com o timeout configurado
+func lookupBatchItem(ctx context.Context, code string, opts *options) batchItem {
+	result, err := opts.client.Lookup(ctx, code)
+	return batchItem{cep: code, result: result, err: err}
+}
+
+// Exibe o resultado de um CEP do lote em uma única linha
+func displayBatchItem(item batchItem, opts *options) {
+	if opts.format == "json" {
+		if item.err != nil {
+			out := struct {
+				CEP  string `json:"cep"`
+				Erro string `json:"erro"`
+			}{maskedCEP(item.cep, opts), maskedErrorText(item.cep, item.err, opts)}
+			if err := json.NewEncoder(os.Stdout).Encode(out); err != nil {
+				slog.Error(tr("Erro ao gerar a saída em JSON"), "erro", err)
+			}
+			return
+		}
+		printJSON(item.result)
+		return
+	}
+	if opts.format == "csv" {
+		if item.err != nil {
+			printCSVError(maskedCEP(item.cep, opts), maskedErrorText(item.cep, item.err, opts))
+			return
+		}
+		printCSV(item.result)
+		return
+	}
+	// Com template, apenas os CEPs encontrados; as falhas vão para o log ao final do lote
+	if opts.format == "template" {
+		if item.err == nil {
+			printTemplate(item.result, opts.template)
+		}
+		return
+	}
+
+	if item.err != nil {
+		fmt.Print(tr("%s: erro: %s\n", maskedCEP(item.cep, opts), maskedErrorText(item.cep, item.err, opts)))
+		return
+	}
+	fmt.Printf("%s: %s (%s)\n", maskedCEP(item.cep, opts), item.result.FormatAddress(), item.result.API)
+}
+
+// CEP consultado, mascarado quando -mask-cep estiver ativo
+func maskedCEP(code string, opts *options) string {
+	if opts.maskCEP {
+		return cep.Mask(code)
+	}
+	return code
+}
+
+// Resume o erro de um CEP em uma única linha, com o CEP mascarado quando
+// -mask-cep estiver ativo
+func maskedErrorText(code string, err error, opts *options) string {
+	text := batchErrorText(err)
+	if !opts.maskCEP {
+		return text
+	}
+	masked := cep.Mask(code)
+	return strings.NewReplacer(cep.Format(code), masked, code, masked).Replace(text)
+}
+
+// Resume o erro de um CEP em uma única linha
+func batchErrorText(err error) string {
+	var quorumErr *cep.QuorumError
+	if errors.As(err, &quorumErr) {
+		parts := make([]string, 0, len(quorumErr.Results)+len(quorumErr.Errs))
+		for _, r := range quorumErr.Results {
+			parts = append(parts, r.API+": "+r.FormatAddress())
+		}
+		for _, e := range quorumErr.Errs {
+			parts = append(parts, e.Error())
+		}
+		return tr("menos de %d APIs concordam no endereço (%s)", quorumErr.Quorum, strings.Join(parts, "; "))
+	}
+
+	var lookupErr *cep.LookupError
+	if !errors.As(err, &lookupErr) {
+		return err.Error()
+	}
+
+	text := tr("nenhuma API retornou o CEP")
+	switch {
+	case errors.Is(err, cep.ErrTimeout):
+		text = tr("nenhuma API respondeu a tempo")
+	case errors.Is(err, cep.ErrNotFound):
+		text = tr("CEP não encontrado em nenhuma API")
+	}
+	parts := make([]string, len(lookupErr.Errs))
+	for i, e := range lookupErr.Errs {
+		parts[i] = e.Error()
+	}
+	if len(parts) > 0 {
+		text += " (" + strings.Join(parts, "; ") + ")"
+	}
+	return text
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Stub do ViaCEP que responde "erro": true para os CEPs em notFound e
+// registra os CEPs consultados
+type batchStub struct {
+	*httptest.Server
+	mu       sync.Mutex
+	received []string
+}
+
+func newBatchStub(t *testing.T, notFound ...string) *batchStub {
+	t.Helper()
+	stub := &batchStub{}
+	stub.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code := strings.Trim(r.URL.Path, "/")
+		stub.mu.Lock()
+		stub.received = append(stub.received, code)
+		stub.mu.Unlock()
+		for _, nf := range notFound {
+			if code == nf {
+				io.WriteString(w, viaCEPNotFound)
+				return
+			}
+		}
+		io.WriteString(w, strings.Replace(viaCEPFound, "01001-000", code[:5]+"-"+code[5:], 1))
+	}))
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+func (s *batchStub) requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.received...)
+}
+
+// Grava o arquivo do lote em um diretório temporário
+func writeBatchFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunBatchAbortOnFirstError(t *testing.T) {
+	tests := []struct {
+		name     string
+		abort    bool
+		requests int // Consultas esperadas ao stub
+		output   []string
+	}{
+		{"segue após a falha", false, 5, []string{"01001000: Praça da Sé", "01001001: erro", "01001002: Praça da Sé", "01001004: Praça da Sé"}},
+		{"interrompe na primeira falha", true, 2, []string{"01001000: Praça da Sé", "01001001: erro"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newBatchStub(t, "01001001")
+			file := writeBatchFile(t, "ceps.txt", "01001000\n01001001\n01001002\n01001003\n01001004\n")
+			args := []string{"-file", file, "-concurrency", "1", "-providers", "viacep", "-url", "viacep=" + stub.URL + "/%s"}
+			if tt.abort {
+				args = append(args, "-abort-on-first-error")
+			}
+
+			code, out, logs := runCLIStderr(t, args...)
+			if code != 1 {
+				t.Errorf("código de saída = %d, esperado 1", code)
+			}
+			if got := stub.requests(); len(got) != tt.requests {
+				t.Errorf("CEPs consultados = %v, esperados %d", got, tt.requests)
+			}
+			for _, want := range tt.output {
+				if !strings.Contains(out, want) {
+					t.Errorf("saída sem %q:\n%s", want, out)
+				}
+			}
+			if tt.abort {
+				if lines := strings.Count(out, "\n"); lines != 2 {
+					t.Errorf("%d linhas na saída, esperadas apenas as dos CEPs consultados até a falha:\n%s", lines, out)
+				}
+				if !strings.Contains(logs, "Lote interrompido na primeira falha") || !strings.Contains(logs, "cep=01001001") || !strings.Contains(logs, "ignorados=3") {
+					t.Errorf("log sem o CEP que falhou e os ignorados:\n%s", logs)
+				}
+			}
+		})
+	}
+}
+
+func TestAbortOnFirstErrorRequiresBatch(t *testing.T) {
+	if _, err := parseFlags([]string{"-abort-on-first-error", "01001000"}); err == nil || !strings.Contains(err.Error(), "-file") {
+		t.Errorf("erro = %v, esperado que -abort-on-first-error exija -file", err)
+	}
+}
+
+func TestReadBatchFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		column  string
+		content string
+		want    []string
+		skipped int    // Linhas ou itens descartados com aviso
+		err     string // Trecho do erro esperado, vazio se não houver
+	}{
+		{"plain com cabeçalho", "plain", "cep", "cep,nome\n01001-000,Sé\n\n20040020;Centro\n", []string{"01001000", "20040020"}, 1, ""},
+		{"csv pelo nome", "csv", "CEP", "id,cep\n1,01001-000\n2,20040020\n", []string{"01001000", "20040020"}, 0, ""},
+		{"csv pela posição", "csv", "2", "id;codigo\n1;01001-000\n2;\"20040-020\"\n", []string{"01001000", "20040020"}, 0, ""},
+		{"csv com BOM", "csv", "cep", "\ufeffcep,cidade\n01001000,São Paulo\n", []string{"01001000"}, 0, ""},
+		{"csv sem o CEP na linha", "csv", "cep", "id,cep\n1,01001000\n2\n3,\n4,abc\n", []string{"01001000"}, 3, ""},
+		{"csv sem a coluna", "csv", "cep", "id,codigo\n1,01001000\n", nil, 0, `coluna "cep" não encontrada`},
+		{"csv com posição inexistente", "csv", "3", "id,cep\n1,01001000\n", nil, 0, "coluna 3 inexistente"},
+		{"json de strings", "json", "cep", `["01001-000", "20040020"]`, []string{"01001000", "20040020"}, 0, ""},
+		{"json de objetos", "json", "cep", `[{"cep": "01001-000", "id": 1}, {"cep": 20040020}, {"id": 3}, {"cep": ""}, 42]`, []string{"01001000", "20040020"}, 3, ""},
+		{"json que não é array", "json", "cep", `{"cep": "01001000"}`, nil, 0, "esperado um array JSON"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs strings.Builder
+			defer slog.SetDefault(slog.Default())
+			slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+			got, err := readBatchFile(writeBatchFile(t, "ceps."+tt.format, tt.content), tt.format, tt.column)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("erro = %v, esperado %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got.ceps, tt.want) {
+				t.Errorf("CEPs = %v, esperados %v", got, tt.want)
+			}
+			if skipped := strings.Count(logs.String(), "ignorad"); skipped != tt.skipped {
+				t.Errorf("%d avisos de descarte, esperados %d:\n%s", skipped, tt.skipped, logs.String())
+			}
+		})
+	}
+}
+
+func TestRunBatchPreserveInputColumn(t *testing.T) {
+	stub := newBatchStub(t, "01001001")
+	file := writeBatchFile(t, "clientes.csv", "id;CEP;nome\n1;01001-000;Ana\n2;01001001;\"Bia; filial\"\n")
+	export := filepath.Join(t.TempDir(), "clientes.csv")
+	code, out, _ := runCLIStderr(t, "-file", file, "-input-format", "csv", "-preserve-input-column", "-format", "csv", "-export", export,
+		"-concurrency", "1", "-providers", "viacep", "-url", "viacep="+stub.URL+"/%s")
+	if code != 1 {
+		t.Errorf("código de saída = %d, esperado 1 (um CEP não encontrado)", code)
+	}
+
+	want := []string{
+		"id,CEP,nome,cidade,estado,logradouro,bairro,erro",
+		"1,01001-000,Ana,São Paulo,SP,Praça da Sé,Sé,",
+		"2,01001001,Bia; filial,,,,,CEP não encontrado em nenhuma API",
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("saída com %d linhas, esperadas %d:\n%s", len(lines), len(want), out)
+	}
+	for i, line := range lines {
+		if !strings.HasPrefix(line, want[i]) {
+			t.Errorf("linha %d = %q, esperado o início %q", i+1, line, want[i])
+		}
+	}
+
+	data, err := os.ReadFile(export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exported := strings.Split(strings.TrimSpace(strings.TrimPrefix(string(data), "\ufeff")), "\n")
+	if len(exported) != len(want) || exported[0] != want[0] || exported[1] != want[1] || !strings.HasPrefix(exported[2], want[2]) {
+		t.Errorf("exportação:\n%s\nesperado o mesmo layout da saída:\n%s", data, strings.Join(want, "\n"))
+	}
+}
+
+func TestPreserveInputColumnRequiresCSV(t *testing.T) {
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"-file", "ceps.txt", "-preserve-input-column", "-format", "csv"}, "-input-format csv"},
+		{[]string{"-file", "ceps.csv", "-input-format", "csv", "-preserve-input-column"}, "-format csv ou -export"},
+	}
+	for _, tt := range tests {
+		if _, err := parseFlags(tt.args); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("parseFlags(%v) = %v, esperado erro com %q", tt.args, err, tt.err)
+		}
+	}
+}
+
+func TestInputFormatRequiresBatch(t *testing.T) {
+	if _, err := parseFlags([]string{"-input-format", "csv", "01001000"}); err == nil || !strings.Contains(err.Error(), "-file") {
+		t.Errorf("erro = %v, esperado que -input-format exija -file", err)
+	}
+	if _, err := parseFlags([]string{"-input-format", "xml", "-file", "ceps.txt"}); err == nil || !strings.Contains(err.Error(), "-input-format inválido") {
+		t.Errorf("erro = %v, esperado formato inválido", err)
+	}
+}
+
+func TestRunBatchOutputDir(t *testing.T) {
+	tests := []struct {
+		name      string
+		noClobber bool
+		written   string // Contagens esperadas no resumo do log
+		existing  string // Conteúdo esperado em 01001000.json, gravado antes do lote
+	}{
+		{"sobrescreve por padrão", false, "gravados=2 mantidos=0", "Praça da Sé"},
+		{"mantém com -no-clobber", true, "gravados=1 mantidos=1", "anterior"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newBatchStub(t, "01001001")
+			file := writeBatchFile(t, "ceps.txt", "01001000\n01001001\n01001002\n")
+			dir := filepath.Join(t.TempDir(), "resultados", "lote")
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "01001000.json"), []byte("anterior\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			args := []string{"-file", file, "-output-dir", dir, "-providers", "viacep", "-url", "viacep=" + stub.URL + "/%s"}
+			if tt.noClobber {
+				args = append(args, "-no-clobber")
+			}
+
+			code, out, logs := runCLIStderr(t, args...)
+			if code != 1 {
+				t.Errorf("código de saída = %d, esperado 1 (um CEP não encontrado)", code)
+			}
+			if out != "" {
+				t.Errorf("saída padrão = %q, esperada vazia com -output-dir", out)
+			}
+			if !strings.Contains(logs, tt.written) {
+				t.Errorf("log sem %q:\n%s", tt.written, logs)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, "01001000.json"))
+			if err != nil || !strings.Contains(string(data), tt.existing) {
+				t.Errorf("01001000.json = %q (%v), esperado %q", data, err, tt.existing)
+			}
+			data, err = os.ReadFile(filepath.Join(dir, "01001002.json"))
+			if err != nil || !strings.Contains(string(data), `"cep": "01001-002"`) {
+				t.Errorf("01001002.json = %q (%v), esperado o resultado do CEP", data, err)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "01001001.json")); !os.IsNotExist(err) {
+				t.Errorf("arquivo gravado para o CEP não encontrado (%v)", err)
+			}
+		})
+	}
+}
+
+func TestOutputDirCreatesDirectory(t *testing.T) {
+	stub := newBatchStub(t)
+	dir := filepath.Join(t.TempDir(), "novo", "dir")
+	code, _, _ := runCLIStderr(t, "-file", writeBatchFile(t, "ceps.txt", "01001000\n"), "-output-dir", dir, "-providers", "viacep", "-url", "viacep="+stub.URL+"/%s")
+	if code != 0 {
+		t.Fatalf("código de saída = %d, esperado 0", code)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "01001000.json")); err != nil {
+		t.Errorf("arquivo do CEP não gravado no diretório criado: %v", err)
+	}
+}
+
+func TestRunBatchResolveOnlyIfChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cep.db")
+	cache, err := cep.LoadCache(path, time.Hour, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	cache.Set(ctx, "01001000", &cep.Result{API: "ViaCEP", CEP: "01001-000", Logradouro: "Praça da Sé", Bairro: "Sé", Cidade: "São Paulo", Estado: "SP"})
+	cache.Set(ctx, "01001002", &cep.Result{API: "ViaCEP", CEP: "01001-002", Logradouro: "Praça da Sé (antiga)", Bairro: "Sé", Cidade: "São Paulo", Estado: "SP"})
+	if err := cache.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	cache.Close()
+
+	stub := newBatchStub(t, "01001001")
+	file := writeBatchFile(t, "ceps.txt", "01001000\n01001001\n01001002\n01001003\n")
+	code, out, logs := runCLIStderr(t, "-file", file, "-resolve-only-if-changed", "-cache-file", path, "-concurrency", "1",
+		"-providers", "viacep", "-url", "viacep="+stub.URL+"/%s")
+	if code != 1 {
+		t.Errorf("código de saída = %d, esperado 1 (um CEP não encontrado)", code)
+	}
+	if got := stub.requests(); len(got) != 4 {
+		t.Errorf("CEPs consultados = %v, esperados todos, inclusive os do cache", got)
+	}
+	want := []string{"01001001: erro", "01001002: Praça da Sé", "01001003: Praça da Sé"}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("saída com %d linhas, esperadas %d (sem o CEP inalterado):\n%s", len(lines), len(want), out)
+	}
+	for i, line := range lines {
+		if !strings.HasPrefix(line, want[i]) {
+			t.Errorf("linha %d = %q, esperado o início %q", i+1, line, want[i])
+		}
+	}
+	if !strings.Contains(logs, "alterados=2 inalterados=1 falhas=1") {
+		t.Errorf("log sem o resumo das alterações:\n%s", logs)
+	}
+}
+
+func TestResolveOnlyIfChangedRequiresCache(t *testing.T) {
+	if _, err := parseFlags([]string{"-file", "ceps.txt", "-resolve-only-if-changed"}); err == nil || !strings.Contains(err.Error(), "-cache-file") {
+		t.Errorf("erro = %v, esperado que -resolve-only-if-changed exija um cache persistido", err)
+	}
+}
+
+// Stub da busca por endereço do ViaCEP: dois CEPs para a Domingos de
+// Morais, uma falha para logradouros com "Falha" e nenhum CEP para os demais
+func newAddressStub(t *testing.T) *batchStub {
+	t.Helper()
+	stub := &batchStub{}
+	stub.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stub.mu.Lock()
+		stub.received = append(stub.received, r.URL.Path)
+		stub.mu.Unlock()
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/Domingos de Morais"):
+			io.WriteString(w, `[
+				{"cep": "04010-100", "logradouro": "Rua Domingos de Morais", "bairro": "Vila Mariana", "localidade": "São Paulo", "uf": "SP"},
+				{"cep": "04036-100", "logradouro": "Rua Domingos de Morais", "bairro": "Vila Mariana", "localidade": "São Paulo", "uf": "SP"}
+			]`)
+		case strings.Contains(r.URL.Path, "Falha"):
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			io.WriteString(w, `[]`)
+		}
+	}))
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+func TestRunAddressFile(t *testing.T) {
+	stub := newAddressStub(t)
+	file := writeBatchFile(t, "enderecos.csv", "uf;cidade;rua\nSP;São Paulo;Domingos de Morais\nSP;SP;Praça da Sé\nRJ;Rio de Janeiro;Rua Inexistente\n")
+
+	code, stdout, stderr := runCLIStderr(t, "-providers", "viacep", "-url", "viacep="+stub.URL+"/%s", "-input-format", "csv", "-address-file", file)
+	if code != 1 {
+		t.Errorf("código de saída = %d, esperado 1 pela linha inválida", code)
+	}
+	want := "=== SP/São Paulo/Domingos de Morais (linha 2) ===\n" +
+		"Rua Domingos de Morais, Vila Mariana, São Paulo - SP, 04010-100\n" +
+		"Rua Domingos de Morais, Vila Mariana, São Paulo - SP, 04036-100\n" +
+		"2 CEP(s) encontrado(s) no ViaCEP\n" +
+		"\n=== SP/SP/Praça da Sé (linha 3) ===\n" +
+		"erro: cidade e logradouro devem ter pelo menos 3 caracteres\n" +
+		"\n=== RJ/Rio de Janeiro/Rua Inexistente (linha 4) ===\n" +
+		"Nenhum CEP encontrado\n"
+	if stdout != want {
+		t.Errorf("saída:\n%s\nesperada:\n%s", stdout, want)
+	}
+	if got := len(stub.requests()); got != 2 {
+		t.Errorf("%d buscas no ViaCEP, esperadas 2 (a linha inválida não é consultada)", got)
+	}
+	if !strings.Contains(stderr, "com_ceps=1 sem_ceps=1 falhas=1") {
+		t.Errorf("log sem o resumo das linhas: %s", stderr)
+	}
+}
+
+func TestRunAddressFileJSON(t *testing.T) {
+	stub := newAddressStub(t)
+	file := writeBatchFile(t, "enderecos.json", `["SP/São Paulo/Domingos de Morais", {"uf": "MG", "cidade": "Belo Horizonte", "logradouro": "Rua Falha"}, 42]`)
+
+	code, stdout := runCLI(t, "-providers", "viacep", "-url", "viacep="+stub.URL+"/%s", "-input-format", "json", "-format", "json", "-concurrency", "1", "-address-file", file)
+	if code != 1 {
+		t.Errorf("código de saída = %d, esperado 1 pelas falhas", code)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("%d linhas, esperado um objeto por item do arquivo:\n%s", len(lines), stdout)
+	}
+	want := []struct {
+		linha    int
+		endereco string
+		ceps     int
+		erro     bool
+	}{
+		{1, "SP/São Paulo/Domingos de Morais", 2, false},
+		{2, "MG/Belo Horizonte/Rua Falha", 0, true},
+		{3, "42", 0, true},
+	}
+	for i, line := range lines {
+		var got jsonAddressGroup
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("linha %d não é JSON: %v", i+1, err)
+		}
+		if got.Linha != want[i].linha || got.Endereco != want[i].endereco || len(got.CEPs) != want[i].ceps || (got.Erro != "") != want[i].erro {
+			t.Errorf("item %d = %+v, esperado %+v", i+1, got, want[i])
+		}
+	}
+}
+
+func TestAddressFileOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"com -file", []string{"-address-file", "enderecos.txt", "-file", "ceps.txt"}, "-address-file não pode ser combinado"},
+		{"com CEP", []string{"-address-file", "enderecos.txt", "01001000"}, "-address-file não pode ser combinado"},
+		{"com template", []string{"-address-file", "enderecos.txt", "-format", "{{.CEP}}"}, "não aceita template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseFlags(tt.args); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("erro = %v, esperado %q", err, tt.want)
+			}
+		})
+	}
+	if _, err := parseFlags([]string{"-address-file", "enderecos.csv", "-input-format", "csv"}); err != nil {
+		t.Errorf("-input-format com -address-file: %v", err)
+	}
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// CEPs consultados pelo bench quando nenhum é informado
+var defaultBenchCEPs = []string{"01001000", "01310100", "01153000", "13335320"}
+
+// Consultas por API padrão do bench
+const defaultBenchRequests = 20
+
+// Tempos de resposta guardados por API, padrão de -max-latency-sample-size
+const defaultLatencySampleSize = 10000
+
+// Métodos de cálculo dos percentis do bench
+const (
+	percentileExact     = "exato"        // Sobre todos os tempos
+	percentileReservoir = "reservatorio" // Estimados sobre a amostra de latencyReservoir
+)
+
+// Distribuição do tempo de resposta e taxa de erro de uma API no bench
+type benchStats struct {
+	API     string        `json:"api"`
+	Total   int           `json:"consultas"`
+	Errors  int           `json:"erros"`
+	P50     time.Duration `json:"-"`
+	P95     time.Duration `json:"-"`
+	P99     time.Duration `json:"-"`
+	P50MS   float64       `json:"p50_ms"`
+	P95MS   float64       `json:"p95_ms"`
+	P99MS   float64       `json:"p99_ms"`
+	ErrRate float64       `json:"taxa_erro"`        // Fração das consultas com erro ou resposta inválida, de 0 a 1
+	Method  string        `json:"metodo"`           // Cálculo dos percentis: percentileExact ou percentileReservoir
+	Samples int           `json:"amostras"`         // Tempos usados no cálculo dos percentis
+	Share   float64       `json:"fracao,omitempty"` // Proporção configurada em -provider-sample, de 0 a 1
+}
+
+// Peso de uma API no sorteio de -provider-sample
+type benchArm struct {
+	id     string
+	weight float64 // Relativo à soma dos pesos
+}
+
+// Amostra de tamanho fixo dos tempos de resposta, pela amostragem de
+// reservatório (algoritmo R): os primeiros size tempos são todos guardados,
+// e os percentis são exatos; a partir daí, o n-ésimo tempo substitui um dos
+// guardados com probabilidade size/n, mantendo uma amostra uniforme de todos
+// e a memória constante para qualquer -requests
+type latencyReservoir struct {
+	size    int
+	seen    int // Tempos recebidos, inclusive os descartados
+	samples []time.Duration
+	rng     *rand.Rand // Sorteio das substituições, nil usa o gerador global
+}
+
+func newLatencyReservoir(size int) *latencyReservoir {
+	return &latencyReservoir{size: size, samples: make([]time.Duration, 0, min(size, defaultLatencySampleSize))}
+}
+
+func (r *latencyReservoir) add(d time.Duration) {
+	r.seen++
+	if len(r.samples) < r.size {
+		r.samples = append(r.samples, d)
+		return
+	}
+	var i int
+	if r.rng != nil {
+		i = r.rng.IntN(r.seen)
+	} else {
+		i = rand.IntN(r.seen)
+	}
+	if i < r.size {
+		r.samples[i] = d
+	}
+}
+
+// Método de cálculo dos percentis da amostra
+func (r *latencyReservoir) method() string {
+	if r.seen > r.size {
+		return percentileReservoir
+	}
+	return percentileExact
+}
+
+// Percentis da amostra, na ordem informada
+func (r *latencyReservoir) percentiles(ps ...float64) []time.Duration {
+	sorted := slices.Sorted(slices.Values(r.samples))
+	out := make([]time.Duration, len(ps))
+	for i, p := range ps {
+		out[i] = percentile(sorted, p)
+	}
+	return out
+}
+
+// Executa requests consultas em cada API configurada, percorrendo os CEPs da
+// amostra, e exibe a comparação dos tempos de resposta (p50, p95 e p99) e da
+// taxa de erro. As APIs são medidas em paralelo, com uma consulta por vez em
+// cada uma, sem novas tentativas nem circuit breaker (como no healthcheck).
+func runBench(opts *options) int {
+	ceps := opts.benchCEPs
+	if opts.file != "" {
+		file, err := readBatchFile(opts.file, opts.inputFormat, opts.inputColumn)
+		if err != nil {
+			slog.Error(tr("Falha ao ler a amostra do bench"), "erro", err)
+			return 1
+		}
+		ceps = file.ceps
+	}
+	if len(ceps) == 0 {
+		slog.Error(tr("Nenhum CEP na amostra do bench"))
+		return 1
+	}
+
+	providers := opts.client.Providers
+	var stats []benchStats
+	if opts.benchSplit != nil {
+		stats = benchSplit(providers, opts.benchSplit, ceps, opts.benchRequests, opts.benchSamples, opts.timeout)
+	} else {
+		stats = make([]benchStats, len(providers))
+		var wg sync.WaitGroup
+		for i, p := range providers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				stats[i] = benchProvider(p, ceps, opts.benchRequests, opts.benchSamples, opts.timeout)
+			}()
+		}
+		wg.Wait()
+	}
+
+	// As APIs mais rápidas primeiro; as que só falharam, por último
+	slices.SortStableFunc(stats, func(a, b benchStats) int {
+		if (a.Errors == a.Total) != (b.Errors == b.Total) {
+			if a.Errors == a.Total {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(a.P50, b.P50)
+	})
+	displayBench(stats, len(ceps), opts)
+	return 0
+}
+
+// Mede requests consultas na API, uma por vez, em rodízio pelos CEPs,
+// guardando no máximo sampleSize tempos de resposta
+func benchProvider(p cep.Provider, ceps []string, requests, sampleSize int, timeout time.Duration) benchStats {
+	r := newBenchRecorder(p.Name(), sampleSize)
+	for i := range requests {
+		r.record(checkProvider(p, ceps[i%len(ceps)], timeout))
+	}
+	return r.finish()
+}
+
+// Modo A/B de -provider-sample: sorteia cada uma das requests consultas, em
+// rodízio pelos CEPs, para uma única API, na proporção dos pesos, e mede só
+// ela. As consultas são feitas uma por vez, sem a corrida nem outra API
+// disputando a rede, e as estatísticas de cada API reúnem as consultas
+// sorteadas para ela. providers e arms estão na mesma ordem.
+func benchSplit(providers []cep.Provider, arms []benchArm, ceps []string, requests, sampleSize int, timeout time.Duration) []benchStats {
+	var total float64
+	recorders := make([]*benchRecorder, len(providers))
+	for i, p := range providers {
+		recorders[i] = newBenchRecorder(p.Name(), sampleSize)
+		total += arms[i].weight
+	}
+	for i := range requests {
+		draw := rand.Float64() * total
+		k := 0
+		for k < len(arms)-1 && draw >= arms[k].weight {
+			draw -= arms[k].weight
+			k++
+		}
+		recorders[k].record(checkProvider(providers[k], ceps[i%len(ceps)], timeout))
+	}
+
+	stats := make([]benchStats, len(recorders))
+	for i, r := range recorders {
+		stats[i] = r.finish()
+		stats[i].Share = arms[i].weight / total
+	}
+	return stats
+}
+
+// Estatísticas de uma API em formação durante o bench
+type benchRecorder struct {
+	stats     benchStats
+	latencies *latencyReservoir
+}
+
+func newBenchRecorder(api string, sampleSize int) *benchRecorder {
+	return &benchRecorder{stats: benchStats{API: api}, latencies: newLatencyReservoir(sampleSize)}
+}
+
+// Registra o desfecho de uma consulta
+func (r *benchRecorder) record(h providerHealth) {
+	r.stats.Total++
+	if h.Status != "ok" {
+		r.stats.Errors++
+		slog.Debug("Bench: consulta com erro", "api", h.API, "status", h.Status, "erro", h.Erro)
+		return
+	}
+	r.latencies.add(h.Elapsed)
+}
+
+// Calcula os percentis e a taxa de erro das consultas registradas
+func (r *benchRecorder) finish() benchStats {
+	stats := r.stats
+	pcts := r.latencies.percentiles(0.50, 0.95, 0.99)
+	stats.P50, stats.P95, stats.P99 = pcts[0], pcts[1], pcts[2]
+	stats.Method, stats.Samples = r.latencies.method(), len(r.latencies.samples)
+	stats.P50MS = float64(stats.P50.Microseconds()) / 1000
+	stats.P95MS = float64(stats.P95.Microseconds()) / 1000
+	stats.P99MS = float64(stats.P99.Microseconds()) / 1000
+	if stats.Total > 0 {
+		stats.ErrRate = float64(stats.Errors) / float64(stats.Total)
+	}
+	return stats
+}
+
+// Percentil pelo método do posto mais próximo, sobre os tempos ordenados
+// (0 sem nenhum tempo)
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// Exibe a comparação: em JSON, uma lista com as estatísticas de cada API;
+// nos demais formatos, uma tabela
+func displayBench(stats []benchStats, samples int, opts *options) {
+	if opts.format == "json" {
+		if err := json.NewEncoder(os.Stdout).Encode(stats); err != nil {
+			slog.Error(tr("Erro ao gerar a saída em JSON"), "erro", err)
+		}
+		return
+	}
+
+	if opts.benchSplit != nil {
+		fmt.Print(tr("Bench A/B das APIs (%d consultas sorteadas entre %d APIs, %d CEPs na amostra)\n", opts.benchRequests, len(stats), samples))
+		fmt.Println("=============================")
+		fmt.Printf("  %-12s %14s %10s %10s %10s %7s\n", "API", tr("consultas"), "p50", "p95", "p99", tr("erros"))
+	} else {
+		fmt.Print(tr("Bench das APIs (%d consultas por API, %d CEPs na amostra)\n", opts.benchRequests, samples))
+		fmt.Println("=============================")
+		fmt.Printf("  %-12s %10s %10s %10s %7s\n", "API", "p50", "p95", "p99", tr("erros"))
+	}
+	for _, s := range stats {
+		row := fmt.Sprintf("  %-12s", s.API)
+		if opts.benchSplit != nil {
+			row += fmt.Sprintf(" %14s", fmt.Sprintf("%d (%.0f%%)", s.Total, s.Share*100))
+		}
+		if s.Errors == s.Total {
+			fmt.Printf("%s %10s %10s %10s %6.0f%%\n", row, "-", "-", "-", s.ErrRate*100)
+			continue
+		}
+		fmt.Printf("%s %10s %10s %10s %6.0f%%\n", row, roundElapsed(s.P50), roundElapsed(s.P95), roundElapsed(s.P99), s.ErrRate*100)
+	}
+	fmt.Println("=============================")
+	if slices.ContainsFunc(stats, func(s benchStats) bool { return s.Method == percentileReservoir }) {
+		fmt.Print(tr("Percentis estimados por amostragem de reservatório (até %d tempos por API)\n", opts.benchSamples))
+	} else {
+		fmt.Println(tr("Percentis exatos, sobre todos os tempos"))
+	}
+}
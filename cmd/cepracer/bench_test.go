@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Os percentis da amostra de reservatório ficam próximos dos exatos em uma
+// distribuição conhecida, com a memória limitada ao tamanho da amostra; com
+// todos os tempos na amostra, são os exatos
+func TestLatencyReservoir(t *testing.T) {
+	const n = 100000
+	rng := rand.New(rand.NewPCG(1, 2))
+	latencies := make([]time.Duration, n)
+	for i := range latencies {
+		latencies[i] = time.Duration(i+1) * time.Millisecond // Uniforme de 1ms a 100s
+	}
+	rng.Shuffle(n, func(i, j int) { latencies[i], latencies[j] = latencies[j], latencies[i] })
+	sorted := slices.Sorted(slices.Values(latencies))
+	ps := []float64{0.50, 0.95, 0.99}
+
+	tests := []struct {
+		name      string
+		size      int
+		method    string
+		tolerance time.Duration
+	}{
+		{"amostra", 2000, percentileReservoir, 4 * time.Second}, // 4% da faixa
+		{"todos os tempos", n, percentileExact, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newLatencyReservoir(tt.size)
+			r.rng = rand.New(rand.NewPCG(3, 4))
+			for _, d := range latencies {
+				r.add(d)
+			}
+			if len(r.samples) != tt.size || r.method() != tt.method {
+				t.Fatalf("%d tempos guardados pelo método %q, esperados %d pelo %q", len(r.samples), r.method(), tt.size, tt.method)
+			}
+			for i, got := range r.percentiles(ps...) {
+				exact := percentile(sorted, ps[i])
+				if diff := got - exact; diff > tt.tolerance || -diff > tt.tolerance {
+					t.Errorf("p%.0f = %v, exato %v (tolerância %v)", ps[i]*100, got, exact, tt.tolerance)
+				}
+			}
+		})
+	}
+}
+
+// No modo A/B, cada consulta vai para uma única API, sorteada na proporção
+// dos pesos, e as estatísticas de cada API somam só as consultas dela
+func TestBenchProviderSample(t *testing.T) {
+	const brasilAPIFound = `{"cep": "01001000", "state": "SP", "city": "São Paulo", "neighborhood": "Sé", "street": "Praça da Sé"}`
+	counted := func(body string, n *atomic.Int64) *httptest.Server {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n.Add(1)
+			io.WriteString(w, body)
+		}))
+		t.Cleanup(srv.Close)
+		return srv
+	}
+	var viaCEPHits, brasilAPIHits atomic.Int64
+	viaCEP := counted(viaCEPFound, &viaCEPHits)
+	brasilAPI := counted(brasilAPIFound, &brasilAPIHits)
+
+	const requests = 200
+	code, out := runCLI(t, "bench", "-format", "json", "-requests", "200", "-provider-sample", "viacep=3,brasilapi=1",
+		"-url", "viacep="+viaCEP.URL+"/%s", "-url", "brasilapi="+brasilAPI.URL+"/%s", "01001000")
+	if code != 0 {
+		t.Fatalf("código de saída = %d, esperado 0\n%s", code, out)
+	}
+	var stats []benchStats
+	if err := json.Unmarshal([]byte(out), &stats); err != nil || len(stats) != 2 {
+		t.Fatalf("saída = %s, esperadas as estatísticas de duas APIs (%v)", out, err)
+	}
+	byAPI := map[string]benchStats{}
+	for _, s := range stats {
+		byAPI[s.API] = s
+	}
+	via, brasil := byAPI["ViaCEP"], byAPI["Brasil API"]
+	if via.Total+brasil.Total != requests || via.Errors+brasil.Errors != 0 {
+		t.Fatalf("consultas = %d + %d com %d erros, esperadas %d sem erros", via.Total, brasil.Total, via.Errors+brasil.Errors, requests)
+	}
+	if int64(via.Total) != viaCEPHits.Load() || int64(brasil.Total) != brasilAPIHits.Load() {
+		t.Errorf("requisições = %d/%d, esperadas as sorteadas %d/%d", viaCEPHits.Load(), brasilAPIHits.Load(), via.Total, brasil.Total)
+	}
+	// Esperadas 150 para o ViaCEP, com desvio padrão próximo de 6
+	if via.Total < 110 || via.Total > 190 {
+		t.Errorf("ViaCEP sorteado %d de %d vezes, esperado perto de 3/4", via.Total, requests)
+	}
+	if via.Share != 0.75 || brasil.Share != 0.25 {
+		t.Errorf("frações = %v/%v, esperadas 0.75/0.25", via.Share, brasil.Share)
+	}
+}
+
+func TestParseProviderSample(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []benchArm
+		wantErr string
+	}{
+		{"viacep,brasilapi", []benchArm{{"viacep", 1}, {"brasilapi", 1}}, ""},
+		{" ViaCEP = 70 , brasilapi=30,", []benchArm{{"viacep", 70}, {"brasilapi", 30}}, ""},
+		{"viacep", nil, "ao menos duas APIs"},
+		{"viacep,viacep", nil, "repetida"},
+		{"viacep=0,brasilapi", nil, "peso inválido"},
+		{"viacep=abc,brasilapi", nil, "peso inválido"},
+		{"viacep,correios", nil, "desconhecida"},
+		{"viacep,unix", nil, "exige -unix-provider"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseProviderSample(tt.value, false)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("erro = %v, esperado com %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !slices.Equal(got, tt.want) {
+				t.Errorf("parseProviderSample(%q) = %v, %v; esperado %v", tt.value, got, err, tt.want)
+			}
+		})
+	}
+	for _, args := range [][]string{
+		{"-provider-sample", "viacep,brasilapi", "01001000"},
+		{"bench", "-provider-sample", "viacep,brasilapi", "-providers", "viacep"},
+	} {
+		if _, err := parseFlags(args); err == nil || !strings.Contains(err.Error(), "-provider-sample") {
+			t.Errorf("parseFlags(%q): erro = %v, esperada a recusa de -provider-sample", args, err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Tempo máximo das operações do subcomando cache, que no Redis percorrem
+// todas as chaves do namespace
+const cacheCommandTimeout = 30 * time.Second
+
+// Resumo do cache no subcomando cache stats em JSON
+type cacheStatsOutput struct {
+	Cache        string     `json:"cache"`
+	Entradas     int        `json:"entradas"`
+	TamanhoBytes int64      `json:"tamanho_bytes"`
+	MaisAntiga   *time.Time `json:"mais_antiga,omitempty"`
+	MaisRecente  *time.Time `json:"mais_recente,omitempty"`
+	Acertos      uint64     `json:"acertos"` // Desde o início do processo
+	Falhas       uint64     `json:"falhas"`
+}
+
+// Executa o subcomando cache: stats resume as entradas do cache de
+// -cache-file ou -cache-redis e clear as remove, após a confirmação no
+// terminal (ver confirm)
+func runCache(opts *options) int {
+	ctx, cancel := context.WithTimeout(context.Background(), cacheCommandTimeout)
+	defer cancel()
+	cache, name := opts.client.Cache, cacheName(opts)
+
+	if opts.cacheCommand == "clear" {
+		if !confirm(tr("Remover todas as entradas do cache %s?", name), opts.yes) {
+			slog.Warn(tr("Limpeza do cache cancelada"), "cache", name)
+			return 1
+		}
+		removed, err := cache.Clear(ctx)
+		if err != nil {
+			slog.Error(tr("Falha ao esvaziar o cache"), "cache", name, "erro", err)
+			return 1
+		}
+		if opts.format == "json" {
+			printCacheJSON(struct {
+				Cache     string `json:"cache"`
+				Removidas int    `json:"removidas"`
+			}{name, removed})
+			return 0
+		}
+		fmt.Print(tr("Cache %s esvaziado: %d entrada(s) removida(s)\n", name, removed))
+		return 0
+	}
+
+	info, err := cache.Info(ctx)
+	if err != nil {
+		slog.Error(tr("Falha ao ler o cache"), "cache", name, "erro", err)
+		return 1
+	}
+	hits, misses := cache.Stats()
+	if opts.format == "json" {
+		out := cacheStatsOutput{Cache: name, Entradas: info.Entries, TamanhoBytes: info.Size, Acertos: hits, Falhas: misses}
+		if !info.Oldest.IsZero() {
+			out.MaisAntiga, out.MaisRecente = &info.Oldest, &info.Newest
+		}
+		printCacheJSON(out)
+		return 0
+	}
+
+	fmt.Print(tr("Cache %s\n", name))
+	fmt.Println("=============================")
+	fmt.Print(tr("Entradas:     %d\n", info.Entries))
+	fmt.Print(tr("Tamanho:      %s\n", formatSize(info.Size)))
+	if !info.Oldest.IsZero() {
+		fmt.Print(tr("Mais antiga:  %s\n", info.Oldest.Local().Format(time.DateTime)))
+		fmt.Print(tr("Mais recente: %s\n", info.Newest.Local().Format(time.DateTime)))
+	}
+	if total := hits + misses; total > 0 {
+		fmt.Print(tr("Acertos:      %d de %d consulta(s) (%.0f%%)\n", hits, total, float64(hits)/float64(total)*100))
+	} else {
+		fmt.Println(tr("Acertos:      não registrados entre execuções"))
+	}
+	fmt.Println("=============================")
+	return 0
+}
+
+// Identificação do cache nas saídas do subcomando cache: o arquivo de
+// -cache-file ou a URL de -cache-redis, sem a senha
+func cacheName(opts *options) string {
+	if opts.cacheRedis == "" {
+		return opts.cacheFile
+	}
+	if u, err := url.Parse(opts.cacheRedis); err == nil {
+		return u.Redacted()
+	}
+	return opts.cacheRedis
+}
+
+func printCacheJSON(v any) {
+	if err := json.NewEncoder(os.Stdout).Encode(v); err != nil {
+		slog.Error(tr("Erro ao gerar a saída em JSON"), "erro", err)
+	}
+}
+
+// Tamanho em bytes para exibição (ex: 512 B, 4.2 KB, 1.5 MB)
+func formatSize(n int64) string {
+	switch {
+	case n < 1024:
+		return fmt.Sprintf("%d B", n)
+	case n < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	}
+	return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+}
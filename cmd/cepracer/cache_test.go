@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Grava um cache persistido com os CEPs informados em um diretório temporário
+func writeCacheFile(t *testing.T, ceps ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cep.db")
+	c, err := cep.LoadCache(path, time.Hour, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, code := range ceps {
+		if err := c.Set(context.Background(), code, &cep.Result{API: "ViaCEP", CEP: cep.Format(code), Cidade: "São Paulo"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+	return path
+}
+
+func TestRunCacheStats(t *testing.T) {
+	path := writeCacheFile(t, "01001000", "20040020")
+
+	code, out := runCLI(t, "cache", "-cache-file", path, "stats")
+	if code != 0 {
+		t.Fatalf("código de saída = %d, esperado 0", code)
+	}
+	for _, want := range []string{"Cache " + path, "Entradas:     2", "Tamanho:", "Mais antiga:", "Mais recente:"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("saída sem %q:\n%s", want, out)
+		}
+	}
+
+	code, out = runCLI(t, "cache", "-cache-file", path, "-format", "json", "stats")
+	var stats cacheStatsOutput
+	if err := json.Unmarshal([]byte(out), &stats); err != nil || code != 0 {
+		t.Fatalf("saída em JSON inválida (código %d): %v\n%s", code, err, out)
+	}
+	if stats.Entradas != 2 || stats.TamanhoBytes <= 0 || stats.MaisAntiga == nil || stats.MaisRecente == nil {
+		t.Errorf("stats = %+v, esperadas 2 entradas com tamanho e datas", stats)
+	}
+}
+
+func TestRunCacheClear(t *testing.T) {
+	path := writeCacheFile(t, "01001000", "20040020")
+
+	code, out := runCLI(t, "cache", "-cache-file", path, "clear")
+	if code != 0 || !strings.Contains(out, "2 entrada(s) removida(s)") {
+		t.Fatalf("cache clear = %d:\n%s\nesperadas 2 entradas removidas", code, out)
+	}
+	code, out = runCLI(t, "cache", "-cache-file", path, "stats")
+	if code != 0 || !strings.Contains(out, "Entradas:     0") {
+		t.Errorf("cache stats após clear = %d:\n%s\nesperado o cache vazio", code, out)
+	}
+}
+
+func TestCacheSubcommandArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"cache", "-cache-file", "cep.db"}, "cache stats ou cache clear"},
+		{[]string{"cache", "-cache-file", "cep.db", "purge"}, "cache stats ou cache clear"},
+		{[]string{"cache", "stats"}, "-cache-file ou -cache-redis"},
+		{[]string{"cache", "-cache-file", "cep.db", "-file", "ceps.txt", "stats"}, "não pode ser combinado"},
+	}
+	for _, tt := range tests {
+		if _, err := parseFlags(tt.args); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("parseFlags(%v) = %v, esperado erro com %q", tt.args, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sync"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Arquivo de fixtures em JSON (ex: cassette.json) com as respostas gravadas
+// das APIs
+type cassette struct {
+	Interactions []interaction `json:"interactions"`
+}
+
+// Resposta gravada de uma API para um CEP
+type interaction struct {
+	Provider string      `json:"provider"`
+	CEP      string      `json:"cep"`
+	Status   int         `json:"status"`
+	Proto    string      `json:"proto"`
+	Header   http.Header `json:"header,omitempty"`
+	Body     string      `json:"body"`
+}
+
+// Identifica o CEP (com ou sem hífen) no caminho da URL
+var cepInPath = regexp.MustCompile(`\d{5}-?\d{3}`)
+
+// Identifica a API e o CEP de uma requisição, usados como chave das interações
+func interactionKey(u *url.URL) (provider, code string) {
+	provider = u.Host
+	for _, p := range cep.RegisteredProviders() {
+		if pu, err := url.Parse(fmt.Sprintf(p.URL, "")); err == nil && pu.Host == u.Host {
+			provider = p.Name
+			break
+		}
+	}
+	return provider, cepInPath.FindString(u.Path)
+}
+
+// Transport que executa as requisições reais e grava as respostas no arquivo
+type recordingTransport struct {
+	next http.RoundTripper
+	path string
+
+	mu       sync.Mutex
+	cassette cassette
+}
+
+func newRecordingTransport(path string, next http.RoundTripper) *recordingTransport {
+	return &recordingTransport{next: next, path: path}
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lê o corpo para gravá-lo e o devolve intacto para quem fez a requisição
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	provider, cep := interactionKey(req.URL)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cassette.Interactions = append(t.cassette.Interactions, interaction{
+		Provider: provider,
+		CEP:      cep,
+		Status:   resp.StatusCode,
+		Proto:    resp.Proto,
+		Header:   resp.Header,
+		Body:     string(body),
+	})
+
+	// Salva a cada interação para não perder a gravação se o programa encerrar antes
+	if err := t.save(); err != nil {
+		return nil, fmt.Errorf("record: erro ao salvar %s: %v", t.path, err)
+	}
+	return resp, nil
+}
+
+func (t *recordingTransport) save() error {
+	data, err := json.MarshalIndent(t.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(t.path, data, 0o644)
+}
+
+// Transport que responde a partir das interações gravadas, sem acessar a rede
+type replayTransport struct {
+	interactions map[string]interaction
+}
+
+// Carrega o arquivo de fixtures para reprodução
+func newReplayTransport(path string) (*replayTransport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("replay: erro ao ler %s: %v", path, err)
+	}
+
+	var c cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("replay: arquivo %s inválido, esperado o JSON gravado com -record: %v", path, err)
+	}
+
+	t := &replayTransport{interactions: make(map[string]interaction, len(c.Interactions))}
+	for _, i := range c.Interactions {
+		t.interactions[i.Provider+"|"+i.CEP] = i
+	}
+	return t, nil
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	provider, cep := interactionKey(req.URL)
+	i, ok := t.interactions[provider+"|"+cep]
+	if !ok {
+		return nil, fmt.Errorf("replay: nenhuma interação gravada para %s (CEP %s)", provider, cep)
+	}
+
+	proto := i.Proto
+	if proto == "" {
+		proto = "HTTP/1.1"
+	}
+	major, minor, _ := http.ParseHTTPVersion(proto)
+
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", i.Status, http.StatusText(i.Status)),
+		StatusCode: i.Status,
+		Proto:      proto,
+		ProtoMajor: major,
+		ProtoMinor: minor,
+		Header:     i.Header,
+		Body:       io.NopCloser(bytes.NewBufferString(i.Body)),
+		Request:    req,
+	}, nil
+}
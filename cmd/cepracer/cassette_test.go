@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestInteractionKey(t *testing.T) {
+	tests := []struct {
+		url      string
+		provider string
+		cep      string
+	}{
+		{"https://viacep.com.br/ws/01001000/json/", "ViaCEP", "01001000"},
+		{"https://brasilapi.com.br/api/cep/v1/01001-000", "Brasil API", "01001-000"},
+		{"http://127.0.0.1:8080/cep/01001000", "127.0.0.1:8080", "01001000"},
+		{"http://127.0.0.1:8080/status", "127.0.0.1:8080", ""},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if provider, code := interactionKey(u); provider != tt.provider || code != tt.cep {
+			t.Errorf("interactionKey(%s) = %q, %q, esperado %q, %q", tt.url, provider, code, tt.provider, tt.cep)
+		}
+	}
+}
+
+// A consulta gravada com -record é reproduzida com -replay sem acessar a API
+func TestRecordReplay(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, viaCEPFound)
+	}))
+	t.Cleanup(srv.Close)
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	args := []string{"-providers", "viacep", "-url", "viacep=" + srv.URL + "/%s", "-format", "json"}
+
+	code, recorded := runCLI(t, append(args, "-record", path, "01001000")...)
+	if code != 0 {
+		t.Fatalf("código de saída da gravação = %d, esperado 0", code)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		t.Fatalf("cassette inválido: %v\n%s", err, data)
+	}
+	if len(c.Interactions) != 1 {
+		t.Fatalf("%d interações gravadas, esperada 1", len(c.Interactions))
+	}
+	if i := c.Interactions[0]; i.Provider != strings.TrimPrefix(srv.URL, "http://") || i.CEP != "01001000" || i.Status != http.StatusOK ||
+		i.Body != viaCEPFound || i.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("interação gravada = %+v", i)
+	}
+
+	code, replayed := runCLI(t, append(args, "-replay", path, "01001000")...)
+	if code != 0 {
+		t.Fatalf("código de saída da reprodução = %d, esperado 0", code)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("%d requisições à API, esperada apenas a da gravação", n)
+	}
+	var want, got map[string]any
+	if err := json.Unmarshal([]byte(recorded), &want); err != nil {
+		t.Fatalf("saída da gravação não é JSON: %v\n%s", err, recorded)
+	}
+	if err := json.Unmarshal([]byte(replayed), &got); err != nil {
+		t.Fatalf("saída da reprodução não é JSON: %v\n%s", err, replayed)
+	}
+	for _, field := range []string{"cep", "logradouro", "bairro", "cidade", "estado"} {
+		if got[field] != want[field] {
+			t.Errorf("%s reproduzido = %v, gravado %v", field, got[field], want[field])
+		}
+	}
+
+	// CEP fora do cassette falha sem acessar a API
+	code, _, stderr := runCLIStderr(t, append(args, "-replay", path, "20040020")...)
+	if code != 1 || !strings.Contains(stderr, "nenhuma interação gravada") {
+		t.Errorf("código de saída = %d, log = %q, esperada a falha da interação ausente", code, stderr)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("%d requisições à API, esperada apenas a da gravação", n)
+	}
+}
+
+// Adapta uma função a http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// Cada resposta, inclusive as de erro, é gravada e reproduzida com o status
+// e o protocolo originais
+func TestReplayTransport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	rec := newRecordingTransport(path, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		status := http.StatusOK
+		if strings.Contains(req.URL.Path, "99999999") {
+			status = http.StatusNotFound
+		}
+		return &http.Response{
+			StatusCode: status,
+			Proto:      "HTTP/2.0",
+			Header:     http.Header{"X-Api": {req.URL.Host}},
+			Body:       io.NopCloser(strings.NewReader(req.URL.Path)),
+			Request:    req,
+		}, nil
+	}))
+	for _, u := range []string{"https://viacep.com.br/ws/01001000/json/", "https://viacep.com.br/ws/99999999/json/"} {
+		req, _ := http.NewRequest(http.MethodGet, u, nil)
+		resp, err := rec.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != req.URL.Path {
+			t.Errorf("corpo devolvido pela gravação = %q, esperado %q", body, req.URL.Path)
+		}
+	}
+
+	replay, err := newReplayTransport(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for u, status := range map[string]int{"https://viacep.com.br/ws/01001000/json/": http.StatusOK, "https://viacep.com.br/ws/99999999/json/": http.StatusNotFound} {
+		req, _ := http.NewRequest(http.MethodGet, u, nil)
+		resp, err := replay.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("%s: %v", u, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != status || resp.ProtoMajor != 2 || resp.Header.Get("X-Api") != "viacep.com.br" || string(body) != req.URL.Path {
+			t.Errorf("%s: resposta reproduzida = %d %s %v %q", u, resp.StatusCode, resp.Proto, resp.Header, body)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://brasilapi.com.br/api/cep/v1/01001000", nil)
+	if _, err := replay.RoundTrip(req); err == nil || !strings.Contains(err.Error(), "nenhuma interação gravada para Brasil API (CEP 01001000)") {
+		t.Errorf("erro = %v, esperada a interação ausente da Brasil API", err)
+	}
+}
+
+func TestReplayTransportInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+	if err := os.WriteFile(path, []byte("interactions:\n  - provider: ViaCEP\n    cep: \"01001000\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newReplayTransport(path); err == nil || !strings.Contains(err.Error(), "esperado o JSON gravado com -record") {
+		t.Errorf("erro = %v, esperado o arquivo inválido", err)
+	}
+	if _, err := newReplayTransport(filepath.Join(t.TempDir(), "ausente.json")); err == nil || !strings.Contains(err.Error(), "erro ao ler") {
+		t.Errorf("erro = %v, esperada a falha de leitura", err)
+	}
+}
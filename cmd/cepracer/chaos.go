@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Erro retornado pelas falhas injetadas via -chaos
+var errChaosInjected = errors.New("chaos: falha injetada")
+
+// Configuração de injeção de falhas de uma API. APENAS PARA TESTES.
+type chaosConfig struct {
+	failureRate float64       // Probabilidade (0 a 1) de a requisição falhar
+	latency     time.Duration // Latência adicionada antes da requisição
+}
+
+// Faz o parse do valor de -chaos: "api=taxa[:latência]" (ex: "viacep=0.3:200ms")
+func parseChaos(value string, into map[string]chaosConfig) error {
+	id, spec, found := strings.Cut(value, "=")
+	if !found || id == "" {
+		return fmt.Errorf("valor inválido para -chaos: %q (use api=taxa[:latência])", value)
+	}
+	if id != "unix" && !knownProvider(id) {
+		return fmt.Errorf("API desconhecida em -chaos: %q", id)
+	}
+
+	var cfg chaosConfig
+	rate, latency, _ := strings.Cut(spec, ":")
+	if rate != "" {
+		r, err := strconv.ParseFloat(rate, 64)
+		if err != nil || r < 0 || r > 1 {
+			return fmt.Errorf("taxa de falha inválida em -chaos: %q (use um valor entre 0 e 1)", rate)
+		}
+		cfg.failureRate = r
+	}
+	if latency != "" {
+		d, err := time.ParseDuration(latency)
+		if err != nil || d < 0 {
+			return fmt.Errorf("latência inválida em -chaos: %q", latency)
+		}
+		cfg.latency = d
+	}
+	into[id] = cfg
+	return nil
+}
+
+// Transport que injeta latência e falhas nas requisições das APIs configuradas.
+// Ferramenta de chaos engineering: APENAS PARA TESTES, desativada por padrão.
+type chaosTransport struct {
+	next    http.RoundTripper
+	configs map[string]chaosConfig
+}
+
+func (t *chaosTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	provider, _ := interactionKey(req.URL)
+	cfg, ok := t.configs[strings.ToLower(strings.ReplaceAll(provider, " ", ""))]
+	if ok {
+		// A latência injetada respeita o cancelamento do contexto
+		if cfg.latency > 0 {
+			timer := time.NewTimer(cfg.latency)
+			select {
+			case <-timer.C:
+			case <-req.Context().Done():
+				timer.Stop()
+				return nil, req.Context().Err()
+			}
+		}
+		if cfg.failureRate > 0 && rand.Float64() < cfg.failureRate {
+			return nil, errChaosInjected
+		}
+	}
+
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return next.RoundTrip(req)
+}
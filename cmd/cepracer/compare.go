@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Resultado do modo de comparação em JSON
+type compareOutput struct {
+	Concordam    bool                  `json:"concordam"`
+	Divergencias []cep.FieldDivergence `json:"divergencias,omitempty"`
+	Resultados   []jsonResult          `json:"resultados"`
+}
+
+// Aguarda a resposta de todas as APIs (dentro do timeout), em vez da corrida,
+// e informa, campo a campo, em que os resultados divergem
+func runCompare(code string, opts *options) int {
+	all, err := opts.client.LookupAll(context.Background(), code)
+	if err != nil {
+		slog.Error(tr("Falha na comparação"), "erro", err)
+		return exitCode(err)
+	}
+	for _, err := range all.Errs {
+		slog.Warn(tr("API falhou na comparação"), "erro", err)
+	}
+	if all.Timeout {
+		slog.Warn(tr("Timeout: nem todas as APIs responderam a tempo, comparando as que responderam"))
+	}
+
+	displayComparison(all.Results, cep.Compare(all.Results), opts)
+	return 0
+}
+
+// Exibe a comparação: o resultado único quando as APIs concordam ou todos
+// os resultados, com o relatório de divergências, quando diferem
+func displayComparison(results []*cep.Result, divergences []cep.FieldDivergence, opts *options) {
+	if opts.format == "json" {
+		out := compareOutput{Concordam: len(divergences) == 0, Divergencias: divergences}
+		for _, result := range results {
+			out.Resultados = append(out.Resultados, newJSONResult(result))
+		}
+		if err := json.NewEncoder(os.Stdout).Encode(out); err != nil {
+			slog.Error(tr("Erro ao gerar a saída em JSON"), "erro", err)
+		}
+		return
+	}
+
+	// Em CSV e com template, uma linha por API; as divergências vão para o log
+	if opts.format == "csv" || opts.format == "template" {
+		for _, d := range divergences {
+			slog.Warn(tr("Divergência entre as APIs"), "campo", d.Field, "valores", describeValues(d))
+		}
+		for _, result := range results {
+			if opts.format == "csv" {
+				printCSV(result)
+			} else {
+				printTemplate(result, opts.template)
+			}
+		}
+		return
+	}
+
+	if len(divergences) == 0 {
+		if opts.format == "oneline" {
+			fmt.Printf("%s (%s)\n", results[0].FormatAddress(), results[0].API)
+			return
+		}
+		fmt.Println(tr("Dados do CEP localizado"))
+		fmt.Println("=============================")
+		printFields(results[0], opts.fields, tr("API mais rápida"))
+		fmt.Println("=============================")
+		fmt.Print(tr("As %d APIs que responderam concordam nos campos principais\n", len(results)))
+		printLatencies(results)
+		return
+	}
+
+	if opts.format == "oneline" {
+		for _, d := range divergences {
+			slog.Warn(tr("Divergência entre as APIs"), "campo", d.Field, "valores", describeValues(d))
+		}
+		for _, result := range results {
+			fmt.Printf("%s (%s)\n", result.FormatAddress(), result.API)
+		}
+		return
+	}
+
+	for _, result := range results {
+		fmt.Println("=============================")
+		printFields(result, opts.fields, "API")
+	}
+	fmt.Println("=============================")
+	fmt.Print(tr("Divergências entre as %d APIs que responderam:\n", len(results)))
+	for _, d := range divergences {
+		fmt.Printf("  %s:\n", d.Field)
+		for _, v := range d.Values {
+			fmt.Printf("    %-12s %q\n", v.API+":", v.Value)
+		}
+	}
+	printLatencies(results)
+}
+
+// Exibe o tempo de resposta de cada API e a diferença para a primeira a responder
+func printLatencies(results []*cep.Result) {
+	if len(results) < 2 {
+		return
+	}
+	fmt.Println(tr("Tempos de resposta:"))
+	fmt.Printf("  %-12s %s\n", results[0].API+":", roundElapsed(results[0].Elapsed))
+	for _, other := range results[1:] {
+		fmt.Printf("  %-12s %s (%s)\n", other.API+":", roundElapsed(other.Elapsed), latencyDiff(results[0], other))
+	}
+}
+
+// Descreve os valores do campo divergente em uma linha
+// (ex: Brasil API "Centro", ViaCEP "Sé")
+func describeValues(d cep.FieldDivergence) string {
+	values := make([]string, len(d.Values))
+	for i, v := range d.Values {
+		values[i] = fmt.Sprintf("%s %q", v.API, v.Value)
+	}
+	return strings.Join(values, ", ")
+}
@@ -0,0 +1,409 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Prefixo das variáveis de ambiente que substituem as opções (ex:
+// CEPRACER_TIMEOUT=3s para -timeout, CEPRACER_CACHE_FILE para -cache-file)
+const envPrefix = "CEPRACER_"
+
+// Flags cujo valor é uma lista separada por vírgula: no arquivo, as listas
+// são unidas em um único valor, enquanto nas demais cada item é um valor
+// da flag (ex: -rate-limit, que pode ser repetida)
+var commaListFlags = []string{"providers", "fields"}
+
+// Chaves aceitas por API na seção providers do arquivo e a flag api=valor
+// correspondente
+var providerConfigFlags = map[string]string{
+	"url":        "url",
+	"timeout":    "provider-timeout",
+	"retries":    "provider-retries",
+	"rate-limit": "rate-limit",
+	"srv":        "srv-provider",
+	"header":     "provider-header",
+	"query":      "provider-query",
+	"token":      "provider-token",
+}
+
+// Chaves de uma API na seção providers que aceitam uma lista de valores
+// (ex: vários cabeçalhos)
+var providerListKeys = []string{"header", "query"}
+
+// Valor de uma flag lido do arquivo de configuração ou do ambiente
+type setting struct {
+	flag   string
+	value  string
+	source string // Origem do valor nas mensagens de erro (ex: "cep.yaml:3")
+}
+
+// Nome da variável de ambiente que substitui a flag
+func envName(flag string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// Aplica às flags não informadas na linha de comando os valores do arquivo
+// de configuração (path, vazio desativa) e das variáveis de ambiente. A
+// linha de comando prevalece sobre o ambiente, que prevalece sobre o
+// arquivo; a origem que prevalece substitui todos os valores da flag.
+func applyConfig(fs *flag.FlagSet, path string) error {
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	var env []setting
+	fromEnv := make(map[string]bool)
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == "config" {
+			return
+		}
+		if v, ok := os.LookupEnv(envName(f.Name)); ok {
+			env = append(env, setting{flag: f.Name, value: v, source: envName(f.Name)})
+			fromEnv[f.Name] = true
+		}
+	})
+
+	var file []setting
+	if path != "" {
+		s, err := loadConfigFile(path, fs)
+		if err != nil {
+			return err
+		}
+		file = s
+	}
+
+	for _, s := range file {
+		if !explicit[s.flag] && !fromEnv[s.flag] {
+			if err := s.apply(fs); err != nil {
+				return err
+			}
+		}
+	}
+	for _, s := range env {
+		if !explicit[s.flag] {
+			if err := s.apply(fs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Aplica o valor à flag, indicando a origem dele em caso de erro
+func (s setting) apply(fs *flag.FlagSet) error {
+	if err := fs.Set(s.flag, s.value); err != nil {
+		return fmt.Errorf("%s: -%s %q: %w", s.source, s.flag, s.value, err)
+	}
+	return nil
+}
+
+// Lê o arquivo de configuração, convertendo as chaves nas flags
+// correspondentes: "chave: valor" define a flag de mesmo nome, e uma seção
+// agrupa flags pelo prefixo (ex: ttl em cache define -cache-ttl) ou pares
+// api=valor (ex: viacep: 4 em provider-retries). Em providers, cada API
+// listada participa da corrida (exceto com enabled: false), com url,
+// timeout, retries, rate-limit, srv, header, query e token próprios.
+func loadConfigFile(path string, fs *flag.FlagSet) ([]setting, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao abrir o arquivo de configuração: %w", err)
+	}
+	defer f.Close()
+
+	root, err := parseConfig(f, path)
+	if err != nil {
+		return nil, err
+	}
+	if root.fields == nil && (root.value != "" || root.list != nil) {
+		return nil, fmt.Errorf("%s:%d: esperado um mapa de opções (chave: valor)", path, root.line)
+	}
+
+	var settings []setting
+	add := func(node *configNode, flag, value string) {
+		settings = append(settings, setting{flag: flag, value: value, source: fmt.Sprintf("%s:%d", path, node.line)})
+	}
+	addValues := func(node *configNode, flag, prefix string) {
+		if node.list == nil {
+			add(node, flag, prefix+node.value)
+			return
+		}
+		if slices.Contains(commaListFlags, flag) {
+			var values []string
+			for _, item := range node.list {
+				values = append(values, item.value)
+			}
+			add(node, flag, strings.Join(values, ","))
+			return
+		}
+		for _, item := range node.list {
+			add(item, flag, prefix+item.value)
+		}
+	}
+	unknown := func(node *configNode, key string) error {
+		return fmt.Errorf("%s:%d: opção desconhecida: %q", path, node.line, key)
+	}
+
+	for _, field := range root.fields {
+		key, node := field.key, field.node
+		switch {
+		case key == "config":
+			return nil, fmt.Errorf("%s:%d: config não pode ser definida no próprio arquivo", path, node.line)
+		case key == "providers" && node.fields != nil:
+			s, err := providerSettings(path, node)
+			if err != nil {
+				return nil, err
+			}
+			settings = append(settings, s...)
+		case node.fields != nil:
+			for _, sub := range node.fields {
+				if sub.node.fields != nil {
+					return nil, fmt.Errorf("%s:%d: seção aninhada não suportada em %s", path, sub.node.line, key)
+				}
+				switch {
+				case fs.Lookup(key+"-"+sub.key) != nil:
+					addValues(sub.node, key+"-"+sub.key, "")
+				case fs.Lookup(key) != nil:
+					addValues(sub.node, key, sub.key+"=")
+				default:
+					return nil, unknown(sub.node, key+"."+sub.key)
+				}
+			}
+		case fs.Lookup(key) != nil:
+			addValues(node, key, "")
+		default:
+			return nil, unknown(node, key)
+		}
+	}
+	return settings, nil
+}
+
+// Converte a seção providers do arquivo: as APIs habilitadas definem
+// -providers e as chaves de cada uma, as flags api=valor correspondentes
+func providerSettings(path string, section *configNode) ([]setting, error) {
+	var settings []setting
+	var enabled []string
+	for _, p := range section.fields {
+		id := p.key
+		on := true
+		for _, field := range p.node.fields {
+			source := fmt.Sprintf("%s:%d", path, field.node.line)
+			if field.node.fields != nil || (field.node.list != nil && !slices.Contains(providerListKeys, field.key)) {
+				return nil, fmt.Errorf("%s: esperado um valor em %s.%s", source, id, field.key)
+			}
+			if field.key == "enabled" {
+				v, err := strconv.ParseBool(field.node.value)
+				if err != nil {
+					return nil, fmt.Errorf("%s: valor inválido para enabled: %q (use true ou false)", source, field.node.value)
+				}
+				on = v
+				continue
+			}
+			flag, ok := providerConfigFlags[field.key]
+			if !ok {
+				return nil, fmt.Errorf("%s: opção desconhecida para a API %s: %q (use enabled, url, timeout, retries, rate-limit, srv, header, query ou token)", source, id, field.key)
+			}
+			if field.node.list != nil {
+				for _, item := range field.node.list {
+					settings = append(settings, setting{flag: flag, value: id + "=" + item.value, source: fmt.Sprintf("%s:%d", path, item.line)})
+				}
+				continue
+			}
+			settings = append(settings, setting{flag: flag, value: id + "=" + field.node.value, source: source})
+		}
+		if p.node.fields == nil && (p.node.value != "" || p.node.list != nil) {
+			return nil, fmt.Errorf("%s:%d: esperadas as opções da API %s (ex: timeout: 500ms)", path, p.node.line, id)
+		}
+		if on {
+			enabled = append(enabled, id)
+		}
+	}
+	if len(enabled) == 0 {
+		return nil, fmt.Errorf("%s:%d: nenhuma API habilitada em providers", path, section.line)
+	}
+	providers := setting{flag: "providers", value: strings.Join(enabled, ","), source: fmt.Sprintf("%s:%d", path, section.line)}
+	return append([]setting{providers}, settings...), nil
+}
+
+// Nó do arquivo de configuração: um valor, uma lista ou um mapa
+type configNode struct {
+	line   int
+	value  string        // Valor escalar, vazio em listas e mapas
+	list   []*configNode // Itens de uma lista ("- item" ou "[a, b]")
+	fields []configField // Chaves de um mapa, na ordem do arquivo
+}
+
+// Chave de um mapa do arquivo de configuração
+type configField struct {
+	key  string
+	node *configNode
+}
+
+// Linha significativa do arquivo, sem comentário e indentação
+type configLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+// Faz o parse do subconjunto de YAML aceito no arquivo de configuração:
+// mapas "chave: valor" aninhados por indentação com espaços, listas
+// ("- item" ou "[a, b]"), valores entre aspas e comentários com #. Demais
+// recursos do YAML (âncoras, blocos de texto, vários documentos) não são
+// suportados, evitando uma dependência externa.
+func parseConfig(r io.Reader, path string) (*configNode, error) {
+	var lines []configLine
+	scanner := bufio.NewScanner(r)
+	for num := 1; scanner.Scan(); num++ {
+		raw := stripComment(scanner.Text())
+		text := strings.TrimLeft(raw, " ")
+		if strings.TrimSpace(text) == "" || (num == 1 && text == "---") {
+			continue
+		}
+		if strings.HasPrefix(text, "\t") {
+			return nil, fmt.Errorf("%s:%d: use espaços, e não tabulação, na indentação", path, num)
+		}
+		lines = append(lines, configLine{num: num, indent: len(raw) - len(text), text: strings.TrimSpace(text)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("falha ao ler o arquivo de configuração: %w", err)
+	}
+	if len(lines) == 0 {
+		return &configNode{line: 1}, nil
+	}
+
+	root, rest, err := parseConfigBlock(lines, lines[0].indent)
+	if err == nil && len(rest) > 0 {
+		err = fmt.Errorf("linha %d: indentação inconsistente", rest[0].num)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return root, nil
+}
+
+// Lê um mapa ou uma lista cujas linhas têm a indentação informada,
+// retornando as linhas seguintes ao bloco
+func parseConfigBlock(lines []configLine, indent int) (*configNode, []configLine, error) {
+	node := &configNode{line: lines[0].num}
+	isList := isListItem(lines[0].text)
+	for len(lines) > 0 && lines[0].indent >= indent {
+		l := lines[0]
+		if l.indent > indent {
+			return nil, nil, fmt.Errorf("linha %d: indentação inesperada", l.num)
+		}
+		if isListItem(l.text) != isList {
+			return nil, nil, fmt.Errorf("linha %d: lista e mapa misturados no mesmo nível", l.num)
+		}
+		lines = lines[1:]
+
+		if isList {
+			value, err := configScalar(strings.TrimSpace(strings.TrimPrefix(l.text, "-")))
+			if err != nil {
+				return nil, nil, fmt.Errorf("linha %d: %w", l.num, err)
+			}
+			node.list = append(node.list, &configNode{line: l.num, value: value})
+			continue
+		}
+
+		key, value, ok := strings.Cut(l.text, ": ")
+		if !ok {
+			key, ok = strings.CutSuffix(l.text, ":")
+		}
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, nil, fmt.Errorf("linha %d: esperado chave: valor", l.num)
+		}
+
+		child := &configNode{line: l.num}
+		value = strings.TrimSpace(value)
+		switch {
+		case value != "":
+			if err := child.setValue(value); err != nil {
+				return nil, nil, fmt.Errorf("linha %d: %w", l.num, err)
+			}
+		case len(lines) > 0 && lines[0].indent > indent:
+			nested, rest, err := parseConfigBlock(lines, lines[0].indent)
+			if err != nil {
+				return nil, nil, err
+			}
+			nested.line = l.num
+			child, lines = nested, rest
+		}
+		node.fields = append(node.fields, configField{key: key, node: child})
+	}
+	return node, lines, nil
+}
+
+// Define o valor do nó a partir do texto após "chave:", aceitando listas
+// na forma [a, b]
+func (n *configNode) setValue(text string) error {
+	inner, ok := strings.CutPrefix(text, "[")
+	if !ok {
+		value, err := configScalar(text)
+		n.value = value
+		return err
+	}
+	inner, ok = strings.CutSuffix(inner, "]")
+	if !ok {
+		return errors.New("lista sem ] de fechamento")
+	}
+	n.list = []*configNode{}
+	for _, item := range strings.Split(inner, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		value, err := configScalar(item)
+		if err != nil {
+			return err
+		}
+		n.list = append(n.list, &configNode{line: n.line, value: value})
+	}
+	return nil
+}
+
+// Indica se a linha é um item de lista ("- item")
+func isListItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// Remove as aspas de um valor ("texto" ou 'texto')
+func configScalar(text string) (string, error) {
+	switch {
+	case len(text) >= 2 && text[0] == '"' && text[len(text)-1] == '"':
+		value, err := strconv.Unquote(text)
+		if err != nil {
+			return "", fmt.Errorf("valor entre aspas inválido: %s", text)
+		}
+		return value, nil
+	case len(text) >= 2 && text[0] == '\'' && text[len(text)-1] == '\'':
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	}
+	return text, nil
+}
+
+// Remove o comentário da linha: # no início ou após um espaço, fora de aspas
+func stripComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return strings.TrimRight(line[:i], " \t")
+		}
+	}
+	return strings.TrimRight(line, " \t")
+}
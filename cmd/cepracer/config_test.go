@@ -0,0 +1,173 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Representação compacta do nó para comparação: {chave: valor} nos mapas
+// e [a, b] nas listas
+func dumpConfig(n *configNode) string {
+	switch {
+	case n.fields != nil:
+		parts := make([]string, len(n.fields))
+		for i, f := range n.fields {
+			parts[i] = f.key + ": " + dumpConfig(f.node)
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	case n.list != nil:
+		parts := make([]string, len(n.list))
+		for i, item := range n.list {
+			parts[i] = dumpConfig(item)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	return n.value
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{"mapas aninhados", "timeout: 2s\ncache:\n  ttl: 1h\n  file: cep.db\nverbose: true\n", "{timeout: 2s, cache: {ttl: 1h, file: cep.db}, verbose: true}", ""},
+		{"dois níveis", "providers:\n  viacep:\n    timeout: 500ms\n  brasilapi:\n    enabled: false\n", "{providers: {viacep: {timeout: 500ms}, brasilapi: {enabled: false}}}", ""},
+		{"lista entre colchetes", "providers: [viacep, \"brasil api\", ]\n", "{providers: [viacep, brasil api]}", ""},
+		{"lista vazia", "fields: []\n", "{fields: []}", ""},
+		{"lista com hífen", "rate-limit:\n  - viacep=10/s\n  - 'brasilapi=5/s'\n", "{rate-limit: [viacep=10/s, brasilapi=5/s]}", ""},
+		{"# entre aspas", "user-agent: \"cep # racer\" # comentário\nformat: '{{.CEP}} #1'\n", "{user-agent: cep # racer, format: {{.CEP}} #1}", ""},
+		{"# sem espaço antes", "url: viacep=http://localhost/#%s\n", "{url: viacep=http://localhost/#%s}", ""},
+		{"comentários e separador de documento", "---\n# arquivo de exemplo\n\ntimeout: 1s # padrão\n  # comentário indentado\n", "{timeout: 1s}", ""},
+		{"aspas simples escapadas", "user-agent: 'it''s'\n", "{user-agent: it's}", ""},
+		{"arquivo vazio", "# só comentários\n", "", ""},
+		{"tabulação na indentação", "cache:\n\tttl: 1h\n", "", "cep.yaml:2: use espaços, e não tabulação"},
+		{"indentação maior no mesmo mapa", "cache:\n    ttl: 1h\n  file: cep.db\n", "", "linha 3: indentação inesperada"},
+		{"valor seguido de bloco", "timeout: 1s\n  retries: 2\n", "", "linha 2: indentação inesperada"},
+		{"indentação menor que a primeira linha", "  timeout: 1s\nretries: 2\n", "", "linha 2: indentação inconsistente"},
+		{"lista e mapa misturados", "providers:\n  - viacep\n  timeout: 1s\n", "", "linha 3: lista e mapa misturados"},
+		{"lista sem fechamento", "providers: [viacep, brasilapi\n", "", "linha 1: lista sem ] de fechamento"},
+		{"sem dois-pontos", "timeout 1s\n", "", "linha 1: esperado chave: valor"},
+		{"aspas inválidas", "user-agent: \"a\\qb\"\n", "", "linha 1: valor entre aspas inválido"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := parseConfig(strings.NewReader(tt.input), "cep.yaml")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("erro = %v, esperado com %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if got := dumpConfig(root); got != tt.want {
+				t.Errorf("parseConfig = %s, esperado %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// A linha de comando prevalece sobre o ambiente (CEPRACER_*), que prevalece
+// sobre o arquivo, e a origem que prevalece substitui todos os valores da
+// flag
+func TestApplyConfig(t *testing.T) {
+	const file = `timeout: 3s
+user-agent: "do arquivo"
+cache:
+  ttl: 1h
+rate-limit:
+  - viacep=10/s
+  - brasilapi=5/s
+`
+	tests := []struct {
+		name      string
+		args      []string
+		env       map[string]string
+		timeout   time.Duration
+		userAgent string
+		cacheTTL  time.Duration
+		limits    string
+	}{
+		{"só o arquivo", nil, nil, 3 * time.Second, "do arquivo", time.Hour, "viacep=10/s,brasilapi=5/s"},
+		{"ambiente sobre o arquivo", nil, map[string]string{"CEPRACER_TIMEOUT": "5s", "CEPRACER_CACHE_TTL": "2h", "CEPRACER_RATE_LIMIT": "opencep=1/s"},
+			5 * time.Second, "do arquivo", 2 * time.Hour, "opencep=1/s"},
+		{"linha de comando sobre o ambiente", []string{"-timeout", "7s", "-rate-limit", "postmon=2/s"}, map[string]string{"CEPRACER_TIMEOUT": "5s", "CEPRACER_USER_AGENT": "do ambiente"},
+			7 * time.Second, "do ambiente", time.Hour, "postmon=2/s"},
+	}
+	path := filepath.Join(t.TempDir(), "cep.yaml")
+	if err := os.WriteFile(path, []byte(file), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			fs := flag.NewFlagSet("cepracer", flag.ContinueOnError)
+			timeout := fs.Duration("timeout", time.Second, "")
+			userAgent := fs.String("user-agent", "padrão", "")
+			cacheTTL := fs.Duration("cache-ttl", 24*time.Hour, "")
+			var limits []string
+			fs.Func("rate-limit", "", func(v string) error {
+				limits = append(limits, v)
+				return nil
+			})
+			fs.String("config", "", "")
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := applyConfig(fs, path); err != nil {
+				t.Fatalf("applyConfig: %v", err)
+			}
+			got := fmt.Sprintf("%s %q %s %s", *timeout, *userAgent, *cacheTTL, strings.Join(limits, ","))
+			want := fmt.Sprintf("%s %q %s %s", tt.timeout, tt.userAgent, tt.cacheTTL, tt.limits)
+			if got != want {
+				t.Errorf("opções = %s, esperadas %s", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"opção desconhecida", "timeout: 1s\nretry: 2\n", `cep.yaml:2: opção desconhecida: "retry"`},
+		{"opção desconhecida em seção", "cache:\n  size: 10\n", `cep.yaml:2: opção desconhecida: "cache.size"`},
+		{"config no próprio arquivo", "config: outro.yaml\n", "cep.yaml:1: config não pode ser definida"},
+		{"lista na raiz", "- timeout\n", "cep.yaml:1: esperado um mapa de opções"},
+		{"valor inválido com a linha", "timeout: 1s\ncache:\n  ttl: depois\n", `cep.yaml:3: -cache-ttl "depois"`},
+		{"variável de ambiente inválida", "", `CEPRACER_TIMEOUT: -timeout "logo"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cep.yaml")
+			if err := os.WriteFile(path, []byte(tt.input), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if strings.HasPrefix(tt.wantErr, envPrefix) {
+				t.Setenv("CEPRACER_TIMEOUT", "logo")
+			}
+			fs := flag.NewFlagSet("cepracer", flag.ContinueOnError)
+			fs.Duration("timeout", time.Second, "")
+			fs.Duration("cache-ttl", 24*time.Hour, "")
+			fs.String("config", "", "")
+
+			err := applyConfig(fs, path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("erro = %v, esperado com %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Pede confirmação de uma operação destrutiva (como cache clear) na entrada
+// padrão. Com -yes, ou quando a entrada padrão não é um terminal (scripts e
+// pipes), confirma sem perguntar.
+func confirm(question string, yes bool) bool {
+	if yes || !stdinIsTerminal() {
+		return true
+	}
+	return promptConfirm(os.Stdin, os.Stderr, question)
+}
+
+// Exibe a pergunta em out e lê a resposta de in: apenas "s", "sim", "y" ou
+// "yes" (sem diferenciar maiúsculas) confirmam; a resposta vazia ou o fim
+// da entrada recusam
+func promptConfirm(in io.Reader, out io.Writer, question string) bool {
+	fmt.Fprint(out, question+tr(" [s/N] "))
+	line, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "s", "sim", "y", "yes":
+		return true
+	}
+	return false
+}
+
+// Indica se a entrada padrão é um terminal, e não um arquivo, um pipe ou o
+// dispositivo nulo (também de caracteres, como os terminais)
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	null, err := os.Stat(os.DevNull)
+	return err != nil || !os.SameFile(info, null)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPromptConfirm(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   bool
+	}{
+		{"s\n", true},
+		{"Sim\n", true},
+		{"  y  \n", true},
+		{"YES", true},
+		{"n\n", false},
+		{"\n", false},
+		{"", false}, // Fim da entrada
+		{"talvez\n", false},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		if got := promptConfirm(strings.NewReader(tt.answer), &out, "Remover?"); got != tt.want {
+			t.Errorf("resposta %q: confirmado = %v, esperado %v", tt.answer, got, tt.want)
+		}
+		if out.String() != "Remover? [s/N] " {
+			t.Errorf("pergunta = %q, esperado %q", out.String(), "Remover? [s/N] ")
+		}
+	}
+}
+
+// Nos testes, a entrada padrão não é um terminal: a confirmação é dispensada
+func TestConfirmWithoutTerminal(t *testing.T) {
+	if stdinIsTerminal() {
+		t.Skip("entrada padrão é um terminal")
+	}
+	if !confirm("Remover?", false) {
+		t.Error("confirmação pedida sem terminal na entrada padrão")
+	}
+}
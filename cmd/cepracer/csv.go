@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/csv"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Colunas da saída em CSV, na ordem de exibição
+var csvHeader = []string{"api", "cep", "logradouro", "bairro", "cidade", "estado", "origem", "tempo_resposta_ms", "erro"}
+
+// Colunas da saída em CSV com -authoritative: o rótulo ("mais_rapido" ou
+// "autoritativo") seguido das colunas de csvHeader
+var labeledCSVHeader = append([]string{"resultado"}, csvHeader...)
+
+// Colunas acrescentadas às do arquivo do lote com -preserve-input-column
+var preservedColumns = []string{"cidade", "estado", "logradouro", "bairro", "erro"}
+
+// Saída em CSV no stdout, com o cabeçalho escrito antes da primeira linha
+type csvOutput struct {
+	w      *csv.Writer
+	header bool
+}
+
+var stdoutCSV = &csvOutput{w: csv.NewWriter(os.Stdout)}
+
+// Escreve uma linha e a envia imediatamente, para que cada resultado do
+// lote apareça assim que estiver disponível
+func (o *csvOutput) write(record []string) {
+	o.writeWithHeader(csvHeader, record)
+}
+
+// Escreve uma linha, precedida do cabeçalho informado na primeira
+func (o *csvOutput) writeWithHeader(header, record []string) {
+	if !o.header {
+		o.w.Write(header)
+		o.header = true
+	}
+	o.w.Write(record)
+	o.w.Flush()
+	if err := o.w.Error(); err != nil {
+		slog.Error(tr("Erro ao gerar a saída em CSV"), "erro", err)
+	}
+}
+
+// Exibe o resultado como uma linha CSV
+func printCSV(result *cep.Result) {
+	stdoutCSV.write(csvRecord(result))
+}
+
+// Colunas de csvHeader para o resultado
+func csvRecord(result *cep.Result) []string {
+	return []string{
+		result.API,
+		result.CEP,
+		result.Logradouro,
+		result.Bairro,
+		result.Cidade,
+		result.Estado,
+		result.Origem,
+		strconv.FormatFloat(result.LatencyMS(), 'f', -1, 64),
+		"",
+	}
+}
+
+// Exibe o resultado como uma linha CSV precedida do rótulo (-authoritative)
+func printLabeledCSV(label string, result *cep.Result) {
+	stdoutCSV.writeWithHeader(labeledCSVHeader, append([]string{label}, csvRecord(result)...))
+}
+
+// Exibe a falha de um CEP como uma linha CSV, apenas com o CEP e o erro
+func printCSVError(code, text string) {
+	stdoutCSV.write([]string{"", code, "", "", "", "", "", "", text})
+}
+
+// Exibe a falha como uma linha CSV precedida do rótulo (-authoritative),
+// apenas com o CEP e o erro
+func printLabeledCSVError(label, code, text string) {
+	stdoutCSV.writeWithHeader(labeledCSVHeader, []string{label, "", code, "", "", "", "", "", "", text})
+}
+
+// Linha do lote com -preserve-input-column: as colunas originais do CEP
+// seguidas das de preservedColumns, em branco nas falhas, exceto o erro
+func preservedRow(item batchItem, errText string) []string {
+	row := slices.Clone(item.input)
+	if item.err != nil {
+		return append(row, "", "", "", "", errText)
+	}
+	r := item.result
+	return append(row, r.Cidade, r.Estado, r.Logradouro, r.Bairro, "")
+}
+
+// Exibe o CEP do lote como uma linha CSV com as colunas originais do
+// arquivo (-preserve-input-column), com o CEP mascarado quando -mask-cep
+// estiver ativo
+func printPreservedCSV(file *batchFile, item batchItem, opts *options) {
+	errText := ""
+	if item.err != nil {
+		errText = maskedErrorText(item.cep, item.err, opts)
+	}
+	row := preservedRow(item, errText)
+	if opts.maskCEP {
+		row[file.column] = maskedCEP(item.cep, opts)
+	}
+	stdoutCSV.writeWithHeader(slices.Concat(file.header, preservedColumns), row)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Consulta o estado e as cidades do DDD na Brasil API e os exibe
+func runDDD(opts *options) int {
+	info, err := opts.client.LookupDDD(context.Background(), opts.ddd)
+	if err != nil {
+		switch {
+		case errors.Is(err, cep.ErrDDDNotFound):
+			slog.Error(tr("DDD não encontrado"), "ddd", opts.ddd)
+			return 3
+		case errors.Is(err, context.DeadlineExceeded):
+			slog.Error(tr("Timeout: a Brasil API não respondeu a tempo"), "ddd", opts.ddd)
+			return 4
+		default:
+			slog.Error(tr("Falha na consulta do DDD"), "ddd", opts.ddd, "erro", err)
+			return 1
+		}
+	}
+
+	switch opts.format {
+	case "json":
+		out := struct {
+			*cep.DDDInfo
+			TempoRespostaMS float64 `json:"tempo_resposta_ms,omitempty"`
+		}{info, info.LatencyMS()}
+		if err := json.NewEncoder(os.Stdout).Encode(out); err != nil {
+			slog.Error(tr("Erro ao gerar a saída em JSON"), "erro", err)
+		}
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"ddd", "estado", "cidade"})
+		for _, city := range info.Cidades {
+			w.Write([]string{info.DDD, info.Estado, city})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			slog.Error(tr("Erro ao gerar a saída em CSV"), "erro", err)
+		}
+	case "oneline":
+		fmt.Printf("%s: %s\n", info.Estado, strings.Join(info.Cidades, ", "))
+	case "template":
+		printTemplate(info, opts.template)
+	default:
+		fmt.Print(tr("DDD %s - %s\n", info.DDD, info.Estado))
+		fmt.Println("=============================")
+		fmt.Print(tr("%d cidade(s) atendida(s):\n", len(info.Cidades)))
+		for _, city := range info.Cidades {
+			fmt.Println("  " + city)
+		}
+		fmt.Println("=============================")
+	}
+	return 0
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Consulta os dois CEPs simultaneamente, com as coordenadas ativas, e exibe
+// a distância em linha reta entre eles
+func runDistance(opts *options) int {
+	d, err := opts.client.Distance(context.Background(), opts.distanceCEPs[0], opts.distanceCEPs[1])
+	if err != nil {
+		if errors.Is(err, cep.ErrNoCoordinates) {
+			slog.Error(tr("Falha no cálculo da distância: coordenadas indisponíveis (ver -geocoder-url)"), "erro", err)
+			return 1
+		}
+		slog.Error(tr("Falha no cálculo da distância"), "erro", err)
+		return exitCode(err)
+	}
+
+	switch opts.format {
+	case "json":
+		out := struct {
+			From jsonResult `json:"origem"`
+			To   jsonResult `json:"destino"`
+			KM   float64    `json:"distancia_km"`
+		}{newJSONResult(d.From), newJSONResult(d.To), d.KM}
+		if err := json.NewEncoder(os.Stdout).Encode(out); err != nil {
+			slog.Error(tr("Erro ao gerar a saída em JSON"), "erro", err)
+		}
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"origem", "destino", "distancia_km"})
+		w.Write([]string{d.From.CEP, d.To.CEP, strconv.FormatFloat(d.KM, 'f', 3, 64)})
+		w.Flush()
+		if err := w.Error(); err != nil {
+			slog.Error(tr("Erro ao gerar a saída em CSV"), "erro", err)
+		}
+	case "oneline":
+		fmt.Printf("%s km\n", formatKM(d.KM))
+	case "template":
+		printTemplate(d, opts.template)
+	default:
+		fmt.Println(tr("Distância entre os CEPs"))
+		fmt.Println("=============================")
+		fmt.Print(tr("Origem: %s\n", d.From.FormatAddress()))
+		fmt.Print(tr("Destino: %s\n", d.To.FormatAddress()))
+		fmt.Print(tr("Distância: %s km (em linha reta)\n", formatKM(d.KM)))
+		fmt.Println("=============================")
+	}
+	return 0
+}
+
+// Formata a distância com uma casa decimal e vírgula (ex: 2,6), ou ponto
+// em inglês
+func formatKM(km float64) string {
+	s := strconv.FormatFloat(km, 'f', 1, 64)
+	if i := len(s) - 2; i > 0 && lang != "en" {
+		s = s[:i] + "," + s[i+1:]
+	}
+	return s
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Colunas da exportação do lote (-export), na ordem do arquivo. A área de
+// entrega (GeoJSON) fica de fora: não cabe em uma célula de planilha.
+var exportHeader = []string{
+	"cep_consultado", "api", "origem", "cep", "logradouro", "bairro", "cidade", "estado",
+	"ibge", "siafi", "ddd", "somente_municipio", "fuso",
+	"regiao", "mesorregiao", "microrregiao", "populacao",
+	"latitude", "longitude", "origem_coordenadas",
+	"cache", "tempo_resposta_ms", "erro",
+}
+
+// Colunas numéricas, gravadas como números (e não texto) no .xlsx
+var exportNumeric = map[string]bool{"populacao": true, "latitude": true, "longitude": true, "tempo_resposta_ms": true}
+
+// Linha da exportação para um CEP do lote: todos os campos do resultado ou,
+// em caso de falha, apenas o CEP consultado e o erro
+func exportRow(item batchItem) []string {
+	if item.err != nil {
+		row := make([]string, len(exportHeader))
+		row[0], row[len(row)-1] = item.cep, batchErrorText(item.err)
+		return row
+	}
+
+	r := item.result
+	var m cep.Municipality
+	if r.Municipality != nil {
+		m = *r.Municipality
+	}
+	number := func(v float64) string {
+		if v == 0 {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return []string{
+		item.cep, r.API, r.Origem, r.CEP, r.Logradouro, r.Bairro, r.Cidade, r.Estado,
+		r.IBGE, r.SIAFI, r.DDD, strconv.FormatBool(r.SomenteMunicipio), r.TimeZone,
+		m.Regiao, m.Mesorregiao, m.Microrregiao, number(float64(m.Populacao)),
+		number(r.Latitude), number(r.Longitude), r.CoordinatesSource,
+		strconv.FormatBool(r.Cached), strconv.FormatFloat(r.LatencyMS(), 'f', -1, 64), "",
+	}
+}
+
+// Valida o arquivo de -export: CSV ou planilha do Excel, pela extensão
+func checkExportPath(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv", ".xlsx":
+		return nil
+	}
+	return fmt.Errorf("arquivo inválido para -export: %q (use a extensão .csv ou .xlsx)", path)
+}
+
+// Grava as linhas do lote no arquivo de -export, em CSV ou .xlsx conforme
+// a extensão, com o cabeçalho na primeira linha (exportHeader ou, com
+// -preserve-input-column, as colunas do arquivo do lote)
+func writeExport(path string, header []string, rows [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("erro ao criar o arquivo de exportação: %v", err)
+	}
+	if strings.EqualFold(filepath.Ext(path), ".xlsx") {
+		err = writeXLSX(f, header, rows)
+	} else {
+		err = writeExportCSV(f, header, rows)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao gravar o arquivo de exportação %s: %v", path, err)
+	}
+	return nil
+}
+
+// CSV em UTF-8 com BOM, para que o Excel reconheça os acentos ao abri-lo
+func writeExportCSV(w io.Writer, header []string, rows [][]string) error {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+	cw.WriteAll(rows)
+	return cw.Error()
+}
+
+// Partes fixas do pacote .xlsx (Office Open XML) com uma única planilha
+var xlsxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="CEPs" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// Planilha do Excel (.xlsx) gerada sem dependências: um pacote zip com o
+// XML mínimo, o cabeçalho congelado e os textos inline nas células
+func writeXLSX(w io.Writer, header []string, rows [][]string) error {
+	zw := zip.NewWriter(w)
+	for _, part := range xlsxParts {
+		pw, err := zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(pw, xml.Header+part.content); err != nil {
+			return err
+		}
+	}
+
+	sw, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	b.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	b.WriteString(`<sheetData>`)
+	for i, row := range append([][]string{header}, rows...) {
+		fmt.Fprintf(&b, `<row r="%d">`, i+1)
+		for j, value := range row {
+			if value == "" {
+				continue
+			}
+			ref := xlsxColumn(j) + strconv.Itoa(i+1)
+			if _, err := strconv.ParseFloat(value, 64); err == nil && i > 0 && exportNumeric[header[j]] {
+				fmt.Fprintf(&b, `<c r="%s"><v>%s</v></c>`, ref, value)
+				continue
+			}
+			fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			xml.EscapeText(&b, []byte(value))
+			b.WriteString(`</t></is></c>`)
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	if _, err := io.WriteString(sw, b.String()); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Letra da coluna na planilha a partir do índice (0 é A, 26 é AA)
+func xlsxColumn(i int) string {
+	var name string
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Linhas de exportação de um CEP encontrado, com caracteres especiais do
+// XML, e de um que falhou
+func exportTestRows() [][]string {
+	found := batchItem{cep: "01001000", result: &cep.Result{
+		API: "ViaCEP", Origem: "viacep", CEP: "01001-000", Logradouro: `Praça <da> Sé & "Cia"`,
+		Bairro: "Sé", Cidade: "São Paulo", Estado: "SP", Elapsed: 1500 * time.Microsecond,
+	}}
+	failed := batchItem{cep: "99999999", err: errors.New("CEP não encontrado")}
+	return [][]string{exportRow(found), exportRow(failed)}
+}
+
+func TestXLSXColumn(t *testing.T) {
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{0, "A"}, {25, "Z"}, {26, "AA"}, {27, "AB"}, {51, "AZ"}, {52, "BA"}, {701, "ZZ"}, {702, "AAA"},
+	}
+	for _, tt := range tests {
+		if got := xlsxColumn(tt.index); got != tt.want {
+			t.Errorf("xlsxColumn(%d) = %q, esperado %q", tt.index, got, tt.want)
+		}
+	}
+}
+
+// Célula da planilha: texto inline (t="inlineStr") ou número
+type xlsxCell struct {
+	Ref    string `xml:"r,attr"`
+	Type   string `xml:"t,attr"`
+	Value  string `xml:"v"`
+	Inline string `xml:"is>t"`
+}
+
+func TestWriteXLSX(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ceps.xlsx")
+	if err := writeExport(path, exportHeader, exportTestRows()); err != nil {
+		t.Fatalf("writeExport: %v", err)
+	}
+
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("arquivo não é um zip: %v", err)
+	}
+	defer zr.Close()
+	parts := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[f.Name] = string(data)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("parte %s ausente do pacote", name)
+		}
+	}
+
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	if !strings.Contains(sheet, "Praça &lt;da&gt; Sé &amp; &#34;Cia&#34;") {
+		t.Errorf("logradouro sem o escape do XML na planilha:\n%s", sheet)
+	}
+	var doc struct {
+		Rows []struct {
+			Ref   string     `xml:"r,attr"`
+			Cells []xlsxCell `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal([]byte(sheet), &doc); err != nil {
+		t.Fatalf("planilha com XML inválido: %v", err)
+	}
+	if len(doc.Rows) != 3 {
+		t.Fatalf("%d linhas na planilha, esperados o cabeçalho e 2 CEPs", len(doc.Rows))
+	}
+	cell := func(row int, column string) xlsxCell {
+		for _, c := range doc.Rows[row].Cells {
+			if c.Ref == fmt.Sprintf("%s%d", column, row+1) {
+				return c
+			}
+		}
+		return xlsxCell{}
+	}
+	column := func(name string) string {
+		return xlsxColumn(slices.Index(exportHeader, name))
+	}
+
+	if c := cell(0, "A"); c.Type != "inlineStr" || c.Inline != "cep_consultado" {
+		t.Errorf("A1 = %+v, esperado o cabeçalho em texto", c)
+	}
+	if c := cell(1, column("logradouro")); c.Inline != `Praça <da> Sé & "Cia"` {
+		t.Errorf("logradouro = %q, esperado o texto original", c.Inline)
+	}
+	if c := cell(1, column("tempo_resposta_ms")); c.Type != "" || c.Value != "1.5" {
+		t.Errorf("tempo de resposta = %+v, esperado o número 1.5", c)
+	}
+	// O CEP que falhou traz apenas o CEP consultado e o erro; as células
+	// vazias são omitidas
+	if cells := doc.Rows[2].Cells; len(cells) != 2 || cells[0].Inline != "99999999" || cell(2, column("erro")).Inline != "CEP não encontrado" {
+		t.Errorf("linha do CEP que falhou = %+v, esperados o CEP e o erro", cells)
+	}
+}
+
+// Com as colunas do lote (-preserve-input-column), o cabeçalho pode passar
+// da coluna Z
+func TestWriteXLSXWideHeader(t *testing.T) {
+	header := make([]string, 28)
+	for i := range header {
+		header[i] = fmt.Sprintf("coluna%d", i+1)
+	}
+	row := slices.Clone(header)
+	row[27] = "última"
+
+	var buf bytes.Buffer
+	if err := writeXLSX(&buf, header, [][]string{row}); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := zr.Open("xl/worksheets/sheet1.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`<c r="Z1" t="inlineStr">`, `<c r="AA1" t="inlineStr">`, `<c r="AB2" t="inlineStr"><is><t xml:space="preserve">última</t>`} {
+		if !strings.Contains(string(sheet), want) {
+			t.Errorf("planilha sem %s", want)
+		}
+	}
+}
+
+func TestWriteExportCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ceps.csv")
+	if err := writeExport(path, exportHeader, exportTestRows()); err != nil {
+		t.Fatalf("writeExport: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, ok := strings.CutPrefix(string(data), "\ufeff")
+	if !ok {
+		t.Error("CSV sem o BOM do UTF-8")
+	}
+
+	records, err := csv.NewReader(strings.NewReader(text)).ReadAll()
+	if err != nil {
+		t.Fatalf("CSV inválido: %v", err)
+	}
+	if len(records) != 3 || !slices.Equal(records[0], exportHeader) {
+		t.Fatalf("CSV = %q, esperados o cabeçalho e 2 CEPs", records)
+	}
+	if found := records[1]; found[0] != "01001000" || found[4] != `Praça <da> Sé & "Cia"` || found[len(found)-1] != "" {
+		t.Errorf("linha do CEP encontrado = %q", found)
+	}
+	failed := records[2]
+	if failed[0] != "99999999" || failed[len(failed)-1] != "CEP não encontrado" {
+		t.Errorf("linha do CEP que falhou = %q, esperados o CEP e o erro", failed)
+	}
+	if filled := slices.DeleteFunc(slices.Clone(failed[1:len(failed)-1]), func(v string) bool { return v == "" }); len(filled) > 0 {
+		t.Errorf("colunas do resultado preenchidas no CEP que falhou: %q", filled)
+	}
+}
+
+func TestCheckExportPath(t *testing.T) {
+	for path, valid := range map[string]bool{"ceps.csv": true, "CEPS.XLSX": true, "ceps.xls": false, "ceps": false} {
+		if err := checkExportPath(path); (err == nil) != valid {
+			t.Errorf("checkExportPath(%q) = %v, válido: %v", path, err, valid)
+		}
+	}
+}
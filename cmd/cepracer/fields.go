@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Campos disponíveis na saída em texto, na ordem padrão de exibição
+var textFields = []string{"api", "cep", "logradouro", "bairro", "cidade", "estado", "origem", "ibge", "municipio", "area", "fuso", "coordenadas", "tempo"}
+
+// Faz o parse da lista ordenada de campos de -fields (ex: "cidade,estado")
+func parseFields(value string) ([]string, error) {
+	var fields []string
+	for _, f := range strings.Split(value, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" {
+			continue
+		}
+		if !isTextField(f) {
+			return nil, fmt.Errorf("campo desconhecido em -fields: %q (disponíveis: %s)", f, strings.Join(textFields, ", "))
+		}
+		fields = append(fields, f)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("nenhum campo informado em -fields")
+	}
+	return fields, nil
+}
+
+func isTextField(name string) bool {
+	for _, f := range textFields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Exibe os campos do resultado. Sem lista explícita, usa a ordem padrão e
+// omite os campos opcionais que não foram preenchidos.
+func printFields(result *cep.Result, fields []string, apiLabel string) {
+	explicit := fields != nil
+	if !explicit {
+		fields = textFields
+	}
+
+	for _, f := range fields {
+		if !explicit && optionalFieldEmpty(result, f) {
+			continue
+		}
+		fmt.Println(fieldLine(result, f, apiLabel))
+	}
+	if c := result.Confidence; c != nil {
+		fmt.Println(tr("Confiança: %s (%d de %d APIs concordam)", confidenceLabel(c.Level), c.Agreeing, c.Responded))
+	}
+	switch {
+	case result.Origem == "offline":
+		fmt.Println(tr("Aviso: nenhuma API respondeu, resultado aproximado da base offline"))
+	case result.SomenteMunicipio:
+		fmt.Println(tr("Aviso: CEP não localizado, resultado apenas em nível de município"))
+	}
+	if result.Cached {
+		fmt.Println(tr("Resultado obtido do cache"))
+	}
+}
+
+// Nível de confiança para exibição em texto
+func confidenceLabel(level string) string {
+	switch level {
+	case cep.ConfidenceHigh:
+		return tr("alta")
+	case cep.ConfidenceMedium:
+		return tr("média")
+	}
+	return tr("baixa")
+}
+
+// Indica se o campo é opcional e não foi preenchido no resultado
+func optionalFieldEmpty(result *cep.Result, field string) bool {
+	switch field {
+	case "ibge":
+		return result.IBGE == "" && result.SIAFI == "" && result.DDD == ""
+	case "municipio":
+		return result.Municipality == nil
+	case "area":
+		return result.Geometry == nil
+	case "fuso":
+		return result.TimeZone == ""
+	case "coordenadas":
+		return !result.HasCoordinates()
+	case "tempo":
+		return result.Elapsed == 0
+	}
+	return false
+}
+
+// Formata a linha de exibição de um campo
+func fieldLine(result *cep.Result, field, apiLabel string) string {
+	switch field {
+	case "api":
+		return fmt.Sprintf("%s: %s", apiLabel, result.API)
+	case "cep":
+		return fmt.Sprintf("CEP: %s", result.CEP)
+	case "logradouro":
+		return tr("Logradoruo: %s", result.Logradouro)
+	case "bairro":
+		return tr("Bairro: %s", result.Bairro)
+	case "cidade":
+		return tr("Cidade: %s", result.Cidade)
+	case "estado":
+		return tr("Estado: %s", result.Estado)
+	case "origem":
+		return tr("Origem: %s", result.Origem)
+	case "ibge":
+		return tr("Códigos: %s", joinLabeled("IBGE", result.IBGE, "SIAFI", result.SIAFI, "DDD", result.DDD))
+	case "municipio":
+		m := result.Municipality
+		if m == nil {
+			return tr("Município (IBGE): ")
+		}
+		population := ""
+		if m.Populacao > 0 {
+			population = tr("%s habitantes (Censo 2022)", formatThousands(m.Populacao))
+		}
+		return tr("Município (IBGE): %s", strings.Join(slices.DeleteFunc([]string{m.Regiao, m.Mesorregiao, population}, func(v string) bool { return v == "" }), ", "))
+	case "area":
+		if result.Geometry == nil {
+			return tr("Área de entrega: ")
+		}
+		return tr("Área de entrega: %s", cep.GeometryType(result.Geometry))
+	case "fuso":
+		if result.TimeZone != "" && cep.TimeZoneAmbiguous(result.Estado) {
+			return tr("Fuso horário: %s (predominante, o estado possui mais de um fuso)", result.TimeZone)
+		}
+		return tr("Fuso horário: %s", result.TimeZone)
+	case "coordenadas":
+		if !result.HasCoordinates() {
+			return tr("Coordenadas: ")
+		}
+		return tr("Coordenadas: %s, %s (%s)", formatCoordinate(result.Latitude), formatCoordinate(result.Longitude), result.CoordinatesSource)
+	case "tempo":
+		return tr("Tempo de resposta: %s", roundElapsed(result.Elapsed))
+	}
+	return ""
+}
+
+// Junta os pares rótulo/valor não vazios (ex: "IBGE 3550308, DDD 11")
+func joinLabeled(pairs ...string) string {
+	var parts []string
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] != "" {
+			parts = append(parts, pairs[i]+" "+pairs[i+1])
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Formata o número com separador de milhar (ex: 11.451.999, ou 11,451,999
+// em inglês)
+func formatThousands(n int) string {
+	sep := "."
+	if lang == "en" {
+		sep = ","
+	}
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + sep + s[i:]
+	}
+	return s
+}
+
+// Formata latitude ou longitude com a precisão informada pela fonte
+func formatCoordinate(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// Arredonda o tempo de resposta para milissegundos, mantendo a precisão de
+// microssegundos abaixo de 1ms (ex: respostas reproduzidas de fixtures)
+func roundElapsed(d time.Duration) time.Duration {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond)
+	}
+	return d.Round(time.Millisecond)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// CEP consultado pelo healthcheck quando nenhum é informado: a Praça da Sé,
+// conhecido por todas as APIs
+const healthcheckCEP = "01001000"
+
+// Estado de uma API verificado pelo healthcheck
+type providerHealth struct {
+	API     string        `json:"api"`
+	Status  string        `json:"status"` // "ok", "invalida" (respondeu com dados inconsistentes) ou "erro"
+	Elapsed time.Duration `json:"-"`
+	TempoMS float64       `json:"tempo_ms"`
+	Erro    string        `json:"erro,omitempty"`
+}
+
+// Saída do healthcheck em JSON
+type healthcheckOutput struct {
+	CEP  string           `json:"cep"`
+	OK   bool             `json:"ok"`
+	APIs []providerHealth `json:"apis"`
+}
+
+// Consulta o CEP em cada API configurada, uma única vez e sem novas
+// tentativas nem circuit breaker, exibindo o estado, o tempo de resposta e
+// a validade da resposta de cada uma. Retorna 1 se alguma API não estiver ok,
+// para uso em monitoramento.
+func runHealthcheck(code string, opts *options) int {
+	providers := opts.client.Providers
+	health := make([]providerHealth, len(providers))
+	var wg sync.WaitGroup
+	for i, p := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			health[i] = checkProvider(p, code, opts.timeout)
+		}()
+	}
+	wg.Wait()
+
+	healthy := 0
+	for _, h := range health {
+		if h.Status == "ok" {
+			healthy++
+		} else {
+			slog.Warn(tr("Healthcheck: API indisponível"), "api", h.API, "status", h.Status, "erro", h.Erro)
+		}
+	}
+	displayHealth(code, health, healthy, opts)
+	if healthy < len(health) {
+		return 1
+	}
+	return 0
+}
+
+// Consulta o CEP na API, limitada pelo timeout, e valida a resposta
+func checkProvider(p cep.Provider, code string, timeout time.Duration) providerHealth {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	result, err := p.Fetch(ctx, code)
+	elapsed := time.Since(start)
+
+	h := providerHealth{API: p.Name(), Status: "ok", Elapsed: elapsed, TempoMS: float64(elapsed.Microseconds()) / 1000}
+	if err == nil {
+		err = validateHealth(result, code)
+		if err != nil {
+			h.Status = "invalida"
+		}
+	} else {
+		h.Status = "erro"
+	}
+	if err != nil {
+		h.Erro = err.Error()
+	}
+	return h
+}
+
+// Verifica se a resposta da API corresponde ao CEP consultado e traz ao
+// menos a cidade e o estado, consistente com a faixa do CEP
+func validateHealth(result *cep.Result, code string) error {
+	if got, err := cep.Normalize(result.CEP); err != nil || got != code {
+		return errors.New(tr("%s: CEP divergente na resposta: %q", result.API, result.CEP))
+	}
+	if result.Cidade == "" || result.Estado == "" {
+		return errors.New(tr("%s: resposta sem cidade ou estado", result.API))
+	}
+	if want, ok := cep.StateOf(code); ok && !strings.EqualFold(strings.TrimSpace(result.Estado), want) {
+		return errors.New(tr("%s: estado %s inconsistente com a faixa do CEP (esperado %s)", result.API, result.Estado, want))
+	}
+	return nil
+}
+
+// Exibe o estado das APIs: em JSON, um único objeto; nos demais formatos,
+// uma linha por API
+func displayHealth(code string, health []providerHealth, healthy int, opts *options) {
+	if opts.format == "json" {
+		out := healthcheckOutput{CEP: code, OK: healthy == len(health), APIs: health}
+		if err := json.NewEncoder(os.Stdout).Encode(out); err != nil {
+			slog.Error(tr("Erro ao gerar a saída em JSON"), "erro", err)
+		}
+		return
+	}
+
+	fmt.Print(tr("Healthcheck das APIs (CEP %s)\n", cep.Format(code)))
+	fmt.Println("=============================")
+	for _, h := range health {
+		if h.Erro != "" {
+			fmt.Printf("  %-12s %-9s %s (%s)\n", h.API+":", h.Status, roundElapsed(h.Elapsed), h.Erro)
+		} else {
+			fmt.Printf("  %-12s %-9s %s\n", h.API+":", h.Status, roundElapsed(h.Elapsed))
+		}
+	}
+	fmt.Println("=============================")
+	fmt.Print(tr("%d de %d APIs operacionais\n", healthy, len(health)))
+}
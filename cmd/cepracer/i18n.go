@@ -0,0 +1,254 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Idioma das mensagens exibidas ao usuário (saída em texto, log e erros do
+// servidor): "pt" (padrão) ou "en", definido por -lang ou pelo ambiente
+var lang = detectLang()
+
+// Traduções para o inglês, indexadas pela mensagem original em português
+// (que também é o formato de fmt). Mensagens sem tradução são exibidas em
+// português.
+var messagesEN = map[string]string{
+	// Saída em texto
+	"Buscando CEP: %s\n\n":                       "Looking up CEP: %s\n\n",
+	"Dados do CEP localizado":                    "CEP found",
+	"Utilização da API mais rápida com sucesso!": "Fastest API result used successfully!",
+	"API vencedora":                              "Winning API",
+	"API autoritativa":                           "Authoritative API",
+	"API mais rápida":                            "Fastest API",
+	"Resultado da API autoritativa":              "Authoritative API result",
+	"Autoritativo: %s (%s)\n":                    "Authoritative: %s (%s)\n",
+	"Autoritativo: ":                             "Authoritative: ",
+	"%s: erro: %s\n":                             "%s: error: %s\n",
+	"Aviso: nenhuma API respondeu, resultado aproximado da base offline": "Warning: no API responded, approximate result from the offline database",
+	"Aviso: CEP não localizado, resultado apenas em nível de município":  "Warning: CEP not found, municipality-level result only",
+	"Resultado obtido do cache":  "Result served from cache",
+	"Logradoruo: %s":             "Street: %s",
+	"Bairro: %s":                 "Neighborhood: %s",
+	"Cidade: %s":                 "City: %s",
+	"Estado: %s":                 "State: %s",
+	"Origem: %s":                 "Source: %s",
+	"Códigos: %s":                "Codes: %s",
+	"Município (IBGE): ":         "Municipality (IBGE): ",
+	"Município (IBGE): %s":       "Municipality (IBGE): %s",
+	"%s habitantes (Censo 2022)": "%s inhabitants (2022 Census)",
+	"Área de entrega: ":          "Delivery area: ",
+	"Área de entrega: %s":        "Delivery area: %s",
+	"Fuso horário: %s (predominante, o estado possui mais de um fuso)": "Time zone: %s (predominant, the state has more than one time zone)",
+	"Fuso horário: %s":                    "Time zone: %s",
+	"Coordenadas: ":                       "Coordinates: ",
+	"Coordenadas: %s, %s (%s)":            "Coordinates: %s, %s (%s)",
+	"Tempo de resposta: %s":               "Response time: %s",
+	"Próxima página: -page %d\n":          "Next page: -page %d\n",
+	"%d CEP(s) encontrado(s) no ViaCEP\n": "%d CEP(s) found in ViaCEP\n",
+	"%d CEP(s) encontrado(s) no ViaCEP, exibindo %d a %d (página %d de %d)\n": "%d CEP(s) found in ViaCEP, showing %d to %d (page %d of %d)\n",
+	"Bench das APIs (%d consultas por API, %d CEPs na amostra)\n":             "API bench (%d lookups per API, %d CEPs in the sample)\n",
+	"erros": "errors",
+	"As %d APIs que responderam concordam nos campos principais\n": "The %d APIs that responded agree on the main fields\n",
+	"Divergências entre as %d APIs que responderam:\n":             "Differences between the %d APIs that responded:\n",
+	"Tempos de resposta:":                "Response times:",
+	"Distância entre os CEPs":            "Distance between the CEPs",
+	"Origem: %s\n":                       "From: %s\n",
+	"Destino: %s\n":                      "To: %s\n",
+	"Distância: %s km (em linha reta)\n": "Distance: %s km (straight line)\n",
+	"Healthcheck das APIs (CEP %s)\n":    "API healthcheck (CEP %s)\n",
+	"%d de %d APIs operacionais\n":       "%d of %d APIs operational\n",
+	"Sugestões para %q em %s/%s\n":       "Suggestions for %q in %s/%s\n",
+	"DDD %s - %s\n":                      "Area code %s - %s\n",
+	"%d cidade(s) atendida(s):\n":        "%d city(ies) served:\n",
+	"Modo interativo: digite um CEP por linha (ajuda lista os comandos, sair encerra)\n":       "Interactive mode: type one CEP per line (help lists the commands, exit quits)\n",
+	"Comandos: <cep> consulta o CEP, cache exibe os acertos e falhas do cache, sair encerra\n": "Commands: <cep> looks up the CEP, cache shows the cache hits and misses, exit quits\n",
+	"  API vencedora: %s | tempo: %s | cache: %s\n":                                            "  Winning API: %s | time: %s | cache: %s\n",
+	"sim":                                "yes",
+	"não":                                "no",
+	"Cache desativado (ver -cache-ttl)":  "Cache disabled (see -cache-ttl)",
+	"Cache: %d acerto(s), %d falha(s)\n": "Cache: %d hit(s), %d miss(es)\n",
+
+	// Mensagens de erro, inclusive as respostas de erro do servidor
+	"%s: CEP divergente na resposta: %q":                           "%s: mismatched CEP in the response: %q",
+	"%s: estado %s inconsistente com a faixa do CEP (esperado %s)": "%s: state %s inconsistent with the CEP range (expected %s)",
+	"%s: resposta sem cidade ou estado":                            "%s: response without city or state",
+	"menos de %d APIs concordam no endereço (%s)":                  "fewer than %d APIs agree on the address (%s)",
+	"menos de %d APIs concordam no endereço":                       "fewer than %d APIs agree on the address",
+	"nenhuma API retornou o CEP":                                   "no API returned the CEP",
+	"nenhuma API respondeu a tempo":                                "no API responded in time",
+	"CEP não encontrado em nenhuma API":                            "CEP not found in any API",
+	"CEP não encontrado":                                           "CEP not found",
+	"CEP inválido: deve conter 8 dígitos (recebido %q)":            "invalid CEP: must contain 8 digits (got %q)",
+	"objeto JSON inválido: %v":                                     "invalid JSON object: %v",
+	"campo \"cep\" ausente":                                        "missing \"cep\" field",
+	"campo \"cep\" inválido: %v":                                   "invalid \"cep\" field: %v",
+
+	// Mensagens do log
+	"Timeout: o ViaCEP não respondeu a tempo":     "Timeout: ViaCEP did not respond in time",
+	"Timeout: a Brasil API não respondeu a tempo": "Timeout: Brasil API did not respond in time",
+	"DDD não encontrado":                          "Area code not found",
+	"Falha na consulta do DDD":                    "Area code lookup failed",
+	"Falha na busca por endereço":                 "Address search failed",
+	"Nenhum CEP encontrado para o endereço (a busca por endereço está disponível apenas no ViaCEP)": "No CEP found for the address (address search is only available on ViaCEP)",
+	"Página inexistente":                                "Page out of range",
+	"Timeout: a API autoritativa não respondeu a tempo": "Timeout: the authoritative API did not respond in time",
+	"API autoritativa falhou":                           "Authoritative API failed",
+	"a API autoritativa não respondeu a tempo":          "the authoritative API did not respond in time",
+	"resultado do cache ou do fallback por município, a API autoritativa não foi consultada": "result from the cache or the municipality fallback, the authoritative API was not queried",
+	"Linha ignorada":                  "Line skipped",
+	"Falha ao ler o lote":             "Failed to read the batch",
+	"Falha na exportação do lote":     "Batch export failed",
+	"CEP(s) do lote falharam":         "Batch CEP(s) failed",
+	"Falha no lote":                   "Batch failure",
+	"Erro ao gerar a saída em JSON":   "Failed to write the JSON output",
+	"Erro ao gerar a saída em CSV":    "Failed to write the CSV output",
+	"Falha ao ler a amostra do bench": "Failed to read the bench sample",
+	"Nenhum CEP na amostra do bench":  "No CEP in the bench sample",
+	"Falha na comparação":             "Comparison failed",
+	"API falhou na comparação":        "API failed during comparison",
+	"Timeout: nem todas as APIs responderam a tempo, comparando as que responderam": "Timeout: not all APIs responded in time, comparing those that did",
+	"Divergência entre as APIs": "APIs disagree",
+	"Falha no cálculo da distância: coordenadas indisponíveis (ver -geocoder-url)": "Distance calculation failed: coordinates unavailable (see -geocoder-url)",
+	"Falha no cálculo da distância":                                                "Distance calculation failed",
+	"Healthcheck: API indisponível":                                                "Healthcheck: API unavailable",
+	"Opções inválidas":                                                             "Invalid options",
+	"Configuração recusada":                                                        "Configuration rejected",
+	"Falha na consulta":                                                            "Lookup failed",
+	"Falha ao gravar o cache":                                                      "Failed to save the cache",
+	"otlp: fila cheia, span descartado":                                            "otlp: queue full, span dropped",
+	"otlp: erro ao gerar o JSON":                                                   "otlp: failed to encode the JSON",
+	"otlp: falha na exportação dos spans":                                          "otlp: failed to export the spans",
+	"Servindo consultas de CEP":                                                    "Serving CEP lookups",
+	"Erro no servidor":                                                             "Server error",
+	"Erro ao encerrar o servidor":                                                  "Failed to shut down the server",
+	"Erro ao escrever a resposta":                                                  "Failed to write the response",
+	"snapshot: erro ao gravar":                                                     "snapshot: write failed",
+	"snapshot: fila cheia, resposta descartada":                                    "snapshot: queue full, response dropped",
+	"SRV: falha na descoberta, usando URLs estáticas":                              "SRV: discovery failed, using static URLs",
+	"Falha ao ler a entrada do modo interativo":                                    "Failed to read the interactive mode input",
+	"Falha ao ler a entrada do stream":                                             "Failed to read the stream input",
+	"CEP(s) do stream falharam":                                                    "Stream CEP(s) failed",
+	"Nenhum endereço semelhante encontrado (a busca por endereço está disponível apenas no ViaCEP)": "No similar address found (address search is only available on ViaCEP)",
+	"Erro ao aplicar o template de -format":         "Failed to apply the -format template",
+	"Verificação: API falhou":                       "Verification: API failed",
+	"Divergência com o vencedor":                    "Mismatch with the winner",
+	"Verificação: resultado confirmado":             "Verification: result confirmed",
+	"webhook: evento não entregue":                  "webhook: event not delivered",
+	"webhook: fila cheia, evento descartado":        "webhook: queue full, event dropped",
+	"webhook: eventos descartados com a fila cheia": "webhook: events dropped with the queue full",
+	"Pânico no handler":                             "Handler panic",
+	"erro interno do servidor":                      "internal server error",
+
+	// Confiança (-confidence)
+	"Confiança: %s (%d de %d APIs concordam)": "Confidence: %s (%d of %d APIs agree)",
+	"alta":  "high",
+	"média": "medium",
+	"baixa": "low",
+
+	// Servidor
+	"tempo inválido em %s: %q (use um número de milissegundos maior que zero)": "invalid time in %s: %q (use a number of milliseconds greater than zero)",
+	"Encerrando o servidor ocioso": "Shutting down the idle server",
+
+	// Eventos do servidor (GET/POST /stream)
+	"Cliente desconectado do stream":                                                 "Client disconnected from the stream",
+	"erro ao ler o corpo: %v":                                                        "error reading the body: %v",
+	"corpo inválido: esperado um array JSON de CEPs: %v":                             "invalid body: expected a JSON array of CEPs: %v",
+	"nenhum CEP informado (use ?ceps=01001000,20040020 ou os CEPs no corpo do POST)": "no CEP given (use ?ceps=01001000,20040020 or the CEPs in the POST body)",
+	"CEPs demais: %d (máximo %d por requisição)":                                     "too many CEPs: %d (at most %d per request)",
+
+	// Pré-carregamento do cache do servidor (-prefetch-file)
+	"Falha ao ler o arquivo de pré-carregamento": "Failed to read the prefetch file",
+	"Pré-carregamento do cache iniciado":         "Cache prefetch started",
+	"Pré-carregamento do cache em andamento":     "Cache prefetch in progress",
+	"Pré-carregamento do cache concluído":        "Cache prefetch finished",
+	"Falha no pré-carregamento do CEP":           "Failed to prefetch CEP",
+
+	// Percentis do subcomando bench
+	"Percentis estimados por amostragem de reservatório (até %d tempos por API)\n": "Percentiles estimated by reservoir sampling (up to %d times per API)\n",
+	"Percentis exatos, sobre todos os tempos":                                      "Exact percentiles, over all times",
+
+	// Modo A/B do subcomando bench (-provider-sample)
+	"Bench A/B das APIs (%d consultas sorteadas entre %d APIs, %d CEPs na amostra)\n": "A/B API bench (%d lookups drawn among %d APIs, %d CEPs in the sample)\n",
+	"consultas": "lookups",
+
+	// Subcomando cache
+	"Cache %s\n":         "Cache %s\n",
+	"Entradas:     %d\n": "Entries:      %d\n",
+	"Tamanho:      %s\n": "Size:         %s\n",
+	"Mais antiga:  %s\n": "Oldest:       %s\n",
+	"Mais recente: %s\n": "Newest:       %s\n",
+	"Acertos:      %d de %d consulta(s) (%.0f%%)\n":   "Hits:         %d of %d lookup(s) (%.0f%%)\n",
+	"Acertos:      não registrados entre execuções":   "Hits:         not tracked across runs",
+	"Cache %s esvaziado: %d entrada(s) removida(s)\n": "Cache %s cleared: %d entry(ies) removed\n",
+	"Falha ao esvaziar o cache":                       "Failed to clear the cache",
+	"Falha ao ler o cache":                            "Failed to read the cache",
+	"Remover todas as entradas do cache %s?":          "Remove all entries from cache %s?",
+	" [s/N] ":                                         " [y/N] ",
+	"Limpeza do cache cancelada":                      "Cache clear cancelled",
+
+	// Lote
+	"Lote interrompido na primeira falha":               "Batch aborted on the first failure",
+	"Item ignorado":                                     "Item skipped",
+	"CEP ausente na coluna %q":                          "missing CEP in column %q",
+	"item deve ser um CEP ou um objeto com o campo cep": "item must be a CEP or an object with the cep field",
+	"Falha ao gravar o resultado do CEP":                "Failed to write the CEP result",
+	"Arquivos do lote gravados":                         "Batch files written",
+	"Alterações em relação ao cache":                    "Changes from the cache",
+
+	// Busca por endereços em lote (-address-file)
+	"Falha ao ler o arquivo de endereços": "Failed to read the address file",
+	"Busca por endereços concluída":       "Address search finished",
+	"item deve ser um endereço UF/Cidade/Logradouro ou um objeto com os campos uf, cidade e logradouro": "item must be a UF/City/Street address or an object with the uf, cidade and logradouro fields",
+	"=== %s (linha %d) ===\n":        "=== %s (line %d) ===\n",
+	"%s: nenhum CEP encontrado\n":    "%s: no CEP found\n",
+	"nenhum CEP encontrado":          "no CEP found",
+	"Nenhum CEP encontrado":          "No CEP found",
+	"erro: %s\n":                     "error: %s\n",
+	"o ViaCEP não respondeu a tempo": "ViaCEP did not respond in time",
+}
+
+// Traduz a mensagem para o idioma configurado e aplica os argumentos, como
+// fmt.Sprintf
+func tr(format string, args ...any) string {
+	if lang == "en" {
+		if translated, ok := messagesEN[format]; ok {
+			format = translated
+		}
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Faz o parse do idioma de -lang: pt ou en, aceitando também a região
+// (ex: pt-BR, en_US)
+func parseLang(value string) (string, error) {
+	if l := langPrefix(value); l == "pt" || l == "en" {
+		return l, nil
+	}
+	return "", fmt.Errorf("idioma inválido para -lang: %q (use pt ou en)", value)
+}
+
+// Idioma padrão pelas variáveis de locale do ambiente (LC_ALL, LC_MESSAGES
+// e LANG, nessa ordem): inglês para locales "en", português nos demais casos
+func detectLang() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			if langPrefix(value) == "en" {
+				return "en"
+			}
+			return "pt"
+		}
+	}
+	return "pt"
+}
+
+// Código do idioma de um locale (ex: "en" em "en_US.UTF-8")
+func langPrefix(locale string) string {
+	l, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(locale)), ".")
+	l, _, _ = strings.Cut(l, "_")
+	l, _, _ = strings.Cut(l, "-")
+	return l
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Acompanha as requisições ao servidor para encerrá-lo após -max-idle-time
+// sem nenhuma. As sondagens de /healthz e /metrics (do orquestrador e do
+// Prometheus) não contam como atividade.
+type idleWatchdog struct {
+	timeout time.Duration
+	last    atomic.Int64 // Instante (UnixNano) do início ou do fim da última requisição
+	active  atomic.Int64 // Requisições em andamento
+}
+
+func newIdleWatchdog(timeout time.Duration) *idleWatchdog {
+	w := &idleWatchdog{timeout: timeout}
+	w.last.Store(time.Now().UnixNano())
+	return w
+}
+
+// Registra o início e o fim de cada requisição
+func (w *idleWatchdog) track(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" || r.URL.Path == "/metrics" {
+			next.ServeHTTP(rw, r)
+			return
+		}
+		w.active.Add(1)
+		w.last.Store(time.Now().UnixNano())
+		defer func() {
+			w.last.Store(time.Now().UnixNano())
+			w.active.Add(-1)
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// Aguarda até o servidor ficar timeout sem requisições e sem nenhuma em
+// andamento, retornando true, ou até o fim do contexto, retornando false
+func (w *idleWatchdog) wait(ctx context.Context) bool {
+	timer := time.NewTimer(w.timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-timer.C:
+		}
+		if w.active.Load() > 0 {
+			timer.Reset(w.timeout)
+			continue
+		}
+		idle := time.Since(time.Unix(0, w.last.Load()))
+		if idle >= w.timeout {
+			return true
+		}
+		timer.Reset(w.timeout - idle)
+	}
+}
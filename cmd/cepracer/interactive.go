@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Prompt do modo interativo, escrito no stderr para não se misturar à saída
+const interactivePrompt = "cep> "
+
+// Modo interativo: lê um CEP por linha da entrada padrão e exibe, a cada
+// consulta, o resultado, a API vencedora, o tempo de resposta e se veio do
+// cache. O processo, com o cache, os circuit breakers e as conexões
+// reaproveitadas, permanece ativo até "sair" ou o fim da entrada (Ctrl-D).
+func runInteractive(opts *options) int {
+	fmt.Fprint(os.Stderr, tr("Modo interativo: digite um CEP por linha (ajuda lista os comandos, sair encerra)\n"))
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Fprint(os.Stderr, interactivePrompt)
+		if !scanner.Scan() {
+			break
+		}
+		line := strings.TrimSpace(scanner.Text())
+		switch strings.ToLower(line) {
+		case "":
+		case "sair", "exit", "quit":
+			return 0
+		case "ajuda", "help":
+			fmt.Print(tr("Comandos: <cep> consulta o CEP, cache exibe os acertos e falhas do cache, sair encerra\n"))
+		case "cache":
+			printCacheStats(opts.client.Cache)
+		default:
+			interactiveLookup(line, opts)
+		}
+	}
+	fmt.Fprintln(os.Stderr)
+
+	if err := scanner.Err(); err != nil {
+		slog.Error(tr("Falha ao ler a entrada do modo interativo"), "erro", err)
+		return 1
+	}
+	return 0
+}
+
+// Consulta um CEP digitado no modo interativo. As falhas são apenas
+// registradas no log, sem encerrar o modo.
+func interactiveLookup(line string, opts *options) {
+	code, err := cep.Normalize(line)
+	if err != nil {
+		slog.Warn(tr("CEP inválido: deve conter 8 dígitos (recebido %q)", line))
+		return
+	}
+
+	start := time.Now()
+	result, err := opts.client.Lookup(context.Background(), code)
+	elapsed := time.Since(start)
+	if err != nil {
+		slog.Error(tr("Falha na consulta"), "cep", maskedCEP(code, opts), "erro", maskedErrorText(code, err, opts), "tempo", roundElapsed(elapsed))
+		return
+	}
+
+	if opts.format != "text" {
+		displayResult(result, opts)
+		return
+	}
+	if opts.fields != nil {
+		printFields(result, opts.fields, tr("API vencedora"))
+	} else {
+		fmt.Println(result.FormatAddress())
+	}
+	cached := tr("não")
+	if result.Cached {
+		cached = tr("sim")
+	}
+	fmt.Print(tr("  API vencedora: %s | tempo: %s | cache: %s\n", result.API, roundElapsed(elapsed), cached))
+}
+
+// Exibe os acertos e falhas do cache desde o início do processo
+func printCacheStats(cache cep.CacheBackend) {
+	if cache == nil {
+		fmt.Println(tr("Cache desativado (ver -cache-ttl)"))
+		return
+	}
+	hits, misses := cache.Stats()
+	fmt.Print(tr("Cache: %d acerto(s), %d falha(s)\n", hits, misses))
+}
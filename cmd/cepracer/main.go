@@ -0,0 +1,1662 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Opções de execução informadas via linha de comando, arquivo de
+// configuração ou ambiente
+type options struct {
+	cep         string        // CEP a ser consultado
+	file        string        // Arquivo com um CEP por linha (modo em lote), vazio desativa
+	export      string        // Arquivo .csv ou .xlsx com uma linha por CEP do lote, vazio desativa
+	stream      bool          // Lê CEPs da entrada padrão e escreve NDJSON à medida que terminam (modo stream)
+	interactive bool          // Consulta os CEPs digitados, um por linha, no mesmo processo (modo interativo)
+	serve       string        // Endereço do servidor HTTP (modo servidor), vazio desativa
+	address     cep.Address   // Endereço da busca reversa (modo endereço), UF vazia desativa
+	page        int           // Página exibida dos CEPs da busca reversa, a partir de 1
+	pageSize    int           // CEPs por página na busca reversa, 0 exibe todos
+	concurrency int           // Máximo de CEPs consultados simultaneamente nos modos em lote e stream
+	timeout     time.Duration // Tempo máximo da consulta
+	format      string        // Formato de exibição: "text", "oneline", "json", "csv" ou "template"
+
+	abortOnFirstError bool // Interrompe o lote na primeira falha, cancelando as consultas restantes
+
+	inputFormat string // Formato do arquivo do lote: "plain", "csv" ou "json"
+	inputColumn string // Coluna do CEP no lote em CSV: nome do cabeçalho ou posição a partir de 1
+
+	preserveInputColumn bool // Repete as colunas do lote em CSV na saída em CSV e no -export
+
+	outputDir string // Diretório com um arquivo {cep}.json por CEP do lote, em vez da saída padrão (vazio desativa)
+	noClobber bool   // Mantém os arquivos já existentes em outputDir
+
+	resolveOnlyIfChanged bool // Omite os CEPs do lote com o resultado igual ao do cache
+
+	addressFile string // Arquivo com um endereço UF/Cidade/Logradouro por linha (busca reversa em lote), vazio desativa
+
+	budgetHeader string        // Cabeçalho com o tempo máximo da consulta, em ms, pedido pelo cliente do servidor (vazio desativa)
+	maxBudget    time.Duration // Limite do tempo pedido em budgetHeader
+
+	maxIdleTime time.Duration // Encerra o servidor após esse tempo sem requisições, 0 desativa
+
+	traceIDHeader string // Cabeçalho com o identificador de cada requisição ao servidor, gerado quando ausente (vazio desativa)
+
+	prefetchFile     string        // Arquivo com os CEPs pré-carregados no cache do servidor, vazio desativa
+	prefetchInterval time.Duration // Intervalo entre as renovações dos CEPs de prefetchFile
+	prefetchRate     float64       // Consultas do pré-carregamento iniciadas por segundo
+
+	metricsExemplars bool // Exemplars com o trace das consultas no histograma de latência de /metrics (OpenMetrics)
+
+	suggest      bool     // Sugere endereços parecidos com o logradouro de address (subcomando suggest)
+	suggestLimit int      // Máximo de sugestões exibidas
+	distanceCEPs []string // CEPs de origem e destino do subcomando distance, nil desativa
+	ddd          string   // DDD consultado no subcomando ddd, vazio desativa
+	cacheCommand string   // Operação do subcomando cache ("stats" ou "clear"), vazio desativa
+	yes          bool     // Confirma as operações destrutivas sem perguntar
+
+	template *template.Template // Template da saída quando -format é um template (format "template")
+
+	strictHTTPS bool // Recusa APIs configuradas com http:// (sem criptografia)
+	maskCEP     bool // Mascara os últimos dígitos do CEP nos logs
+
+	transport     http.RoundTripper // Transport das requisições (gravação/reprodução)
+	clientTimeout time.Duration     // Timeout do http.Client, limite de segurança além do contexto
+	urls          map[string]string // URL de cada API por identificador (ex: "viacep")
+	srvs          []srvProvider     // Registros SRV para descoberta das URLs das APIs
+
+	providers     []string // APIs que participam da corrida, por identificador (nil usa todas)
+	authoritative string   // API cujo resultado é exibido junto ao mais rápido, vazio desativa
+	fields        []string // Campos (e ordem) exibidos na saída em texto, nil usa o padrão
+
+	unixSocket string // Socket Unix de um serviço local de CEP, vazio desativa
+	unixPath   string // Caminho HTTP no serviço local (%s é substituído pelo CEP)
+
+	snapshots *snapshotWriter // Gravação das respostas brutas das APIs, nil desativa
+	webhook   *webhookSender  // Entrega dos resultados do lote e do servidor ao webhook, nil desativa
+	tracer    *otlpTracer     // Exportação dos spans das consultas via OTLP, nil desativa
+	verify    bool            // Verifica o vencedor contra as demais APIs após exibi-lo
+	compare   bool            // Aguarda todas as APIs e compara os resultados, em vez da corrida
+
+	confidence bool // Aguarda as demais APIs antes de exibir o resultado, com a confiança pela concordância delas
+
+	healthcheck bool // Verifica cada API com o CEP, em vez da corrida (subcomando healthcheck)
+
+	bench         bool     // Mede o tempo de resposta de cada API, em vez da corrida (subcomando bench)
+	benchCEPs     []string // CEPs da amostra do bench
+	benchRequests int      // Consultas por API no bench
+	benchSamples  int      // Tempos de resposta guardados por API no bench para os percentis
+
+	benchSplit []benchArm // Sorteio de cada consulta do bench para uma única API (-provider-sample), nil mede todas
+
+	chaos map[string]chaosConfig // Falhas/latências injetadas por API (APENAS PARA TESTES)
+
+	logLevel  slog.Level // Nível mínimo do log estruturado
+	logFormat string     // Formato do log: text ou json
+
+	cacheFile  string // Arquivo em que o cache é persistido entre execuções, vazio desativa
+	cacheRedis string // URL do Redis de -cache-redis, vazio desativa
+
+	providerRetries  map[string]int                // Novas tentativas por identificador da API, substituindo -retries
+	providerTimeouts map[string]time.Duration      // Tempo máximo por identificador da API, dentro de -timeout
+	rateLimits       map[string]cep.RateLimit      // Limite de requisições por identificador da API
+	providerRequests map[string]cep.RequestOptions // Cabeçalhos, query e token das requisições por identificador da API
+
+	client *cep.Client // Client da biblioteca configurado a partir das opções
+}
+
+func main() {
+	os.Exit(run())
+}
+
+// Executa a consulta e retorna o código de saída do programa
+func run() int {
+	opts, err := parseFlags(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		slog.Error(tr("Opções inválidas"), "erro", err)
+		return 2
+	}
+	opts.setupLogger(os.Stderr)
+	defer opts.close()
+
+	// Descobre as URLs das APIs via DNS SRV, quando configurado
+	if len(opts.srvs) > 0 {
+		discoverSRVProviders(opts.urls, opts.srvs)
+	}
+
+	// Falha antes de qualquer requisição se algum destino não usar HTTPS
+	if opts.strictHTTPS {
+		if err := checkStrictHTTPS(opts); err != nil {
+			slog.Error(tr("Configuração recusada"), "erro", err)
+			return 1
+		}
+	}
+
+	// Busca reversa: lista os CEPs de um endereço no ViaCEP
+	if opts.address.UF != "" && opts.suggest {
+		return runSuggest(opts)
+	}
+	if opts.address.UF != "" {
+		return runAddressLookup(opts)
+	}
+	if opts.addressFile != "" {
+		return runAddressFile(opts)
+	}
+
+	// Modo servidor: a consulta é exposta em GET /cep/{cep}
+	if opts.serve != "" {
+		return runServer(opts)
+	}
+
+	// Distância em linha reta entre dois CEPs
+	if opts.distanceCEPs != nil {
+		return runDistance(opts)
+	}
+
+	// Estado e cidades de um DDD
+	if opts.ddd != "" {
+		return runDDD(opts)
+	}
+
+	// Resumo ou limpeza do cache persistido
+	if opts.cacheCommand != "" {
+		return runCache(opts)
+	}
+
+	// Verificação das APIs para monitoramento: uma consulta por API
+	if opts.healthcheck {
+		return runHealthcheck(opts.cep, opts)
+	}
+
+	// Comparação dos tempos de resposta das APIs em várias consultas
+	if opts.bench {
+		return runBench(opts)
+	}
+
+	// Modo interativo: um CEP por linha digitada, com o cache entre as consultas
+	if opts.interactive {
+		return runInteractive(opts)
+	}
+
+	// Modo stream: NDJSON da entrada padrão à saída padrão
+	if opts.stream {
+		return runStream(opts)
+	}
+
+	// Modo em lote: uma linha por CEP do arquivo
+	if opts.file != "" {
+		return runBatch(opts)
+	}
+
+	// Ex: 01001000 (Praça da Sé, São Paulo). O CEP 13335320 retorna dados
+	// diferentes entre as APIs: ViaCEP 13333-140 | Brasil API 13335-320
+	code := opts.cep
+
+	// Mascara o CEP em todas as linhas de log, se configurado
+	logCEP := code
+	if opts.maskCEP {
+		opts.setupLogger(newMaskingWriter(os.Stderr, code))
+		logCEP = cep.Mask(code)
+	}
+
+	// Na saída em JSON, CSV e com template, stdout contém apenas o resultado
+	if opts.format != "json" && opts.format != "csv" && opts.format != "template" {
+		fmt.Print(tr("Buscando CEP: %s\n\n", logCEP))
+	}
+
+	// A consulta usa o timeout configurado (1 segundo por padrão)
+	if opts.compare {
+		return runCompare(code, opts)
+	}
+
+	r, err := opts.client.Race(context.Background(), code)
+	if err != nil {
+		slog.Error(tr("Falha na consulta"), "cep", logCEP, "erro", err)
+		return exitCode(err)
+	}
+	defer r.Close()
+
+	// Cancela imediatamente as requisições perdedoras, a menos que o
+	// resultado delas ainda seja aguardado (API autoritativa, verificação ou
+	// confiança)
+	if opts.authoritative == "" && !opts.verify && !opts.confidence {
+		r.Close()
+	}
+	if opts.confidence {
+		r.Confidence()
+	}
+
+	// Resultados das demais APIs aguardados após a exibição do mais rápido
+	if opts.authoritative != "" {
+		displayWithAuthoritative(r, opts)
+	} else {
+		displayResult(r.Result, opts)
+	}
+	if r.Result.SomenteMunicipio || r.Result.Cached {
+		return 0
+	}
+	if opts.verify {
+		verifyAgainstRemaining(r)
+	}
+	return 0
+}
+
+// Código de saída de uma consulta que falhou, pelo tipo da falha: 3 quando
+// o CEP não existe em nenhuma API, 4 em timeout, 5 quando as APIs não
+// concordam no quórum e 1 nas demais falhas (o 2 é reservado às opções
+// inválidas)
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, cep.ErrNoQuorum):
+		return 5
+	case errors.Is(err, cep.ErrTimeout):
+		return 4
+	case errors.Is(err, cep.ErrNotFound):
+		return 3
+	default:
+		return 1
+	}
+}
+
+// Subcomandos, informados antes das opções (ver parseFlags)
+var subcommands = []string{"serve", "search", "suggest", "distance", "ddd", "healthcheck", "bench", "cache"}
+
+// Endereço padrão do subcomando serve
+const defaultServeAddr = ":8080"
+
+// Realiza o parse dos argumentos de linha de comando (sem o nome do programa)
+func parseFlags(args []string) (*options, error) {
+	// Subcomandos "serve [opções] [endereço]", equivalente a -serve,
+	// "search [opções] UF/Cidade/Logradouro", equivalente a -address,
+	// "suggest [opções] UF/Cidade/Logradouro", que sugere endereços para um
+	// logradouro parcial, "distance [opções] <cep1> <cep2>", que calcula a
+	// distância entre dois CEPs, "ddd [opções] <ddd>", que lista as cidades
+	// de um DDD,
+	// "healthcheck [opções] [cep]", que verifica cada API,
+	// "bench [opções] [cep...]", que mede o tempo de resposta de cada API, e
+	// "cache [opções] stats|clear", que resume ou esvazia o cache persistido
+	subcommand := ""
+	if len(args) > 0 && slices.Contains(subcommands, args[0]) {
+		subcommand, args = args[0], args[1:]
+	}
+
+	fs := flag.NewFlagSet("cepracer", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Uso: %s [opções] <cep>\n       %s serve [opções] [endereço]\n       %s search [opções] <UF/Cidade/Logradouro>\n       %s suggest [opções] <UF/Cidade/Logradouro>\n       %s distance [opções] <cep1> <cep2>\n       %s ddd [opções] <ddd>\n       %s healthcheck [opções] [cep]\n       %s bench [opções] [cep...]\n       %s cache [opções] stats|clear\n\nOpções:\n", fs.Name(), fs.Name(), fs.Name(), fs.Name(), fs.Name(), fs.Name(), fs.Name(), fs.Name(), fs.Name())
+		fs.PrintDefaults()
+	}
+
+	configFile := fs.String("config", "", "Arquivo de configuração (subconjunto de YAML) com os valores padrão das opções (ex: cep.yaml); "+envPrefix+"<OPÇÃO> no ambiente também define uma opção")
+	cepFlag := fs.String("cep", "", "CEP a ser consultado (alternativa ao argumento posicional)")
+	file := fs.String("file", "", "Arquivo com um CEP por linha para consulta em lote (- lê da entrada padrão)")
+	fs.StringVar(file, "batch", "", "Alias de -file (ex: -batch ceps.txt)")
+	export := fs.String("export", "", "No modo em lote (-file), grava um arquivo .csv ou .xlsx com todos os campos de cada CEP, a API vencedora, o tempo de resposta e o erro das falhas")
+	inputFormat := fs.String("input-format", "plain", "Formato do arquivo do lote (-file): plain (um CEP por linha ou a primeira coluna), csv (com cabeçalho, CEP na coluna de -input-column) ou json (array de CEPs ou de objetos com o campo cep)")
+	inputColumn := fs.String("input-column", "cep", "Com -input-format csv, coluna do CEP: nome do cabeçalho (sem diferenciar maiúsculas) ou posição a partir de 1")
+	preserveInputColumn := fs.Bool("preserve-input-column", false, "Com -input-format csv, repete as colunas originais de cada linha na saída em CSV e no -export, seguidas de cidade, estado, logradouro, bairro e erro")
+	outputDir := fs.String("output-dir", "", "No modo em lote (-file), grava o resultado de cada CEP encontrado em {cep}.json no diretório informado (criado se necessário), em vez da saída padrão")
+	noClobber := fs.Bool("no-clobber", false, "Com -output-dir, mantém os arquivos {cep}.json já existentes em vez de sobrescrevê-los")
+	resolveOnlyIfChanged := fs.Bool("resolve-only-if-changed", false, "No modo em lote (-file) com -cache-file ou -cache-redis, consulta as APIs mesmo com o CEP no cache e exibe apenas os CEPs cujo resultado mudou em relação a ele")
+	abortOnFirstError := fs.Bool("abort-on-first-error", false, "No modo em lote (-file), cancela as consultas em andamento e as pendentes na primeira falha, encerrando com erro e o CEP que falhou no log")
+	stream := fs.Bool("stream", false, "Lê CEPs (ou objetos NDJSON com o campo cep) da entrada padrão e escreve os resultados em NDJSON à medida que terminam")
+	interactive := fs.Bool("interactive", false, "Modo interativo: consulta cada CEP digitado (um por linha) no mesmo processo, reaproveitando o cache e as conexões, e exibe a API vencedora, o tempo e se veio do cache")
+	serve := fs.String("serve", "", "Inicia um servidor HTTP no endereço informado (ex: :8080) com a consulta em GET /cep/{cep}")
+	budgetHeader := fs.String("deadline-budget-header", "X-Timeout-Ms", "No servidor, cabeçalho em que o cliente informa o tempo máximo da consulta em milissegundos (ex: X-Timeout-Ms: 800), limitado por -max-deadline-budget; vazio desativa")
+	maxBudget := fs.Duration("max-deadline-budget", 0, "Limite do tempo pedido em -deadline-budget-header (padrão -timeout)")
+	traceIDHeader := fs.String("trace-id-header", "X-Request-ID", "No servidor, cabeçalho com o identificador de cada requisição (ex: X-Correlation-ID ou traceparent), gerado quando ausente e repetido nos logs, no span e na resposta; vazio desativa")
+	prefetchFile := fs.String("prefetch-file", "", "No servidor, arquivo com um CEP por linha consultado em segundo plano logo após o início, para que já estejam no cache, e renovado a cada -prefetch-interval; exige o cache ativo")
+	prefetchInterval := fs.Duration("prefetch-interval", 0, "Intervalo entre as renovações dos CEPs de -prefetch-file (padrão metade de -cache-ttl)")
+	prefetchRate := fs.Float64("prefetch-rate", 10, "Consultas por segundo iniciadas pelo pré-carregamento de -prefetch-file, além do -rate-limit de cada API")
+	maxIdleTime := fs.Duration("max-idle-time", 0, "No servidor, encerra o processo normalmente após esse tempo sem requisições (ex: 5m), concluindo as em andamento; /healthz e /metrics não contam; 0 (padrão) não encerra")
+	var address cep.Address
+	fs.Func("address", "Busca reversa no ViaCEP: lista os CEPs de um endereço UF/Cidade/Logradouro (ex: \"SP/São Paulo/Domingos de Morais\")", func(v string) error {
+		a, err := cep.ParseAddress(v)
+		address = a
+		return err
+	})
+	addressFile := fs.String("address-file", "", "Busca reversa em lote no ViaCEP: lista os CEPs de cada endereço do arquivo (- lê da entrada padrão), no formato de -input-format (plain: um UF/Cidade/Logradouro por linha; csv: colunas uf, cidade e logradouro; json: array de endereços ou de objetos com esses campos)")
+	page := fs.Int("page", 1, "Página exibida dos CEPs encontrados na busca por endereço (-address ou search)")
+	pageSize := fs.Int("page-size", 10, "CEPs por página na busca por endereço (0 exibe todos)")
+	suggestLimit := fs.Int("limit", defaultSuggestLimit, "Máximo de endereços sugeridos pelo subcomando suggest (0 exibe todos)")
+	concurrency := fs.Int("concurrency", 4, "Número máximo de CEPs consultados simultaneamente no modo em lote (-file), stream (-stream) e na busca por endereços em lote (-address-file)")
+	timeout := fs.Duration("timeout", 1*time.Second, "Tempo máximo para as APIs responderem (ex: 3s)")
+	httpVersion := fs.String("fail-on-http-version", "", "Falha a consulta se o protocolo HTTP negociado não for o informado (ex: HTTP/2.0)")
+	langFlag := fs.String("lang", "", "Idioma da saída em texto, do log e dos erros do servidor: pt ou en (padrão pelo LANG do ambiente)")
+	format := fs.String("format", "text", "Formato de exibição do resultado: text, oneline, json, csv ou um template Go sobre o resultado (ex: '{{.CEP}};{{.Cidade}}/{{.Estado}}')")
+	jsonStrings := fs.Bool("json-numbers-as-strings", true, "Nas saídas em JSON (incluindo o servidor, o stream e o webhook), emite os códigos do IBGE, do SIAFI e o DDD como strings, como as APIs os retornam; -json-numbers-as-strings=false os emite como números")
+	fs.StringVar(format, "output", "text", "Alias de -format (ex: -output=json)")
+	municipalityFallback := fs.Bool("municipality-fallback", false, "Retorna apenas cidade/estado pelo prefixo quando o CEP não for encontrado")
+	offlineFallback := fs.Bool("offline-fallback", false, "Retorna cidade/estado da base offline (Origem: offline) quando nenhuma API responde")
+	validateState := fs.Bool("validate-state", false, "Descarta os resultados cujo estado não corresponde à faixa do CEP (ex: 01xxx-xxx é SP), como falha da API")
+	offlineDB := fs.String("offline-db", "", "Base offline em CSV (inicio,fim,cidade,uf) no lugar da embutida; ativa -offline-fallback")
+	logLevel := slog.LevelInfo
+	fs.Func("log-level", "Nível mínimo do log: debug (cada requisição e o desfecho de todas as APIs), info, warn ou error (padrão info)", func(v string) error {
+		if err := logLevel.UnmarshalText([]byte(v)); err != nil {
+			return fmt.Errorf("nível de log inválido: %q (use debug, info, warn ou error)", v)
+		}
+		return nil
+	})
+	logFormat := fs.String("log-format", "text", "Formato do log no stderr: text (chave=valor) ou json")
+	verbose := fs.Bool("verbose", false, "Alias de -log-level debug: registra cada requisição e o desfecho de todas as APIs")
+	userAgent := fs.String("user-agent", cep.DefaultUserAgent, "User-Agent enviado nas requisições às APIs")
+	cacheTTL := fs.Duration("cache-ttl", 24*time.Hour, "Tempo de validade dos resultados no cache em memória (0 desativa)")
+	fs.DurationVar(cacheTTL, "cache-max-age", 24*time.Hour, "Alias de -cache-ttl, idade máxima das entradas lidas de -cache-file")
+	cacheFile := fs.String("cache-file", "", "Persiste o cache no arquivo JSON informado, reaproveitando-o nas próximas execuções (ex: cep.db); cada resultado é gravado na hora no diário <arquivo>.journal")
+	cacheSize := fs.Int("cache-size", 10000, "Número máximo de CEPs no cache em memória, descartando os usados há mais tempo (0 não limita)")
+	cacheRedis := fs.String("cache-redis", "", "Guarda o cache no Redis informado, compartilhado entre instâncias, no lugar do cache em memória (ex: redis://localhost:6379/0)")
+	yes := fs.Bool("yes", false, "Confirma cache clear sem perguntar (sem terminal na entrada padrão, a confirmação já é dispensada)")
+	sinceModified := fs.Bool("since-modified", false, "Guarda no cache os validadores ETag e Last-Modified das APIs e, após -cache-ttl, revalida o resultado com uma requisição condicional (If-None-Match/If-Modified-Since): a resposta 304 renova a entrada sem baixá-la de novo")
+	cacheNamespace := fs.String("cache-namespace", "cepracer", "Prefixo das chaves no Redis de -cache-redis (\"<namespace>:<cep>\")")
+	retries := fs.Int("retries", 2, "Novas tentativas por API em falhas de rede e respostas 5xx (0 desativa)")
+	retryBackoff := fs.Duration("retry-backoff", 100*time.Millisecond, "Espera antes da primeira nova tentativa, dobrada a cada tentativa e sorteada entre metade e o valor inteiro")
+	providerRetries := make(map[string]int)
+	fs.Func("provider-retries", "Novas tentativas de uma API, substituindo -retries, api=n (ex: viacep=4); pode ser repetida", func(v string) error {
+		return parseProviderRetries(v, providerRetries)
+	})
+	providerTimeouts := make(map[string]time.Duration)
+	fs.Func("provider-timeout", "Tempo máximo de uma API, incluindo as novas tentativas, dentro de -timeout, api=duração (ex: viacep=500ms); pode ser repetida", func(v string) error {
+		return parseProviderTimeout(v, providerTimeouts)
+	})
+	breakerThreshold := fs.Int("breaker-threshold", 5, "Falhas consecutivas de uma API que abrem o circuito, deixando de consultá-la por -breaker-cooldown (0 desativa)")
+	breakerCooldown := fs.Duration("breaker-cooldown", 30*time.Second, "Tempo com o circuito aberto antes de testar a API novamente")
+	warnSlowProvider := fs.Duration("warn-slow-provider", 0, "No servidor, retira da corrida por -slow-provider-cooldown a API cuja latência média supera esse tempo (ex: 800ms); 0 (padrão) desativa")
+	slowProviderCooldown := fs.Duration("slow-provider-cooldown", time.Minute, "Tempo fora da corrida de uma API retirada por -warn-slow-provider")
+	latencyEMAAlpha := fs.Float64("latency-ema-alpha", 0.2, "Peso de cada medição, de 0 a 1, na latência média de -warn-slow-provider: maior reage mais rápido a mudanças, menor suaviza picos isolados")
+	rateLimits := make(map[string]cep.RateLimit)
+	fs.Func("rate-limit", "Limite de requisições de uma API, api=req/s[:rajada] (ex: viacep=5:10); pode ser repetida", func(v string) error {
+		return parseRateLimit(v, rateLimits)
+	})
+	providerRequests := make(map[string]cep.RequestOptions)
+	fs.Func("provider-header", "Cabeçalho incluído nas requisições de uma API, api=Nome: valor (ex: \"brasilapi=X-Api-Key: abc123\"); pode ser repetida", func(v string) error {
+		return parseProviderHeader(v, providerRequests)
+	})
+	fs.Func("provider-query", "Parâmetro de query incluído nas requisições de uma API, api=nome=valor (ex: viacep=key=abc123); pode ser repetida", func(v string) error {
+		return parseProviderQuery(v, providerRequests)
+	})
+	fs.Func("provider-token", "Token de autenticação de uma API, enviado em Authorization: Bearer, api=token (ex: opencep=abc123); pode ser repetida", func(v string) error {
+		return parseProviderToken(v, providerRequests)
+	})
+	rateLimitFailFast := fs.Bool("rate-limit-fail-fast", false, "Falha na hora as requisições acima do -rate-limit, em vez de aguardar a vez dentro do timeout")
+	strategy := fs.String("strategy", "race", "Estratégia de consulta: race (todas as APIs juntas, vence a mais rápida), fallback (uma por vez, na ordem de -providers, passando à seguinte em erro ou -provider-timeout) ou quorum (todas juntas, aceitando o endereço apenas quando -quorum APIs concordam)")
+	quorum := fs.Int("quorum", 2, "APIs que precisam concordar no logradouro, cidade e estado em -strategy quorum")
+	hedgeDelay := fs.Duration("hedge-delay", 0, "Dispara as APIs escalonadas, na ordem de -providers: a seguinte só após esse intervalo sem resultado (ex: 200ms); 0 dispara todas juntas")
+	retryOnEmptyFields := fs.Bool("retry-on-empty-fields", false, "Prefere o resultado de outra API quando o primeiro vier sem logradouro e bairro")
+	strictHTTPS := fs.Bool("strict-https", false, "Recusa consultar APIs, geocodificador, webhook e coletor OTLP configurados sem HTTPS")
+	proxy := fs.String("proxy", "", "Proxy das requisições de saída (ex: http://proxy.empresa:3128); padrão HTTP_PROXY, HTTPS_PROXY e NO_PROXY do ambiente")
+	caFile := fs.String("ca-file", "", "Arquivo PEM com certificados de CA confiáveis além dos do sistema (ex: CA do proxy corporativo)")
+	maskCEP := fs.Bool("mask-cep", false, "Mascara os últimos dígitos do CEP nos logs (ex: 01001-***)")
+	preferComplete := fs.Duration("prefer-complete", 0, "Aguarda essa janela após o primeiro resultado e escolhe o mais completo (ex: 150ms)")
+	clientTimeout := fs.Duration("http-client-timeout", 0, "Timeout do client HTTP como limite de segurança além do timeout da consulta (padrão 1,5x -timeout, 0 desativa)")
+	chaos := make(map[string]chaosConfig)
+	fs.Func("chaos", "APENAS PARA TESTES: injeta falhas/latência nas chamadas de uma API, api=taxa[:latência] (ex: viacep=0.3:200ms)", func(v string) error {
+		return parseChaos(v, chaos)
+	})
+	compare := fs.Bool("compare", false, "Aguarda todas as APIs (até o timeout) e informa se os resultados divergem, em vez da corrida")
+	benchRequests := fs.Int("requests", defaultBenchRequests, "Consultas por API no subcomando bench, em rodízio pelos CEPs da amostra (com -provider-sample, o total sorteado entre as APIs)")
+	providerSample := fs.String("provider-sample", "", "Modo A/B do subcomando bench: sorteia cada consulta para uma única API da lista, com pesos opcionais (ex: viacep,brasilapi ou viacep=70,brasilapi=30), em vez de medir todas")
+	benchSampleSize := fs.Int("max-latency-sample-size", defaultLatencySampleSize, "Tempos de resposta guardados por API no bench: acima disso, os percentis são estimados sobre uma amostra uniforme (amostragem de reservatório), com a memória constante")
+	verify := fs.Bool("primary-then-verify", false, "Exibe o resultado mais rápido e verifica as demais APIs em seguida, registrando divergências")
+	confidence := fs.Bool("confidence", false, "Aguarda as demais APIs (até o timeout) antes de exibir o resultado, com a confiança pela concordância delas nos campos principais: alta, media ou baixa")
+	verifyTimeout := fs.Duration("verify-timeout", 0, "Prazo adicional, além de -timeout, para as APIs verificadas com -primary-then-verify (0 encerra no -timeout)")
+	snapshotDir := fs.String("response-snapshot-dir", "", "Grava o corpo bruto de cada resposta das APIs no diretório informado")
+	metricsExemplars := fs.Bool("metrics-exemplars", false, "No servidor, inclui em /metrics os exemplars do OpenMetrics no histograma de latência, com o trace-id das consultas, para os coletores que pedem esse formato; exige -otlp-endpoint")
+	otlpEndpoint := fs.String("otlp-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "Exporta os spans de cada consulta e das requisições às APIs a um coletor OpenTelemetry via OTLP/HTTP (ex: http://localhost:4318); padrão OTEL_EXPORTER_OTLP_ENDPOINT")
+	otlpService := fs.String("otlp-service", cmp.Or(os.Getenv("OTEL_SERVICE_NAME"), "cepracer"), "Nome do serviço (service.name) nos spans exportados; padrão OTEL_SERVICE_NAME ou cepracer")
+	webhookURL := fs.String("webhook", "", "Envia cada resultado do lote (-file) ou do servidor (-serve) em um POST com JSON para a URL informada")
+	webhookSecret := fs.String("webhook-secret", "", "Segredo da assinatura HMAC-SHA256 do corpo enviado ao -webhook, no cabeçalho "+webhookSignatureHeader)
+	webhookRetries := fs.Int("webhook-retries", 3, "Novas tentativas de entrega ao -webhook em falhas de rede e respostas 429 ou 5xx")
+	record := fs.String("record", "", "Grava as respostas reais das APIs no arquivo JSON informado (ex: cassette.json)")
+	replay := fs.String("replay", "", "Responde as consultas a partir do arquivo gravado com -record, sem acessar a rede")
+	unixSocket := fs.String("unix-provider", "", "Socket Unix de um serviço local de CEP que participa da corrida (ex: /var/run/cep.sock)")
+	unixPath := fs.String("unix-provider-path", "/cep/%s", "Caminho HTTP no serviço local, %s é substituído pelo CEP")
+	fields := fs.String("fields", "", "Campos exibidos na saída em texto, em ordem (ex: cidade,estado,logradouro)")
+	timezone := fs.Bool("timezone", false, "Complementa o resultado com o fuso horário (IANA) do estado")
+	ibge := fs.Bool("ibge", false, "Complementa o resultado com região, mesorregião e população do município na API do IBGE")
+	geo := fs.Bool("geo", false, "Complementa o resultado com latitude e longitude (Brasil API v2 ou, na falta delas, o geocodificador)")
+	geocoderURL := fs.String("geocoder-url", cep.NominatimURL, "URL de busca do Nominatim usado como geocodificador de -geo (ex: instância própria)")
+	dddURL := fs.String("ddd-url", cep.DDDURL, "URL da consulta de DDD do subcomando ddd (%s é substituído pelo DDD)")
+	geojsonDB := fs.String("geojson-db", "", "Arquivo GeoJSON com as áreas de entrega por prefixo de CEP")
+	providers := fs.String("providers", "", "APIs que participam da corrida, separadas por vírgula (ex: brasilapi,viacep); padrão todas")
+	authoritative := fs.String("authoritative", "", "Exibe também o resultado da API autoritativa informada (brasilapi, viacep, opencep, apicep, postmon ou unix)")
+	urls := make(map[string]string)
+	fs.Func("url", "URL de uma API, com %s no lugar do CEP, api=url (ex: viacep=https://viacep.com.br/ws/%s/json/); pode ser repetida", func(v string) error {
+		return parseProviderURL(v, urls)
+	})
+	var srvs []srvProvider
+	fs.Func("srv-provider", "Descobre a URL das APIs via DNS SRV: [api=]_servico._tcp.dominio (pode repetir)", func(v string) error {
+		srv, err := parseSRVProvider(v)
+		if err != nil {
+			return err
+		}
+		srvs = append(srvs, srv)
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// Valores padrão do arquivo de configuração e do ambiente, para as
+	// opções não informadas na linha de comando
+	if !isFlagSet(fs, "config") {
+		*configFile = os.Getenv(envName("config"))
+	}
+	if err := applyConfig(fs, *configFile); err != nil {
+		return nil, err
+	}
+	if *langFlag != "" {
+		l, err := parseLang(*langFlag)
+		if err != nil {
+			return nil, err
+		}
+		lang = l
+	}
+	jsonNumbersAsStrings = *jsonStrings
+	positional := fs.Args()
+
+	// No subcomando serve, o argumento posicional é o endereço do servidor
+	if subcommand == "serve" {
+		switch {
+		case *serve != "":
+			return nil, errors.New("use -serve ou o subcomando serve, não ambos")
+		case len(positional) > 1:
+			return nil, fmt.Errorf("apenas um endereço pode ser informado em serve, recebidos %d argumentos", len(positional))
+		case len(positional) == 1:
+			*serve = positional[0]
+		default:
+			*serve = defaultServeAddr
+		}
+		positional = nil
+	}
+
+	// Nos subcomandos search e suggest, o endereço é informado como
+	// UF/Cidade/Logradouro ou em três argumentos (ex: search SP "São Paulo"
+	// "Domingos de Morais")
+	if subcommand == "search" || subcommand == "suggest" {
+		switch {
+		case address.UF != "":
+			return nil, fmt.Errorf("use -address ou o subcomando %s, não ambos", subcommand)
+		case subcommand == "suggest" && *suggestLimit < 0:
+			return nil, fmt.Errorf("número inválido para -limit: %d", *suggestLimit)
+		case len(positional) == 1 || len(positional) == 3:
+			a, err := cep.ParseAddress(strings.Join(positional, "/"))
+			if err != nil {
+				return nil, err
+			}
+			address = a
+		default:
+			fs.Usage()
+			return nil, fmt.Errorf("informe o endereço em %s: UF/Cidade/Logradouro", subcommand)
+		}
+		positional = nil
+	}
+
+	// No subcomando distance, a origem e o destino são informados como
+	// argumentos (ex: distance 01001000 01310100)
+	var distanceCEPs []string
+	if subcommand == "distance" {
+		switch {
+		case *cepFlag != "" || *file != "" || *serve != "" || address.UF != "":
+			return nil, errors.New("distance não pode ser combinado com -cep, -file, -serve ou -address")
+		case *compare:
+			return nil, errors.New("distance não pode ser combinado com -compare")
+		case len(positional) != 2:
+			fs.Usage()
+			return nil, errors.New("informe os dois CEPs em distance: distance <cep1> <cep2>")
+		}
+		for _, code := range positional {
+			normalized, err := cep.Normalize(code)
+			if err != nil {
+				return nil, err
+			}
+			distanceCEPs = append(distanceCEPs, normalized)
+		}
+		positional = nil
+	}
+
+	// No subcomando ddd, o código de área é o argumento (ex: ddd 11)
+	var ddd string
+	if subcommand == "ddd" {
+		switch {
+		case *cepFlag != "" || *file != "" || *serve != "" || address.UF != "":
+			return nil, errors.New("ddd não pode ser combinado com -cep, -file, -serve ou -address")
+		case *compare:
+			return nil, errors.New("ddd não pode ser combinado com -compare")
+		case len(positional) != 1:
+			fs.Usage()
+			return nil, errors.New("informe o DDD em ddd: ddd <ddd>")
+		}
+		normalized, err := cep.NormalizeDDD(positional[0])
+		if err != nil {
+			return nil, err
+		}
+		ddd, positional = normalized, nil
+	}
+
+	// No subcomando cache, a operação é o argumento, sobre o cache de
+	// -cache-file ou -cache-redis (o cache em memória começa vazio)
+	var cacheCommand string
+	if subcommand == "cache" {
+		switch {
+		case *cepFlag != "" || *file != "" || *serve != "" || address.UF != "":
+			return nil, errors.New("cache não pode ser combinado com -cep, -file, -serve ou -address")
+		case *compare:
+			return nil, errors.New("cache não pode ser combinado com -compare")
+		case len(positional) != 1 || (positional[0] != "stats" && positional[0] != "clear"):
+			fs.Usage()
+			return nil, errors.New("informe a operação em cache: cache stats ou cache clear")
+		case *cacheFile == "" && *cacheRedis == "":
+			return nil, errors.New("cache exige -cache-file ou -cache-redis")
+		}
+		cacheCommand, positional = positional[0], nil
+	}
+
+	// No subcomando bench, a amostra são os CEPs informados como argumentos,
+	// os de -file ou, sem nenhum, os CEPs padrão
+	var benchCEPs []string
+	if subcommand == "bench" {
+		switch {
+		case *serve != "" || address.UF != "":
+			return nil, errors.New("bench não pode ser combinado com -serve ou -address")
+		case *compare:
+			return nil, errors.New("bench não pode ser combinado com -compare")
+		case *benchRequests < 1:
+			return nil, fmt.Errorf("número inválido para -requests: %d", *benchRequests)
+		case *benchSampleSize < 1:
+			return nil, fmt.Errorf("tamanho inválido para -max-latency-sample-size: %d", *benchSampleSize)
+		case *file != "" && (*cepFlag != "" || len(positional) > 0):
+			return nil, errors.New("informe a amostra do bench como argumentos ou via -file, não ambos")
+		}
+		if *cepFlag != "" {
+			positional = append([]string{*cepFlag}, positional...)
+		}
+		for _, code := range positional {
+			normalized, err := cep.Normalize(code)
+			if err != nil {
+				return nil, err
+			}
+			benchCEPs = append(benchCEPs, normalized)
+		}
+		if benchCEPs == nil && *file == "" {
+			benchCEPs = defaultBenchCEPs
+		}
+		positional, *cepFlag = nil, ""
+	}
+
+	// CEP informado como argumento posicional ou via -cep
+	code := *cepFlag
+
+	// O healthcheck consulta um CEP conhecido, se nenhum for informado, e não
+	// se combina com os demais modos
+	if subcommand == "healthcheck" {
+		switch {
+		case *file != "" || *serve != "" || address.UF != "":
+			return nil, errors.New("healthcheck não pode ser combinado com -file, -serve ou -address")
+		case *compare:
+			return nil, errors.New("healthcheck não pode ser combinado com -compare")
+		case code == "" && len(positional) == 0:
+			code = healthcheckCEP
+		}
+	}
+	switch {
+	case code != "" && len(positional) > 0:
+		return nil, errors.New("informe o CEP apenas uma vez: como argumento ou via -cep")
+	case len(positional) > 1:
+		return nil, fmt.Errorf("apenas um CEP pode ser informado, recebidos %d argumentos", len(positional))
+	case len(positional) == 1:
+		code = positional[0]
+	}
+	switch {
+	case *addressFile != "" && (subcommand != "" || code != "" || *file != "" || *serve != "" || address.UF != "" || *stream || *interactive):
+		return nil, errors.New("-address-file não pode ser combinado com subcomandos, CEP, -file, -serve, -address, -stream ou -interactive")
+	case *addressFile != "":
+		// Os endereços do arquivo são validados na leitura, linha a linha
+	case *stream && (subcommand != "" || code != "" || *file != "" || *serve != "" || address.UF != ""):
+		return nil, errors.New("-stream não pode ser combinado com subcomandos, CEP, -file, -serve ou -address")
+	case *stream && *interactive:
+		return nil, errors.New("use apenas uma das opções -stream ou -interactive")
+	case *stream && *compare:
+		return nil, errors.New("-stream não pode ser combinado com -compare")
+	case *stream:
+		// Os CEPs da entrada são validados a cada linha
+	case *interactive && (subcommand != "" || code != "" || *file != "" || *serve != "" || address.UF != ""):
+		return nil, errors.New("-interactive não pode ser combinado com subcomandos, CEP, -file, -serve ou -address")
+	case *interactive && (*compare || *verify || *authoritative != ""):
+		return nil, errors.New("-interactive não pode ser combinado com -compare, -primary-then-verify ou -authoritative")
+	case *interactive:
+		// Os CEPs digitados são validados a cada linha
+	case address.UF != "" && (code != "" || *file != "" || *serve != ""):
+		return nil, errors.New("-address não pode ser combinado com CEP, -file ou -serve")
+	case address.UF != "":
+		// A busca reversa não usa CEP
+	case *serve != "" && (code != "" || *file != ""):
+		return nil, errors.New("-serve não aceita CEP nem -file: os CEPs são informados nas requisições")
+	case *serve != "":
+		// Os CEPs são validados a cada requisição ao servidor
+	case *file != "" && code != "":
+		return nil, errors.New("informe o CEP ou -file, não ambos")
+	case *file != "":
+		// Os CEPs do arquivo são validados na leitura do lote
+	case benchCEPs != nil:
+		// A amostra do bench já foi validada
+	case distanceCEPs != nil:
+		// Os CEPs do distance já foram validados
+	case ddd != "":
+		// O DDD já foi validado
+	case cacheCommand != "":
+		// O subcomando cache não consulta CEPs
+	case strings.TrimSpace(code) == "":
+		fs.Usage()
+		return nil, errors.New("nenhum CEP informado")
+	default:
+		normalized, err := cep.Normalize(code)
+		if err != nil {
+			return nil, err
+		}
+		code = normalized
+	}
+	if *concurrency < 1 {
+		return nil, fmt.Errorf("concorrência inválida para -concurrency: %d", *concurrency)
+	}
+
+	var tmpl *template.Template
+	if isFormatTemplate(*format) {
+		var err error
+		if tmpl, err = parseFormatTemplate(*format); err != nil {
+			return nil, err
+		}
+		*format = "template"
+	}
+	if *format != "text" && *format != "oneline" && *format != "json" && *format != "csv" && *format != "template" {
+		return nil, fmt.Errorf("formato inválido: %q (use text, oneline, json, csv ou um template como '{{.CEP}}')", *format)
+	}
+	if *export != "" {
+		if *file == "" || subcommand != "" {
+			return nil, errors.New("-export exige o modo em lote (-file)")
+		}
+		if err := checkExportPath(*export); err != nil {
+			return nil, err
+		}
+	}
+	if !slices.Contains(inputFormats, *inputFormat) {
+		return nil, fmt.Errorf("-input-format inválido: %q (use %s)", *inputFormat, strings.Join(inputFormats, ", "))
+	}
+	if *inputFormat != "plain" && *file == "" && *addressFile == "" {
+		return nil, errors.New("-input-format exige o modo em lote (-file ou -address-file)")
+	}
+	if *addressFile != "" && *format == "template" {
+		return nil, errors.New("-address-file não aceita template em -format (use text, oneline, json ou csv)")
+	}
+	if strings.TrimSpace(*inputColumn) == "" {
+		return nil, errors.New("-input-column não pode ser vazio")
+	}
+	if *preserveInputColumn {
+		if *inputFormat != "csv" {
+			return nil, errors.New("-preserve-input-column exige -input-format csv")
+		}
+		if *format != "csv" && *export == "" {
+			return nil, errors.New("-preserve-input-column exige -format csv ou -export")
+		}
+	}
+	if *outputDir != "" && (*file == "" || subcommand != "") {
+		return nil, errors.New("-output-dir exige o modo em lote (-file)")
+	}
+	if *noClobber && *outputDir == "" {
+		return nil, errors.New("-no-clobber exige -output-dir")
+	}
+	if *resolveOnlyIfChanged {
+		if *file == "" || subcommand != "" {
+			return nil, errors.New("-resolve-only-if-changed exige o modo em lote (-file)")
+		}
+		if *cacheFile == "" && *cacheRedis == "" {
+			return nil, errors.New("-resolve-only-if-changed exige um cache persistido (-cache-file ou -cache-redis)")
+		}
+	}
+	if *abortOnFirstError && (*file == "" || subcommand != "") {
+		return nil, errors.New("-abort-on-first-error exige o modo em lote (-file)")
+	}
+	if *stream && *format != "text" && *format != "json" {
+		return nil, errors.New("-stream sempre escreve NDJSON: -format não se aplica")
+	}
+
+	opts := &options{
+		cep:           code,
+		file:          *file,
+		export:        *export,
+		stream:        *stream,
+		interactive:   *interactive,
+		serve:         *serve,
+		address:       address,
+		suggest:       subcommand == "suggest",
+		suggestLimit:  *suggestLimit,
+		distanceCEPs:  distanceCEPs,
+		ddd:           ddd,
+		cacheCommand:  cacheCommand,
+		yes:           *yes,
+		page:          *page,
+		pageSize:      *pageSize,
+		concurrency:   *concurrency,
+		timeout:       *timeout,
+		format:        *format,
+		template:      tmpl,
+		logLevel:      logLevel,
+		logFormat:     *logFormat,
+		strictHTTPS:   *strictHTTPS,
+		maskCEP:       *maskCEP,
+		urls:          cep.DefaultURLs(),
+		srvs:          srvs,
+		authoritative: *authoritative,
+		unixSocket:    *unixSocket,
+		unixPath:      *unixPath,
+		clientTimeout: *clientTimeout,
+		verify:        *verify,
+		confidence:    *confidence,
+		compare:       *compare,
+		healthcheck:   subcommand == "healthcheck",
+		bench:         subcommand == "bench",
+		benchCEPs:     benchCEPs,
+		benchRequests: *benchRequests,
+		benchSamples:  *benchSampleSize,
+		chaos:         chaos,
+		cacheFile:     *cacheFile,
+		cacheRedis:    *cacheRedis,
+
+		abortOnFirstError: *abortOnFirstError,
+
+		inputFormat: *inputFormat,
+		inputColumn: *inputColumn,
+
+		preserveInputColumn: *preserveInputColumn,
+
+		outputDir: *outputDir,
+		noClobber: *noClobber,
+
+		resolveOnlyIfChanged: *resolveOnlyIfChanged,
+
+		addressFile: *addressFile,
+
+		budgetHeader: http.CanonicalHeaderKey(strings.TrimSpace(*budgetHeader)),
+		maxBudget:    *maxBudget,
+
+		maxIdleTime: *maxIdleTime,
+
+		traceIDHeader: http.CanonicalHeaderKey(strings.TrimSpace(*traceIDHeader)),
+
+		prefetchFile:     *prefetchFile,
+		prefetchInterval: *prefetchInterval,
+		prefetchRate:     *prefetchRate,
+
+		metricsExemplars: *metricsExemplars,
+
+		providerRetries:  providerRetries,
+		providerTimeouts: providerTimeouts,
+		rateLimits:       rateLimits,
+		providerRequests: providerRequests,
+	}
+	maps.Copy(opts.urls, urls)
+	client := &cep.Client{
+		URLs:                 opts.urls,
+		Timeout:              opts.timeout,
+		UserAgent:            *userAgent,
+		Retries:              *retries,
+		RetryBackoff:         *retryBackoff,
+		BreakerThreshold:     *breakerThreshold,
+		BreakerCooldown:      *breakerCooldown,
+		SlowThreshold:        *warnSlowProvider,
+		SlowCooldown:         *slowProviderCooldown,
+		LatencyAlpha:         *latencyEMAAlpha,
+		HedgeDelay:           *hedgeDelay,
+		RetryOnEmptyFields:   *retryOnEmptyFields,
+		PreferComplete:       *preferComplete,
+		MunicipalityFallback: *municipalityFallback,
+		OfflineFallback:      *offlineFallback || *offlineDB != "",
+		ValidateState:        *validateState,
+		TimeZone:             *timezone,
+		Geo:                  *geo || distanceCEPs != nil, // A distância exige as coordenadas
+		IBGE:                 *ibge,
+		MaskCEP:              opts.maskCEP,
+	}
+	if *verbose && !isFlagSet(fs, "log-level") {
+		opts.logLevel = slog.LevelDebug
+	}
+	if opts.logFormat != "text" && opts.logFormat != "json" {
+		return nil, fmt.Errorf("formato de log inválido: %q (use text ou json)", opts.logFormat)
+	}
+	if opts.page < 1 {
+		return nil, fmt.Errorf("página inválida para -page: %d (deve ser a partir de 1)", opts.page)
+	}
+	if opts.pageSize < 0 {
+		return nil, fmt.Errorf("tamanho inválido para -page-size: %d", opts.pageSize)
+	}
+	if opts.timeout <= 0 {
+		return nil, fmt.Errorf("timeout inválido: %s (deve ser maior que zero)", opts.timeout)
+	}
+	if opts.maxBudget < 0 {
+		return nil, fmt.Errorf("limite inválido para -max-deadline-budget: %s", opts.maxBudget)
+	}
+	if opts.maxBudget == 0 {
+		opts.maxBudget = opts.timeout
+	}
+	if opts.maxIdleTime < 0 {
+		return nil, fmt.Errorf("tempo inválido para -max-idle-time: %s", opts.maxIdleTime)
+	}
+	if opts.maxIdleTime > 0 && opts.serve == "" {
+		return nil, errors.New("-max-idle-time exige o modo servidor (-serve)")
+	}
+	if opts.clientTimeout < 0 {
+		return nil, fmt.Errorf("timeout inválido para -http-client-timeout: %s", opts.clientTimeout)
+	}
+
+	if *verifyTimeout < 0 {
+		return nil, fmt.Errorf("prazo inválido para -verify-timeout: %s", *verifyTimeout)
+	}
+	if *verifyTimeout > 0 && !opts.verify {
+		return nil, errors.New("-verify-timeout exige -primary-then-verify")
+	}
+	client.VerifyTimeout = *verifyTimeout
+
+	// Sem valor explícito, o timeout do client fica um pouco acima do da consulta
+	// (incluindo o prazo da verificação), mantendo o contexto como mecanismo
+	// principal de cancelamento
+	if !isFlagSet(fs, "http-client-timeout") {
+		total := opts.timeout + client.VerifyTimeout
+		opts.clientTimeout = total + total/2
+	}
+
+	if *providers != "" {
+		list, err := parseProviders(*providers, opts.unixSocket != "")
+		if err != nil {
+			return nil, err
+		}
+		opts.providers = list
+	}
+	if *providerSample != "" {
+		switch {
+		case !opts.bench:
+			return nil, errors.New("-provider-sample exige o subcomando bench")
+		case *providers != "":
+			return nil, errors.New("-provider-sample não pode ser combinado com -providers: as APIs do sorteio são as da lista")
+		}
+		arms, err := parseProviderSample(*providerSample, opts.unixSocket != "")
+		if err != nil {
+			return nil, err
+		}
+		opts.benchSplit = arms
+		opts.providers = nil
+		for _, arm := range arms {
+			opts.providers = append(opts.providers, arm.id)
+		}
+	}
+	if opts.authoritative != "" && opts.providers != nil && !slices.Contains(opts.providers, opts.authoritative) {
+		return nil, fmt.Errorf("a API autoritativa %q não está entre as informadas em -providers", opts.authoritative)
+	}
+	if opts.authoritative == "unix" && opts.unixSocket == "" {
+		return nil, errors.New("-authoritative unix exige -unix-provider")
+	}
+	if opts.authoritative != "" && opts.authoritative != "unix" && !knownProvider(opts.authoritative) {
+		return nil, fmt.Errorf("API autoritativa desconhecida: %q (use brasilapi, viacep, opencep, apicep, postmon ou unix)", opts.authoritative)
+	}
+	if *cacheTTL < 0 {
+		return nil, fmt.Errorf("TTL inválido para -cache-ttl: %s", *cacheTTL)
+	}
+	if *cacheSize < 0 {
+		return nil, fmt.Errorf("tamanho inválido para -cache-size: %d", *cacheSize)
+	}
+	if opts.cacheFile != "" && *cacheTTL == 0 {
+		return nil, errors.New("-cache-file exige o cache ativo (-cache-ttl maior que 0)")
+	}
+	if *cacheRedis != "" && *cacheTTL == 0 {
+		return nil, errors.New("-cache-redis exige o cache ativo (-cache-ttl maior que 0)")
+	}
+	if *cacheRedis != "" && opts.cacheFile != "" {
+		return nil, errors.New("-cache-redis não pode ser combinado com -cache-file")
+	}
+	if *cacheRedis != "" {
+		cache, err := cep.NewRedisCache(*cacheRedis, *cacheNamespace, *cacheTTL)
+		if err != nil {
+			return nil, err
+		}
+		client.Cache = cache
+	} else if opts.cacheFile != "" {
+		cache, err := cep.LoadCache(opts.cacheFile, *cacheTTL, *cacheSize)
+		if err != nil {
+			return nil, err
+		}
+		client.Cache = cache
+	} else if *cacheTTL > 0 {
+		client.Cache = cep.NewCache(*cacheTTL, *cacheSize)
+	}
+	if *sinceModified && *cacheTTL == 0 {
+		return nil, errors.New("-since-modified exige o cache ativo (-cache-ttl maior que 0)")
+	}
+	if *sinceModified && *cacheRedis != "" {
+		return nil, errors.New("-since-modified exige o cache em memória ou -cache-file: as entradas do Redis expiram sem os validadores")
+	}
+	client.Revalidate = *sinceModified
+	if opts.prefetchFile != "" && opts.serve == "" {
+		return nil, errors.New("-prefetch-file exige o modo servidor (-serve)")
+	}
+	if opts.prefetchFile != "" && *cacheTTL == 0 {
+		return nil, errors.New("-prefetch-file exige o cache ativo (-cache-ttl maior que 0)")
+	}
+	if opts.prefetchInterval < 0 {
+		return nil, fmt.Errorf("intervalo inválido para -prefetch-interval: %s", opts.prefetchInterval)
+	}
+	if opts.prefetchInterval == 0 {
+		opts.prefetchInterval = *cacheTTL / 2
+	}
+	if opts.prefetchRate <= 0 {
+		return nil, fmt.Errorf("taxa inválida para -prefetch-rate: %g (deve ser maior que 0)", opts.prefetchRate)
+	}
+	if opts.confidence && (opts.verify || opts.authoritative != "" || opts.compare || opts.file != "" || opts.serve != "" || opts.stream || opts.interactive || subcommand != "") {
+		return nil, errors.New("-confidence se aplica apenas à consulta de um CEP, sem -primary-then-verify, -authoritative ou -compare")
+	}
+	if opts.compare && (opts.verify || opts.authoritative != "" || opts.file != "" || opts.serve != "") {
+		return nil, errors.New("-compare não pode ser combinado com -primary-then-verify, -authoritative, -file ou -serve")
+	}
+	if client.Retries < 0 {
+		return nil, fmt.Errorf("número de tentativas inválido para -retries: %d", client.Retries)
+	}
+	if client.RetryBackoff <= 0 {
+		return nil, fmt.Errorf("espera inválida para -retry-backoff: %s (deve ser maior que zero)", client.RetryBackoff)
+	}
+	if client.BreakerThreshold < 0 {
+		return nil, fmt.Errorf("número de falhas inválido para -breaker-threshold: %d", client.BreakerThreshold)
+	}
+	if client.BreakerCooldown <= 0 {
+		return nil, fmt.Errorf("tempo inválido para -breaker-cooldown: %s (deve ser maior que zero)", client.BreakerCooldown)
+	}
+	if client.SlowThreshold < 0 {
+		return nil, fmt.Errorf("tempo inválido para -warn-slow-provider: %s", client.SlowThreshold)
+	}
+	if client.SlowThreshold > 0 && opts.serve == "" {
+		return nil, errors.New("-warn-slow-provider exige o modo servidor (-serve)")
+	}
+	if client.SlowCooldown <= 0 {
+		return nil, fmt.Errorf("tempo inválido para -slow-provider-cooldown: %s (deve ser maior que zero)", client.SlowCooldown)
+	}
+	if client.LatencyAlpha <= 0 || client.LatencyAlpha > 1 {
+		return nil, fmt.Errorf("peso inválido para -latency-ema-alpha: %g (deve ser maior que 0 e até 1)", client.LatencyAlpha)
+	}
+	for id, limit := range opts.rateLimits {
+		limit.FailFast = *rateLimitFailFast
+		opts.rateLimits[id] = limit
+	}
+	if _, ok := opts.rateLimits["unix"]; ok && opts.unixSocket == "" {
+		return nil, errors.New("-rate-limit unix exige -unix-provider")
+	}
+	if _, ok := opts.providerRetries["unix"]; ok && opts.unixSocket == "" {
+		return nil, errors.New("-provider-retries unix exige -unix-provider")
+	}
+	if _, ok := opts.providerTimeouts["unix"]; ok && opts.unixSocket == "" {
+		return nil, errors.New("-provider-timeout unix exige -unix-provider")
+	}
+	if _, ok := opts.providerRequests["unix"]; ok && opts.unixSocket == "" {
+		return nil, errors.New("-provider-header, -provider-query e -provider-token unix exigem -unix-provider")
+	}
+	if client.HedgeDelay < 0 {
+		return nil, fmt.Errorf("intervalo inválido para -hedge-delay: %s", client.HedgeDelay)
+	}
+	switch *strategy {
+	case "race":
+	case "fallback":
+		if client.HedgeDelay > 0 {
+			return nil, errors.New("-hedge-delay não se aplica a -strategy fallback, que dispara a próxima API apenas na falha da anterior")
+		}
+		client.Strategy = cep.StrategyFallback
+	case "quorum":
+		if client.HedgeDelay > 0 {
+			return nil, errors.New("-hedge-delay não se aplica a -strategy quorum, que aguarda as respostas de várias APIs")
+		}
+		if *quorum < 2 {
+			return nil, fmt.Errorf("quórum inválido para -quorum: %d (mínimo 2)", *quorum)
+		}
+		client.Strategy = cep.StrategyQuorum
+		client.Quorum = *quorum
+	default:
+		return nil, fmt.Errorf("estratégia inválida: %q (use race, fallback ou quorum)", *strategy)
+	}
+	if client.PreferComplete < 0 {
+		return nil, fmt.Errorf("janela inválida para -prefer-complete: %s", client.PreferComplete)
+	}
+
+	if *fields != "" {
+		list, err := parseFields(*fields)
+		if err != nil {
+			return nil, err
+		}
+		opts.fields = list
+	}
+
+	if client.Geo && *geocoderURL != cep.NominatimURL {
+		u, err := url.Parse(*geocoderURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("URL inválida para -geocoder-url: %q", *geocoderURL)
+		}
+		geocoder := cep.NewNominatimGeocoder(client)
+		geocoder.URL = *geocoderURL
+		client.Geocoder = geocoder
+	}
+
+	if *dddURL != cep.DDDURL {
+		if !strings.Contains(*dddURL, "%s") {
+			return nil, fmt.Errorf("URL sem %%s para o DDD em -ddd-url: %q", *dddURL)
+		}
+		if u, err := url.Parse(strings.ReplaceAll(*dddURL, "%s", "11")); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("URL inválida para -ddd-url: %q (use http:// ou https://)", *dddURL)
+		}
+		client.DDDURL = *dddURL
+	}
+
+	if *offlineDB != "" {
+		db, err := cep.LoadOfflineDB(*offlineDB)
+		if err != nil {
+			return nil, err
+		}
+		client.OfflineDB = db
+	}
+	if *geojsonDB != "" {
+		db, err := cep.LoadGeoDB(*geojsonDB)
+		if err != nil {
+			return nil, err
+		}
+		client.GeoDB = db
+	}
+
+	// Transport das requisições à rede, com o proxy e as CAs configurados
+	network, err := newNetworkTransport(*proxy, *caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// Gravação e reprodução de fixtures são mutuamente exclusivas
+	switch {
+	case *record != "" && *replay != "":
+		return nil, errors.New("use apenas uma das opções -record ou -replay")
+	case *record != "":
+		opts.transport = newRecordingTransport(*record, network)
+	case *replay != "":
+		transport, err := newReplayTransport(*replay)
+		if err != nil {
+			return nil, err
+		}
+		opts.transport = transport
+	default:
+		opts.transport = network
+	}
+	if *httpVersion != "" {
+		proto, err := normalizeHTTPVersion(*httpVersion)
+		if err != nil {
+			return nil, err
+		}
+		client.HTTPVersion = proto
+	}
+
+	// Resultados do lote e do servidor entregues ao webhook
+	if *webhookURL != "" {
+		u, err := url.Parse(*webhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("URL inválida para -webhook: %q", *webhookURL)
+		}
+		if opts.bench || (opts.file == "" && opts.serve == "") {
+			return nil, errors.New("-webhook exige o modo em lote (-file) ou servidor (-serve)")
+		}
+		if *webhookRetries < 0 {
+			return nil, fmt.Errorf("número inválido para -webhook-retries: %d", *webhookRetries)
+		}
+		opts.webhook = newWebhookSender(*webhookURL, *webhookSecret, *userAgent, *webhookRetries, network)
+	} else if *webhookSecret != "" {
+		return nil, errors.New("-webhook-secret exige -webhook")
+	}
+
+	if opts.metricsExemplars && opts.serve == "" {
+		return nil, errors.New("-metrics-exemplars exige o modo servidor (-serve)")
+	}
+	if opts.metricsExemplars && *otlpEndpoint == "" {
+		return nil, errors.New("-metrics-exemplars exige o rastreamento (-otlp-endpoint)")
+	}
+
+	// Spans das consultas exportados ao coletor OpenTelemetry
+	if *otlpEndpoint != "" {
+		u, err := url.Parse(*otlpEndpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("URL inválida para -otlp-endpoint: %q", *otlpEndpoint)
+		}
+		opts.tracer = newOTLPTracer(*otlpEndpoint, *otlpService, network)
+		client.Tracer = opts.tracer
+	}
+
+	// Snapshots das respostas são gravados pelo transport
+	if *snapshotDir != "" {
+		writer, err := newSnapshotWriter(*snapshotDir, opts.maskCEP)
+		if err != nil {
+			return nil, err
+		}
+		opts.snapshots = writer
+	}
+
+	// Um único client, compartilhado por todas as goroutines e consultas
+	client.HTTPClient = &http.Client{Transport: opts.wrapTransport(opts.transport), Timeout: opts.clientTimeout}
+	configureProviders(client, opts)
+	if client.Strategy == cep.StrategyQuorum && client.Quorum > len(client.Providers) {
+		return nil, fmt.Errorf("-quorum %d exige ao menos %d APIs na consulta (participam %d)", client.Quorum, client.Quorum, len(client.Providers))
+	}
+	opts.client = client
+	return opts, nil
+}
+
+// Envolve o transport base com os recursos opcionais: gravação de snapshots
+// e, por fora, a injeção de falhas
+func (o *options) wrapTransport(base http.RoundTripper) http.RoundTripper {
+	transport := base
+	if o.snapshots != nil {
+		transport = &snapshotTransport{next: transport, writer: o.snapshots}
+	}
+	if len(o.chaos) > 0 {
+		transport = &chaosTransport{next: transport, configs: o.chaos}
+	}
+	return transport
+}
+
+// Configura as APIs da corrida: as registradas na biblioteca e, se
+// informado, o serviço local via socket Unix, filtradas e ordenadas por
+// -providers.
+// Identifica também a API autoritativa e as novas tentativas, o tempo máximo,
+// o limite de requisições e as opções das requisições de cada uma.
+func configureProviders(client *cep.Client, opts *options) {
+	setRetries := func(id string, p cep.Provider) {
+		if n, ok := opts.providerRetries[id]; ok {
+			if client.ProviderRetries == nil {
+				client.ProviderRetries = make(map[string]int)
+			}
+			client.ProviderRetries[p.Name()] = n
+		}
+		if timeout, ok := opts.providerTimeouts[id]; ok {
+			if client.ProviderTimeouts == nil {
+				client.ProviderTimeouts = make(map[string]time.Duration)
+			}
+			client.ProviderTimeouts[p.Name()] = timeout
+		}
+		if limit, ok := opts.rateLimits[id]; ok {
+			if client.RateLimits == nil {
+				client.RateLimits = make(map[string]cep.RateLimit)
+			}
+			client.RateLimits[p.Name()] = limit
+		}
+		if req, ok := opts.providerRequests[id]; ok {
+			if client.ProviderRequests == nil {
+				client.ProviderRequests = make(map[string]cep.RequestOptions)
+			}
+			client.ProviderRequests[p.Name()] = req
+		}
+	}
+
+	// Na ordem de -providers, que define a prioridade no disparo escalonado,
+	// ou na do registro, com o serviço local por último
+	ids := opts.providers
+	if ids == nil {
+		ids = providerIDs()
+	}
+	for _, id := range ids {
+		var p cep.Provider
+		switch {
+		case id == "unix" && opts.unixSocket == "":
+			continue
+		case id == "unix":
+			unixClient := &http.Client{
+				Transport: opts.wrapTransport(cep.NewUnixSocketTransport(opts.unixSocket)),
+				Timeout:   opts.clientTimeout,
+			}
+			p = cep.NewUnixSocketProvider(client, opts.unixSocket, opts.unixPath, unixClient)
+		default:
+			registered, err := cep.NewProvider(id, client)
+			if err != nil {
+				continue
+			}
+			p = registered
+		}
+		if id == opts.authoritative {
+			client.Authoritative = p
+		}
+		setRetries(id, p)
+		client.Providers = append(client.Providers, p)
+	}
+}
+
+// Faz o parse de um valor de -provider-retries (ex: "viacep=4")
+func parseProviderRetries(value string, into map[string]int) error {
+	id, count, found := strings.Cut(value, "=")
+	if !found || id == "" {
+		return fmt.Errorf("valor inválido para -provider-retries: %q (use api=n)", value)
+	}
+	if id != "unix" && !knownProvider(id) {
+		return fmt.Errorf("API desconhecida em -provider-retries: %q", id)
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 0 {
+		return fmt.Errorf("número de tentativas inválido em -provider-retries: %q", count)
+	}
+	into[id] = n
+	return nil
+}
+
+// Faz o parse de um valor de -provider-timeout (ex: "viacep=500ms")
+func parseProviderTimeout(value string, into map[string]time.Duration) error {
+	id, spec, found := strings.Cut(value, "=")
+	if !found || id == "" {
+		return fmt.Errorf("valor inválido para -provider-timeout: %q (use api=duração)", value)
+	}
+	if id != "unix" && !knownProvider(id) {
+		return fmt.Errorf("API desconhecida em -provider-timeout: %q", id)
+	}
+	d, err := time.ParseDuration(spec)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("tempo inválido em -provider-timeout: %q (ex: 500ms)", spec)
+	}
+	into[id] = d
+	return nil
+}
+
+// Faz o parse de um valor de -url (ex: "viacep=https://viacep.com.br/ws/%s/json/")
+func parseProviderURL(value string, into map[string]string) error {
+	id, rawURL, found := strings.Cut(value, "=")
+	if !found || id == "" {
+		return fmt.Errorf("valor inválido para -url: %q (use api=url)", value)
+	}
+	if !knownProvider(id) {
+		return fmt.Errorf("API desconhecida em -url: %q (use -unix-provider-path para o serviço local)", id)
+	}
+	if !strings.Contains(rawURL, "%s") {
+		return fmt.Errorf("URL sem %%s para o CEP em -url: %q", rawURL)
+	}
+	u, err := url.Parse(strings.ReplaceAll(rawURL, "%s", "01001000"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("URL inválida em -url: %q (use http:// ou https://)", rawURL)
+	}
+	into[id] = rawURL
+	return nil
+}
+
+// Faz o parse de um valor de -rate-limit (ex: "viacep=5:10")
+func parseRateLimit(value string, into map[string]cep.RateLimit) error {
+	id, spec, found := strings.Cut(value, "=")
+	if !found || id == "" {
+		return fmt.Errorf("valor inválido para -rate-limit: %q (use api=req/s[:rajada])", value)
+	}
+	if id != "unix" && !knownProvider(id) {
+		return fmt.Errorf("API desconhecida em -rate-limit: %q", id)
+	}
+
+	rate, burst, _ := strings.Cut(spec, ":")
+	var limit cep.RateLimit
+	r, err := strconv.ParseFloat(rate, 64)
+	if err != nil || r <= 0 {
+		return fmt.Errorf("taxa inválida em -rate-limit: %q (use um número de requisições por segundo maior que zero)", rate)
+	}
+	limit.PerSecond = r
+	if burst != "" {
+		b, err := strconv.Atoi(burst)
+		if err != nil || b < 1 {
+			return fmt.Errorf("rajada inválida em -rate-limit: %q", burst)
+		}
+		limit.Burst = b
+	}
+	into[id] = limit
+	return nil
+}
+
+// Faz o parse de um valor de -provider-header (ex: "brasilapi=X-Api-Key: abc123")
+func parseProviderHeader(value string, into map[string]cep.RequestOptions) error {
+	id, header, found := strings.Cut(value, "=")
+	if !found || id == "" {
+		return fmt.Errorf("valor inválido para -provider-header: %q (use api=Nome: valor)", value)
+	}
+	if id != "unix" && !knownProvider(id) {
+		return fmt.Errorf("API desconhecida em -provider-header: %q", id)
+	}
+	name, v, found := strings.Cut(header, ":")
+	name = strings.TrimSpace(name)
+	if !found || name == "" || strings.ContainsAny(name, " \t") {
+		return fmt.Errorf("cabeçalho inválido em -provider-header: %q (use Nome: valor)", header)
+	}
+	opts := into[id]
+	if opts.Headers == nil {
+		opts.Headers = make(http.Header)
+	}
+	opts.Headers.Add(name, strings.TrimSpace(v))
+	into[id] = opts
+	return nil
+}
+
+// Faz o parse de um valor de -provider-query (ex: "viacep=key=abc123")
+func parseProviderQuery(value string, into map[string]cep.RequestOptions) error {
+	id, param, found := strings.Cut(value, "=")
+	if !found || id == "" {
+		return fmt.Errorf("valor inválido para -provider-query: %q (use api=nome=valor)", value)
+	}
+	if id != "unix" && !knownProvider(id) {
+		return fmt.Errorf("API desconhecida em -provider-query: %q", id)
+	}
+	name, v, found := strings.Cut(param, "=")
+	if !found || name == "" {
+		return fmt.Errorf("parâmetro inválido em -provider-query: %q (use nome=valor)", param)
+	}
+	opts := into[id]
+	if opts.Query == nil {
+		opts.Query = make(url.Values)
+	}
+	opts.Query.Add(name, v)
+	into[id] = opts
+	return nil
+}
+
+// Faz o parse de um valor de -provider-token (ex: "opencep=abc123")
+func parseProviderToken(value string, into map[string]cep.RequestOptions) error {
+	id, token, found := strings.Cut(value, "=")
+	if !found || id == "" || token == "" {
+		return fmt.Errorf("valor inválido para -provider-token: %q (use api=token)", value)
+	}
+	if id != "unix" && !knownProvider(id) {
+		return fmt.Errorf("API desconhecida em -provider-token: %q", id)
+	}
+	opts := into[id]
+	opts.Token = token
+	into[id] = opts
+	return nil
+}
+
+// Faz o parse da lista de APIs de -providers (ex: "brasilapi,viacep"),
+// removendo repetições. "unix" exige -unix-provider.
+func parseProviders(value string, unixSocket bool) ([]string, error) {
+	var ids []string
+	for _, id := range strings.Split(value, ",") {
+		id = strings.ToLower(strings.TrimSpace(id))
+		switch {
+		case id == "" || slices.Contains(ids, id):
+			continue
+		case id == "unix" && !unixSocket:
+			return nil, errors.New("-providers unix exige -unix-provider")
+		case id != "unix" && !knownProvider(id):
+			return nil, fmt.Errorf("API desconhecida em -providers: %q (disponíveis: %s)", id, strings.Join(providerIDs(), ", "))
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("nenhuma API informada em -providers")
+	}
+	return ids, nil
+}
+
+// Faz o parse da lista de -provider-sample (ex: "viacep=70,brasilapi=30"):
+// ao menos duas APIs, sem repetições, com peso 1 quando omitido
+func parseProviderSample(value string, unixSocket bool) ([]benchArm, error) {
+	var arms []benchArm
+	for _, item := range strings.Split(value, ",") {
+		id, weight, hasWeight := strings.Cut(strings.TrimSpace(item), "=")
+		id = strings.ToLower(strings.TrimSpace(id))
+		arm := benchArm{id: id, weight: 1}
+		if hasWeight {
+			w, err := strconv.ParseFloat(strings.TrimSpace(weight), 64)
+			if err != nil || w <= 0 || math.IsInf(w, 0) {
+				return nil, fmt.Errorf("peso inválido em -provider-sample: %q", item)
+			}
+			arm.weight = w
+		}
+		switch {
+		case id == "" && !hasWeight:
+			continue
+		case id == "unix" && !unixSocket:
+			return nil, errors.New("-provider-sample unix exige -unix-provider")
+		case id != "unix" && !knownProvider(id):
+			return nil, fmt.Errorf("API desconhecida em -provider-sample: %q (disponíveis: %s)", id, strings.Join(providerIDs(), ", "))
+		case slices.ContainsFunc(arms, func(a benchArm) bool { return a.id == id }):
+			return nil, fmt.Errorf("API repetida em -provider-sample: %q", id)
+		}
+		arms = append(arms, arm)
+	}
+	if len(arms) < 2 {
+		return nil, errors.New("informe ao menos duas APIs em -provider-sample")
+	}
+	return arms, nil
+}
+
+// Configura o log estruturado no nível e formato das opções, escrevendo em w,
+// e o usa também para o desfecho das APIs na corrida
+func (o *options) setupLogger(w io.Writer) {
+	handlerOpts := &slog.HandlerOptions{Level: o.logLevel}
+	var handler slog.Handler = slog.NewTextHandler(w, handlerOpts)
+	if o.logFormat == "json" {
+		handler = slog.NewJSONHandler(w, handlerOpts)
+	}
+	logger := slog.New(requestIDHandler{handler})
+	slog.SetDefault(logger)
+	o.client.Logger = cep.NewSlogLogger(logger)
+}
+
+// Libera os recursos das opções, aguardando as gravações pendentes
+func (o *options) close() {
+	if o.snapshots != nil {
+		o.snapshots.Close()
+	}
+	if o.webhook != nil {
+		o.webhook.Close()
+	}
+	if o.tracer != nil {
+		o.tracer.Close()
+	}
+	if o.client == nil {
+		return
+	}
+	switch cache := o.client.Cache.(type) {
+	case *cep.Cache:
+		if o.cacheFile != "" {
+			if err := cache.Save(o.cacheFile); err != nil {
+				slog.Error(tr("Falha ao gravar o cache"), "erro", err)
+			}
+			cache.Close()
+		}
+	case *cep.RedisCache:
+		cache.Close()
+	}
+}
+
+// Verifica se todos os destinos HTTP configurados usam HTTPS: as APIs
+// participantes (inclusive as URLs descobertas via SRV), a consulta de DDD,
+// o geocodificador de -geo, a API do IBGE, o webhook e o coletor OTLP
+func checkStrictHTTPS(opts *options) error {
+	for _, p := range cep.RegisteredProviders() {
+		if opts.providers != nil && !slices.Contains(opts.providers, p.ID) {
+			continue
+		}
+		if u := opts.urls[p.ID]; !strings.HasPrefix(u, "https://") {
+			return fmt.Errorf("strict-https: a API %s está configurada sem HTTPS (%s)", p.Name, u)
+		}
+	}
+
+	client := opts.client
+	var targets [][2]string
+	if opts.ddd != "" && client.DDDURL != "" {
+		targets = append(targets, [2]string{"a consulta de DDD", client.DDDURL})
+	}
+	if geocoder, ok := client.Geocoder.(*cep.NominatimGeocoder); ok && client.Geo && geocoder.URL != "" {
+		targets = append(targets, [2]string{"o geocodificador", geocoder.URL})
+	}
+	if client.IBGE && client.IBGEURL != "" {
+		targets = append(targets, [2]string{"a API do IBGE", client.IBGEURL})
+	}
+	if opts.webhook != nil {
+		targets = append(targets, [2]string{"o webhook", opts.webhook.url})
+	}
+	if opts.tracer != nil {
+		targets = append(targets, [2]string{"o coletor OTLP", opts.tracer.url})
+	}
+	for _, target := range targets {
+		if !strings.HasPrefix(target[1], "https://") {
+			return fmt.Errorf("strict-https: %s não usa HTTPS (%s)", target[0], target[1])
+		}
+	}
+	return nil
+}
+
+// Indica se o identificador é de uma API registrada na biblioteca
+func knownProvider(id string) bool {
+	for _, p := range cep.RegisteredProviders() {
+		if p.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Identificadores das APIs aceitos em -providers
+func providerIDs() []string {
+	var ids []string
+	for _, p := range cep.RegisteredProviders() {
+		ids = append(ids, p.ID)
+	}
+	return append(ids, "unix")
+}
+
+// Indica se a flag foi informada explicitamente na linha de comando
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// Normaliza a versão HTTP informada para o formato de resp.Proto (ex: "2" -> "HTTP/2.0")
+func normalizeHTTPVersion(version string) (string, error) {
+	v := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(version)), "HTTP/")
+	if !strings.Contains(v, ".") {
+		v += ".0"
+	}
+	proto := "HTTP/" + v
+	if _, _, ok := http.ParseHTTPVersion(proto); !ok {
+		return "", fmt.Errorf("versão HTTP inválida: %q", version)
+	}
+	return proto, nil
+}
+
+// Exibe a saída do CEP encontrado da API que forneceu o resultado mais rápido
+func displayResult(result *cep.Result, opts *options) {
+	if opts.format == "json" {
+		printJSON(result)
+		return
+	}
+	// Com -authoritative, as linhas CSV e o template identificam cada resultado
+	if opts.format == "csv" && opts.authoritative != "" {
+		printLabeledCSV(labelFastest, result)
+		return
+	}
+	if opts.format == "csv" {
+		printCSV(result)
+		return
+	}
+	if opts.format == "oneline" {
+		fmt.Printf("%s (%s)\n", result.FormatAddress(), result.API)
+		return
+	}
+	if opts.format == "template" && opts.authoritative != "" {
+		printLabeledTemplate(labelFastest, result, opts.template)
+		return
+	}
+	if opts.format == "template" {
+		printTemplate(result, opts.template)
+		return
+	}
+
+	fmt.Println(tr("Dados do CEP localizado"))
+	fmt.Println("=============================")
+	printFields(result, opts.fields, tr("API vencedora"))
+	fmt.Println("=============================")
+	fmt.Println(tr("Utilização da API mais rápida com sucesso!"))
+}
+
+// Emite os códigos do IBGE, do SIAFI e o DDD como strings nas saídas em
+// JSON, como as APIs os retornam; com -json-numbers-as-strings=false, como
+// números. Definido por parseFlags, como o idioma.
+var jsonNumbersAsStrings = true
+
+// Resultado na saída em JSON, com o tempo de resposta em milissegundos. Os
+// códigos do IBGE, do SIAFI e o DDD sobrepõem os de cep.Result para seguir
+// jsonNumbersAsStrings (ver newJSONResult).
+type jsonResult struct {
+	*cep.Result
+	IBGE            any               `json:"ibge,omitempty"`
+	SIAFI           any               `json:"siafi,omitempty"`
+	DDD             any               `json:"ddd,omitempty"`
+	Municipio       *jsonMunicipality `json:"municipio,omitempty"`
+	TempoRespostaMS float64           `json:"tempo_resposta_ms,omitempty"`
+}
+
+// Dados do município na saída em JSON, com o código do IBGE como em
+// jsonResult
+type jsonMunicipality struct {
+	*cep.Municipality
+	IBGE any `json:"ibge,omitempty"`
+}
+
+// Resultado na saída em JSON, com o tempo de resposta e os códigos no tipo
+// de jsonNumbersAsStrings
+func newJSONResult(result *cep.Result) jsonResult {
+	out := jsonResult{
+		Result:          result,
+		IBGE:            jsonCode(result.IBGE),
+		SIAFI:           jsonCode(result.SIAFI),
+		DDD:             jsonCode(result.DDD),
+		TempoRespostaMS: result.LatencyMS(),
+	}
+	if m := result.Municipality; m != nil {
+		out.Municipio = &jsonMunicipality{Municipality: m, IBGE: jsonCode(m.IBGE)}
+	}
+	return out
+}
+
+// Código numérico (IBGE, SIAFI ou DDD) na saída em JSON: a string da API ou,
+// com jsonNumbersAsStrings desativado, o número, exceto quando o código não
+// for numérico. Vazio é omitido.
+func jsonCode(code string) any {
+	if code == "" {
+		return nil
+	}
+	if jsonNumbersAsStrings {
+		return code
+	}
+	n, err := strconv.ParseInt(code, 10, 64)
+	if err != nil {
+		return code
+	}
+	return n
+}
+
+// Exibe o resultado em JSON, um objeto por linha
+func printJSON(result *cep.Result) {
+	out := newJSONResult(result)
+	if err := json.NewEncoder(os.Stdout).Encode(out); err != nil {
+		slog.Error(tr("Erro ao gerar a saída em JSON"), "erro", err)
+	}
+}
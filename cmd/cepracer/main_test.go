@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckStrictHTTPS(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string // Trecho esperado no erro, vazio se aceita
+	}{
+		{"padrões", []string{"01001000"}, ""},
+		{"API com http", []string{"-url", "viacep=http://127.0.0.1/%s", "01001000"}, "a API ViaCEP"},
+		{"API com http fora da corrida", []string{"-providers", "brasilapi", "-url", "viacep=http://127.0.0.1/%s", "01001000"}, ""},
+		{"geocodificador", []string{"-geo", "-geocoder-url", "http://127.0.0.1/search", "01001000"}, "o geocodificador"},
+		{"geocodificador sem -geo", []string{"-geocoder-url", "http://127.0.0.1/search", "01001000"}, ""},
+		{"webhook", []string{"-webhook", "http://127.0.0.1/hook", "-file", "lote.txt"}, "o webhook"},
+		{"coletor OTLP", []string{"-otlp-endpoint", "http://127.0.0.1:4318", "01001000"}, "o coletor OTLP"},
+		{"DDD", []string{"ddd", "-ddd-url", "http://127.0.0.1/%s", "11"}, "a consulta de DDD"},
+		{"DDD fora do subcomando", []string{"-ddd-url", "http://127.0.0.1/%s", "01001000"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseFlags(tt.args)
+			if err != nil {
+				t.Fatalf("parseFlags: %v", err)
+			}
+			defer opts.close()
+
+			err = checkStrictHTTPS(opts)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("erro inesperado: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("erro = %v, esperado com %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHTTPClientTimeoutFlag(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want time.Duration
+	}{
+		{"padrão: 1,5x -timeout", []string{"-timeout", "2s", "01001000"}, 3 * time.Second},
+		{"padrão com -verify-timeout", []string{"-timeout", "1s", "-primary-then-verify", "-verify-timeout", "1s", "01001000"}, 3 * time.Second},
+		{"explícito", []string{"-timeout", "2s", "-http-client-timeout", "5s", "01001000"}, 5 * time.Second},
+		{"desativado", []string{"-http-client-timeout", "0", "01001000"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseFlags(tt.args)
+			if err != nil {
+				t.Fatalf("parseFlags: %v", err)
+			}
+			defer opts.close()
+			if got := opts.client.HTTPClient.Timeout; got != tt.want {
+				t.Errorf("timeout do client HTTP = %s, esperado %s", got, tt.want)
+			}
+		})
+	}
+}
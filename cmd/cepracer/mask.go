@@ -0,0 +1,32 @@
+package main
+
+import (
+	"io"
+	"strings"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Writer que substitui o CEP pela versão mascarada antes de repassar a saída,
+// garantindo que nenhuma linha de log exponha o CEP completo
+type maskingWriter struct {
+	w        io.Writer
+	replacer *strings.Replacer
+}
+
+// Cria o writer mascarando o CEP tanto no formato "01001000" quanto "01001-000"
+func newMaskingWriter(w io.Writer, code string) *maskingWriter {
+	masked := cep.Mask(code)
+	oldnew := []string{code, masked}
+	if digits := strings.ReplaceAll(code, "-", ""); len(digits) == 8 {
+		oldnew = append(oldnew, digits, masked, digits[:5]+"-"+digits[5:], masked)
+	}
+	return &maskingWriter{w: w, replacer: strings.NewReplacer(oldnew...)}
+}
+
+func (m *maskingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(m.w, m.replacer.Replace(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
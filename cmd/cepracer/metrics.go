@@ -0,0 +1,247 @@
+package main
+
+import (
+	"cmp"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Limites (em segundos) dos buckets do histograma de latência por API
+var latencyBuckets = []float64{0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// Métricas do servidor, expostas em GET /metrics no formato de texto do
+// Prometheus. Seguro para uso concorrente.
+type serveMetrics struct {
+	cache     cep.CacheBackend // Fonte dos acertos e falhas do cache, nil se desativado
+	prefetch  *prefetcher      // Andamento do pré-carregamento, nil sem -prefetch-file
+	client    *cep.Client      // Latência média e APIs retiradas da corrida, nil sem -warn-slow-provider
+	exemplars bool             // Associa o trace das consultas ao histograma de latência (-metrics-exemplars)
+
+	mu        sync.Mutex
+	requests  map[int]uint64               // Requisições de consulta por status HTTP
+	errors    map[string]uint64            // Consultas que falharam, por tipo
+	outcomes  map[[2]string]uint64         // Desfechos por API e resultado
+	latencies map[string]*latencyHistogram // Tempo de resposta por API
+}
+
+// Histograma cumulativo do tempo de resposta de uma API
+type latencyHistogram struct {
+	counts    []uint64 // Observações até cada limite de latencyBuckets
+	count     uint64
+	sum       float64           // Soma das observações, em segundos
+	exemplars []latencyExemplar // Última observação com trace em cada bucket, o último é o +Inf
+}
+
+// Exemplar do OpenMetrics: uma observação do bucket com o trace da consulta
+type latencyExemplar struct {
+	traceID string // Vazio se o bucket ainda não tem exemplar
+	value   float64
+	at      time.Time
+}
+
+func newServeMetrics(cache cep.CacheBackend) *serveMetrics {
+	return &serveMetrics{
+		cache:     cache,
+		requests:  make(map[int]uint64),
+		errors:    make(map[string]uint64),
+		outcomes:  make(map[[2]string]uint64),
+		latencies: make(map[string]*latencyHistogram),
+	}
+}
+
+// Registra o desfecho de uma API na corrida (usado em Client.OnOutcome).
+// Buscas canceladas após a escolha do vencedor não entram no histograma.
+func (m *serveMetrics) observeOutcome(o cep.Outcome) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.outcomes[[2]string{o.API, o.Result}]++
+	if o.Result == "cancelada" {
+		return
+	}
+	h, ok := m.latencies[o.API]
+	if !ok {
+		h = &latencyHistogram{counts: make([]uint64, len(latencyBuckets)), exemplars: make([]latencyExemplar, len(latencyBuckets)+1)}
+		m.latencies[o.API] = h
+	}
+	seconds := o.Elapsed.Seconds()
+	for i, le := range latencyBuckets {
+		if seconds <= le {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+
+	// O exemplar fica no menor bucket que contém a observação
+	if m.exemplars && o.Context != nil {
+		if sc, ok := o.Context.Value(spanContextKey{}).(spanContext); ok && sc.sampled {
+			bucket, _ := slices.BinarySearch(latencyBuckets, seconds)
+			h.exemplars[bucket] = latencyExemplar{traceID: hex.EncodeToString(sc.traceID[:]), value: seconds, at: time.Now()}
+		}
+	}
+}
+
+// Registra uma requisição de consulta pelo status da resposta, classificando
+// as falhas pelo tipo de erro
+func (m *serveMetrics) observeRequest(status int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[status]++
+	switch status {
+	case http.StatusOK:
+	case http.StatusBadRequest:
+		m.errors["cep_invalido"]++
+	case http.StatusNotFound:
+		m.errors["nao_encontrado"]++
+	case http.StatusGatewayTimeout:
+		m.errors["timeout"]++
+	case http.StatusBadGateway:
+		m.errors["falha_apis"]++
+	case http.StatusConflict:
+		m.errors["conflito"]++
+	default:
+		m.errors["interno"]++
+	}
+}
+
+// Conta as requisições respondidas pelo handler, pelo status da resposta
+func (m *serveMetrics) instrument(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+		m.observeRequest(rec.status)
+	}
+}
+
+// ResponseWriter que guarda o status enviado
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Permite o Flush de http.ResponseController (ex: nos eventos de /stream)
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Expõe as métricas no formato de texto do Prometheus ou, com
+// -metrics-exemplars e o coletor aceitando OpenMetrics (cabeçalho Accept),
+// no OpenMetrics, com os exemplars do histograma de latência
+func (m *serveMetrics) handle(w http.ResponseWriter, r *http.Request) {
+	if m.exemplars && strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text") {
+		w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+		m.writeTo(metricsWriter{Writer: w, openMetrics: true})
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.write(w)
+}
+
+// Escreve as métricas no formato de texto do Prometheus
+func (m *serveMetrics) write(w io.Writer) {
+	m.writeTo(metricsWriter{Writer: w})
+}
+
+// Destino das métricas, no formato de texto do Prometheus ou no OpenMetrics
+type metricsWriter struct {
+	io.Writer
+	openMetrics bool
+}
+
+// Escreve a descrição e o tipo da métrica. No OpenMetrics, o nome da
+// família dos contadores não tem o sufixo _total das amostras.
+func (w metricsWriter) family(name, typ, help string) {
+	if w.openMetrics && typ == "counter" {
+		name = strings.TrimSuffix(name, "_total")
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// Encerra a amostra do bucket, com o exemplar no OpenMetrics
+func (w metricsWriter) bucketEnd(e latencyExemplar) {
+	if w.openMetrics && e.traceID != "" {
+		fmt.Fprintf(w, " # {trace_id=%q} %s %.3f", e.traceID, strconv.FormatFloat(e.value, 'g', -1, 64), float64(e.at.UnixMilli())/1000)
+	}
+	fmt.Fprintln(w)
+}
+
+func (m *serveMetrics) writeTo(w metricsWriter) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w.family("cepracer_requests_total", "counter", "Requisições de consulta de CEP recebidas, por status HTTP.")
+	for _, status := range slices.Sorted(maps.Keys(m.requests)) {
+		fmt.Fprintf(w, "cepracer_requests_total{status=\"%d\"} %d\n", status, m.requests[status])
+	}
+
+	w.family("cepracer_errors_total", "counter", "Consultas de CEP que falharam, por tipo de erro.")
+	for _, kind := range slices.Sorted(maps.Keys(m.errors)) {
+		fmt.Fprintf(w, "cepracer_errors_total{tipo=%q} %d\n", kind, m.errors[kind])
+	}
+
+	w.family("cepracer_provider_outcomes_total", "counter", "Desfecho de cada API na corrida (venceu, perdeu, cancelada ou erro).")
+	outcomes := slices.SortedFunc(maps.Keys(m.outcomes), func(a, b [2]string) int {
+		return cmp.Or(cmp.Compare(a[0], b[0]), cmp.Compare(a[1], b[1]))
+	})
+	for _, key := range outcomes {
+		fmt.Fprintf(w, "cepracer_provider_outcomes_total{api=%q,resultado=%q} %d\n", key[0], key[1], m.outcomes[key])
+	}
+
+	if m.cache != nil {
+		hits, misses := m.cache.Stats()
+		w.family("cepracer_cache_hits_total", "counter", "Consultas respondidas pelo cache.")
+		fmt.Fprintf(w, "cepracer_cache_hits_total %d\n", hits)
+		w.family("cepracer_cache_misses_total", "counter", "Consultas sem resultado válido no cache.")
+		fmt.Fprintf(w, "cepracer_cache_misses_total %d\n", misses)
+	}
+	if m.prefetch != nil {
+		m.prefetch.write(w)
+	}
+	if m.client != nil {
+		w.family("cepracer_provider_latency_ema_seconds", "gauge", "Latência média móvel exponencial de cada API (ver -latency-ema-alpha), com -warn-slow-provider.")
+		for _, l := range m.client.ProviderLatencies() {
+			fmt.Fprintf(w, "cepracer_provider_latency_ema_seconds{api=%q} %s\n", l.API, strconv.FormatFloat(l.Average.Seconds(), 'g', -1, 64))
+		}
+		w.family("cepracer_provider_deselected", "gauge", "API fora da corrida por latência alta (1) ou participando (0), com -warn-slow-provider.")
+		for _, l := range m.client.ProviderLatencies() {
+			deselected := 0
+			if l.Deselected {
+				deselected = 1
+			}
+			fmt.Fprintf(w, "cepracer_provider_deselected{api=%q} %d\n", l.API, deselected)
+		}
+	}
+
+	w.family("cepracer_provider_latency_seconds", "histogram", "Tempo de resposta de cada API na corrida, incluindo novas tentativas.")
+	for _, api := range slices.Sorted(maps.Keys(m.latencies)) {
+		h := m.latencies[api]
+		for i, le := range latencyBuckets {
+			fmt.Fprintf(w, "cepracer_provider_latency_seconds_bucket{api=%q,le=%q} %d", api, strconv.FormatFloat(le, 'g', -1, 64), h.counts[i])
+			w.bucketEnd(h.exemplars[i])
+		}
+		fmt.Fprintf(w, "cepracer_provider_latency_seconds_bucket{api=%q,le=\"+Inf\"} %d", api, h.count)
+		w.bucketEnd(h.exemplars[len(latencyBuckets)])
+		fmt.Fprintf(w, "cepracer_provider_latency_seconds_sum{api=%q} %s\n", api, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(w, "cepracer_provider_latency_seconds_count{api=%q} %d\n", api, h.count)
+	}
+	if w.openMetrics {
+		fmt.Fprintln(w, "# EOF")
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Cria o transport das requisições de saída (APIs, webhook e coletor OTLP):
+// o de cep.NewHTTPTransport, que já segue HTTP_PROXY, HTTPS_PROXY e NO_PROXY
+// do ambiente, com o proxy informado no lugar do ambiente e as CAs do
+// arquivo confiáveis além das do sistema
+func newNetworkTransport(proxy, caFile string) (*http.Transport, error) {
+	transport := cep.NewHTTPTransport()
+
+	if proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") || u.Host == "" {
+			return nil, fmt.Errorf("URL inválida para -proxy: %q (use http://, https:// ou socks5://host:porta)", proxy)
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler -ca-file: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("nenhum certificado PEM válido em -ca-file %s", caFile)
+		}
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+	return transport, nil
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Parâmetros da exportação dos spans via OTLP/HTTP (-otlp-endpoint)
+const (
+	otlpTracesPath    = "/v1/traces"
+	otlpQueueSize     = 1024             // Spans aguardando exportação
+	otlpBatchSize     = 256              // Spans por requisição ao coletor
+	otlpFlushInterval = 2 * time.Second  // Intervalo máximo entre as exportações
+	otlpTimeout       = 10 * time.Second // Tempo máximo de cada exportação
+	otlpScope         = "multithreading-apis/pkg/cep"
+)
+
+// Tipos de span do OTLP (SpanKind)
+const (
+	otlpKindInternal = 1
+	otlpKindServer   = 2
+	otlpKindClient   = 3
+)
+
+// Identificação do span no contexto, pai dos spans iniciados a partir dele.
+// Vem de um span local ou do cabeçalho traceparent (W3C Trace Context) de
+// uma requisição recebida pelo servidor.
+type spanContext struct {
+	traceID [16]byte
+	spanID  [8]byte
+	sampled bool
+}
+
+type spanContextKey struct{}
+
+// Tracer que exporta os spans a um coletor OpenTelemetry via OTLP/HTTP, com
+// o corpo em JSON, em lotes e de forma assíncrona, fora do caminho da
+// consulta. Implementa cep.Tracer.
+type otlpTracer struct {
+	url     string // URL de exportação dos spans (endpoint + /v1/traces)
+	service string // Nome do serviço (service.name)
+	client  *http.Client
+
+	mu     sync.Mutex
+	closed bool
+	queue  chan *otlpSpan
+	done   chan struct{}
+}
+
+// Inicia a goroutine de exportação ao coletor. O transport é o da rede: a
+// exportação não passa pela gravação/reprodução nem pela injeção de falhas.
+func newOTLPTracer(endpoint, service string, transport http.RoundTripper) *otlpTracer {
+	url := strings.TrimSuffix(endpoint, "/")
+	if !strings.HasSuffix(url, otlpTracesPath) {
+		url += otlpTracesPath
+	}
+	t := &otlpTracer{
+		url:     url,
+		service: service,
+		client:  &http.Client{Transport: transport, Timeout: otlpTimeout},
+		queue:   make(chan *otlpSpan, otlpQueueSize),
+		done:    make(chan struct{}),
+	}
+	go t.loop()
+	return t
+}
+
+// Inicia um span filho do span do contexto ou, sem ele, a raiz de um novo
+// trace
+func (t *otlpTracer) Start(ctx context.Context, name string, kind cep.SpanKind) (context.Context, cep.Span) {
+	otlpKind := otlpKindInternal
+	if kind == cep.SpanClient {
+		otlpKind = otlpKindClient
+	}
+	return t.start(ctx, name, otlpKind)
+}
+
+func (t *otlpTracer) start(ctx context.Context, name string, kind int) (context.Context, *otlpSpan) {
+	s := &otlpSpan{tracer: t, name: name, kind: kind, start: time.Now()}
+	if parent, ok := ctx.Value(spanContextKey{}).(spanContext); ok {
+		s.ctx.traceID = parent.traceID
+		s.ctx.sampled = parent.sampled
+		s.parentID = parent.spanID
+	} else {
+		rand.Read(s.ctx.traceID[:])
+		s.ctx.sampled = true
+	}
+	rand.Read(s.ctx.spanID[:])
+	return context.WithValue(ctx, spanContextKey{}, s.ctx), s
+}
+
+// Span de uma requisição recebida pelo servidor, filho do traceparent da
+// requisição quando informado, com o status HTTP da resposta
+func (t *otlpTracer) instrument(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		id := requestIDFrom(ctx)
+		if parent, ok := parseTraceparent(r.Header.Get("traceparent")); ok {
+			ctx = context.WithValue(ctx, spanContextKey{}, parent)
+		} else if traceID, ok := requestTraceID(id); ok {
+			// Sem traceparent, o trace é o do identificador de -trace-id-header
+			ctx = context.WithValue(ctx, spanContextKey{}, spanContext{traceID: traceID, sampled: true})
+		}
+		ctx, span := t.start(ctx, r.Method+" "+route, otlpKindServer)
+		// Apenas a rota: o caminho tem o CEP, registrado (mascarado com -mask-cep) no span da consulta
+		span.SetAttributes(slog.String("http.request.method", r.Method), slog.String("http.route", route))
+		if id != "" {
+			span.SetAttributes(slog.String("request.id", id))
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r.WithContext(ctx))
+
+		span.SetAttributes(slog.Int("http.response.status_code", rec.status))
+		var err error
+		if rec.status >= 500 {
+			err = fmt.Errorf("status %d", rec.status)
+		}
+		span.End(err)
+	}
+}
+
+// Interpreta o cabeçalho traceparent (ex: "00-<trace-id>-<span-id>-01"),
+// ignorando valores inválidos
+func parseTraceparent(value string) (spanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return spanContext{}, false
+	}
+	var sc spanContext
+	if _, err := hex.Decode(sc.traceID[:], []byte(parts[1])); err != nil || sc.traceID == [16]byte{} {
+		return spanContext{}, false
+	}
+	if _, err := hex.Decode(sc.spanID[:], []byte(parts[2])); err != nil || sc.spanID == [8]byte{} {
+		return spanContext{}, false
+	}
+	flags, err := strconv.ParseUint(parts[3], 16, 8)
+	if err != nil {
+		return spanContext{}, false
+	}
+	sc.sampled = flags&1 == 1
+	return sc, true
+}
+
+// Enfileira o span encerrado sem bloquear; descarta se a fila estiver cheia
+func (t *otlpTracer) enqueue(s *otlpSpan) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	select {
+	case t.queue <- s:
+	default:
+		slog.Warn(tr("otlp: fila cheia, span descartado"), "span", s.name)
+	}
+}
+
+// Exporta os spans em lotes de até otlpBatchSize ou a cada otlpFlushInterval
+func (t *otlpTracer) loop() {
+	defer close(t.done)
+	ticker := time.NewTicker(otlpFlushInterval)
+	defer ticker.Stop()
+
+	var batch []*otlpSpan
+	for {
+		select {
+		case s, ok := <-t.queue:
+			if !ok {
+				t.export(batch)
+				return
+			}
+			batch = append(batch, s)
+			if len(batch) < otlpBatchSize {
+				continue
+			}
+		case <-ticker.C:
+		}
+		t.export(batch)
+		batch = nil
+	}
+}
+
+// Envia o lote de spans ao coletor; a falha é apenas registrada no log
+func (t *otlpTracer) export(batch []*otlpSpan) {
+	if len(batch) == 0 {
+		return
+	}
+	body, err := json.Marshal(t.encode(batch))
+	if err != nil {
+		slog.Error(tr("otlp: erro ao gerar o JSON"), "erro", err)
+		return
+	}
+	resp, err := t.client.Post(t.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		slog.Warn(tr("otlp: falha na exportação dos spans"), "spans", len(batch), "erro", err)
+		return
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		slog.Warn(tr("otlp: falha na exportação dos spans"), "spans", len(batch), "status", resp.StatusCode)
+	}
+}
+
+// Encerra a fila e aguarda a exportação dos spans pendentes
+func (t *otlpTracer) Close() {
+	t.mu.Lock()
+	if !t.closed {
+		t.closed = true
+		close(t.queue)
+	}
+	t.mu.Unlock()
+	<-t.done
+}
+
+// Span em andamento, exportado ao ser encerrado. Implementa cep.Span.
+type otlpSpan struct {
+	tracer   *otlpTracer
+	ctx      spanContext
+	parentID [8]byte
+	name     string
+	kind     int
+	start    time.Time
+
+	mu    sync.Mutex
+	attrs []slog.Attr
+	end   time.Time
+	err   error
+	ended bool
+}
+
+func (s *otlpSpan) SetAttributes(attrs ...slog.Attr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attrs...)
+}
+
+func (s *otlpSpan) End(err error) {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end, s.err = time.Now(), err
+	s.mu.Unlock()
+
+	// Spans de traces não amostrados pelo chamador não são exportados
+	if s.ctx.sampled {
+		s.tracer.enqueue(s)
+	}
+}
+
+// Corpo da exportação OTLP/HTTP em JSON (ExportTraceServiceRequest), com os
+// identificadores em hexadecimal e os instantes em nanossegundos como texto
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	} `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpScopeSpans struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Spans []otlpSpanData `json:"spans"`
+}
+
+type otlpSpanData struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            *otlpStatus     `json:"status,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"` // 2: erro (STATUS_CODE_ERROR)
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"` // stringValue, intValue (em texto), doubleValue ou boolValue
+}
+
+func (t *otlpTracer) encode(batch []*otlpSpan) otlpRequest {
+	var scope otlpScopeSpans
+	scope.Scope.Name = otlpScope
+	for _, s := range batch {
+		s.mu.Lock()
+		data := otlpSpanData{
+			TraceID:           hex.EncodeToString(s.ctx.traceID[:]),
+			SpanID:            hex.EncodeToString(s.ctx.spanID[:]),
+			Name:              s.name,
+			Kind:              s.kind,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+		}
+		if s.parentID != [8]byte{} {
+			data.ParentSpanID = hex.EncodeToString(s.parentID[:])
+		}
+		for _, a := range s.attrs {
+			data.Attributes = append(data.Attributes, encodeAttribute(a))
+		}
+		if s.err != nil {
+			data.Status = &otlpStatus{Code: 2, Message: s.err.Error()}
+		}
+		s.mu.Unlock()
+		scope.Spans = append(scope.Spans, data)
+	}
+
+	resource := otlpResourceSpans{ScopeSpans: []otlpScopeSpans{scope}}
+	resource.Resource.Attributes = []otlpAttribute{encodeAttribute(slog.String("service.name", t.service))}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{resource}}
+}
+
+// Converte o atributo para o AnyValue do OTLP
+func encodeAttribute(a slog.Attr) otlpAttribute {
+	v := a.Value.Resolve()
+	var value map[string]any
+	switch v.Kind() {
+	case slog.KindInt64:
+		value = map[string]any{"intValue": strconv.FormatInt(v.Int64(), 10)}
+	case slog.KindUint64:
+		value = map[string]any{"intValue": strconv.FormatUint(v.Uint64(), 10)}
+	case slog.KindFloat64:
+		value = map[string]any{"doubleValue": v.Float64()}
+	case slog.KindBool:
+		value = map[string]any{"boolValue": v.Bool()}
+	default:
+		value = map[string]any{"stringValue": v.String()}
+	}
+	return otlpAttribute{Key: a.Key, Value: value}
+}
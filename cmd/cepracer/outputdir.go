@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Diretório de -output-dir, com um arquivo {cep}.json por CEP encontrado no
+// lote
+type outputDir struct {
+	path      string
+	noClobber bool // Mantém os arquivos já existentes em vez de sobrescrevê-los
+	written   int  // Arquivos gravados
+	kept      int  // Arquivos já existentes mantidos com -no-clobber
+}
+
+// Cria o diretório de -output-dir, se ainda não existir
+func newOutputDir(path string, noClobber bool) (*outputDir, error) {
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return nil, fmt.Errorf("erro ao criar o diretório de -output-dir: %v", err)
+	}
+	return &outputDir{path: path, noClobber: noClobber}, nil
+}
+
+// Grava o resultado do CEP em {cep}.json, no mesmo JSON de -format json
+// (indentado). Um CEP repetido no lote ou um arquivo de execuções anteriores
+// é sobrescrito, exceto com -no-clobber. As falhas não geram arquivo.
+func (d *outputDir) write(item batchItem) error {
+	if item.err != nil {
+		return nil
+	}
+	data, err := json.MarshalIndent(newJSONResult(item.result), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Join(d.path, item.cep+".json")
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if d.noClobber {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	}
+	f, err := os.OpenFile(name, flag, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		d.kept++
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao criar %s: %v", name, err)
+	}
+	_, err = f.Write(append(data, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao gravar %s: %v", name, err)
+	}
+	d.written++
+	return nil
+}
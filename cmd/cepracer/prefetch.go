@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Intervalo, em CEPs resolvidos, entre os registros de andamento de uma
+// rodada do pré-carregamento
+const prefetchLogEvery = 100
+
+// Pré-carregamento do cache do servidor com os CEPs de -prefetch-file: a
+// primeira rodada consulta os CEPs ainda fora do cache logo após o início, e
+// as seguintes, a cada -prefetch-interval, renovam todos eles (ver
+// cep.WithCacheRefresh) antes que expirem. As consultas respeitam
+// -prefetch-rate e -concurrency, além do -rate-limit de cada API, e param com
+// o encerramento do servidor.
+type prefetcher struct {
+	opts     *options
+	ceps     []string
+	interval time.Duration
+	rate     float64 // Consultas iniciadas por segundo
+	workers  int
+
+	done     atomic.Int64  // CEPs resolvidos (com ou sem sucesso) na rodada atual
+	failures atomic.Uint64 // Consultas que falharam, somando todas as rodadas
+	rounds   atomic.Uint64 // Rodadas concluídas
+}
+
+// Lê os CEPs de -prefetch-file (um por linha, como no lote), sem repetições
+func newPrefetcher(opts *options) (*prefetcher, error) {
+	file, err := readBatchFile(opts.prefetchFile, "plain", "")
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(file.ceps))
+	ceps := make([]string, 0, len(file.ceps))
+	for _, code := range file.ceps {
+		if !seen[code] {
+			seen[code] = true
+			ceps = append(ceps, code)
+		}
+	}
+	return &prefetcher{
+		opts:     opts,
+		ceps:     ceps,
+		interval: opts.prefetchInterval,
+		rate:     opts.prefetchRate,
+		workers:  opts.concurrency,
+	}, nil
+}
+
+// Executa as rodadas até o fim do contexto do servidor
+func (p *prefetcher) run(ctx context.Context) {
+	slog.Info(tr("Pré-carregamento do cache iniciado"), "ceps", len(p.ceps), "intervalo", p.interval, "taxa", p.rate)
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for refresh := false; ; refresh = true {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		p.round(ctx, refresh)
+		timer.Reset(p.interval)
+	}
+}
+
+// Consulta todos os CEPs uma vez, iniciando no máximo rate consultas por
+// segundo e mantendo no máximo workers em andamento
+func (p *prefetcher) round(ctx context.Context, refresh bool) {
+	start := time.Now()
+	p.done.Store(0)
+	lookupCtx := ctx
+	if refresh {
+		lookupCtx = cep.WithCacheRefresh(ctx)
+	}
+
+	tick := time.NewTicker(max(time.Duration(float64(time.Second)/p.rate), time.Microsecond))
+	defer tick.Stop()
+	sem := make(chan struct{}, p.workers)
+	var wg sync.WaitGroup
+	var failed atomic.Int64
+	for i, code := range p.ceps {
+		if i > 0 {
+			select {
+			case <-tick.C:
+			case <-ctx.Done():
+			}
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			_, err := p.opts.client.Lookup(lookupCtx, code)
+			if err != nil && ctx.Err() == nil {
+				failed.Add(1)
+				p.failures.Add(1)
+				slog.Debug(tr("Falha no pré-carregamento do CEP"), "cep", maskedCEP(code, p.opts), "erro", maskedErrorText(code, err, p.opts))
+			}
+			if n := p.done.Add(1); n%prefetchLogEvery == 0 && int(n) < len(p.ceps) {
+				slog.Info(tr("Pré-carregamento do cache em andamento"), "concluidos", n, "total", len(p.ceps))
+			}
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return
+	}
+	p.rounds.Add(1)
+	slog.Info(tr("Pré-carregamento do cache concluído"), "ceps", len(p.ceps), "falhas", failed.Load(), "renovacao", refresh, "tempo", roundElapsed(time.Since(start)))
+}
+
+// Métricas do pré-carregamento em /metrics (ver serveMetrics.write)
+func (p *prefetcher) write(w metricsWriter) {
+	w.family("cepracer_prefetch_ceps", "gauge", "CEPs distintos de -prefetch-file.")
+	fmt.Fprintf(w, "cepracer_prefetch_ceps %d\n", len(p.ceps))
+	w.family("cepracer_prefetch_done", "gauge", "CEPs resolvidos na rodada atual do pré-carregamento.")
+	fmt.Fprintf(w, "cepracer_prefetch_done %d\n", p.done.Load())
+	w.family("cepracer_prefetch_failures_total", "counter", "Consultas do pré-carregamento que falharam.")
+	fmt.Fprintf(w, "cepracer_prefetch_failures_total %d\n", p.failures.Load())
+	w.family("cepracer_prefetch_rounds_total", "counter", "Rodadas concluídas do pré-carregamento.")
+	fmt.Fprintf(w, "cepracer_prefetch_rounds_total %d\n", p.rounds.Load())
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// Tamanho máximo do identificador recebido em -trace-id-header; valores
+// maiores (ou com caracteres fora do ASCII visível) são substituídos por um
+// gerado
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// Identificador da requisição ao servidor no contexto, vazio fora dele
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Identifica cada requisição pelo cabeçalho de -trace-id-header (ex:
+// X-Request-ID ou X-Correlation-ID), gerando um identificador quando o
+// cabeçalho está ausente ou é inválido. O identificador vai no contexto da
+// requisição, registrado nos logs (ver requestIDHandler) e no span do
+// servidor, e é devolvido no mesmo cabeçalho da resposta. Com traceparent, o
+// identificador é o trace-id do W3C Trace Context.
+func withRequestID(header string, next http.Handler) http.Handler {
+	traceparent := header == "Traceparent"
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := strings.TrimSpace(r.Header.Get(header))
+		id := value
+		if traceparent {
+			id = ""
+			if sc, ok := parseTraceparent(value); ok {
+				id = hex.EncodeToString(sc.traceID[:])
+			}
+		}
+		if !validRequestID(id) {
+			id = newRequestID()
+			value = id
+			if traceparent {
+				value = "00-" + id + "-" + randomHex(8) + "-01"
+			}
+		}
+		w.Header().Set(header, value)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// Indica se o identificador recebido pode ser repetido nos logs e na
+// resposta: não vazio, até maxRequestIDLength e apenas ASCII visível
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// Identificador gerado: 16 bytes aleatórios em hexadecimal, no formato do
+// trace-id do W3C Trace Context, usado também como trace do span do servidor
+func newRequestID() string {
+	return randomHex(16)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Trace do span do servidor a partir do identificador da requisição, quando
+// ele tem o formato de um trace-id (32 dígitos hexadecimais, como os
+// gerados), para que os spans e os logs da requisição se correlacionem
+func requestTraceID(id string) ([16]byte, bool) {
+	var traceID [16]byte
+	if len(id) != 32 {
+		return traceID, false
+	}
+	if _, err := hex.Decode(traceID[:], []byte(id)); err != nil || traceID == [16]byte{} {
+		return traceID, false
+	}
+	return traceID, true
+}
+
+// Handler do slog que acrescenta o identificador da requisição do contexto
+// (request_id) aos registros feitos durante ela, inclusive os do Client
+type requestIDHandler struct {
+	slog.Handler
+}
+
+func (h requestIDHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := requestIDFrom(ctx); id != "" {
+		r = r.Clone()
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h requestIDHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return requestIDHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h requestIDHandler) WithGroup(name string) slog.Handler {
+	return requestIDHandler{h.Handler.WithGroup(name)}
+}
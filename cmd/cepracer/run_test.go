@@ -0,0 +1,384 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Corpos das respostas no formato do ViaCEP
+const (
+	viaCEPFound    = `{"cep": "01001-000", "logradouro": "Praça da Sé", "bairro": "Sé", "localidade": "São Paulo", "uf": "SP"}`
+	viaCEPNotFound = `{"erro": true}`
+)
+
+// Stub de uma API que responde o status e o corpo após o atraso informado
+func newStub(t *testing.T, delay time.Duration, status int, body string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// Executa o programa com os argumentos, retornando o código de saída e o
+// que foi escrito no stdout
+func runCLI(t *testing.T, args ...string) (int, string) {
+	t.Helper()
+	code, stdout, _ := runCLIStderr(t, args...)
+	return code, stdout
+}
+
+// Como runCLI, retornando também o que foi escrito no stderr (o log)
+func runCLIStderr(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	oldArgs, oldStdout, oldStderr, oldCSV, oldLogger := os.Args, os.Stdout, os.Stderr, stdoutCSV, slog.Default()
+	defer func() {
+		os.Args, os.Stdout, os.Stderr, stdoutCSV = oldArgs, oldStdout, oldStderr, oldCSV
+		slog.SetDefault(oldLogger)
+	}()
+
+	capture := func() (*os.File, func() string) {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		copied := make(chan struct{})
+		go func() {
+			io.Copy(&out, r)
+			close(copied)
+		}()
+		return w, func() string {
+			w.Close()
+			<-copied
+			r.Close()
+			return out.String()
+		}
+	}
+	stdout, readStdout := capture()
+	stderr, readStderr := capture()
+
+	os.Args = []string{"cepracer"}
+	if len(args) > 0 && slices.Contains(subcommands, args[0]) {
+		os.Args, args = append(os.Args, args[0]), args[1:]
+	}
+	os.Args = append(os.Args, append([]string{"-lang", "pt", "-retries", "0"}, args...)...)
+	os.Stdout, os.Stderr = stdout, stderr
+	stdoutCSV = &csvOutput{w: csv.NewWriter(stdout)}
+	code := run()
+	return code, readStdout(), readStderr()
+}
+
+func TestRunExitCodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		delay  time.Duration
+		body   string
+		args   []string
+		want   int
+	}{
+		{name: "encontrado", status: http.StatusOK, body: viaCEPFound, want: 0},
+		{name: "CEP inexistente", status: http.StatusOK, body: viaCEPNotFound, want: 3},
+		{name: "timeout", status: http.StatusOK, delay: time.Second, body: viaCEPFound, args: []string{"-timeout", "50ms"}, want: 4},
+		{name: "falha da API", status: http.StatusInternalServerError, want: 1},
+		{name: "opção inválida", status: http.StatusOK, body: viaCEPFound, args: []string{"-format", "xml"}, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newStub(t, tt.delay, tt.status, tt.body)
+			args := append([]string{"-providers", "viacep", "-url", "viacep=" + srv.URL + "/%s"}, tt.args...)
+			if code, _ := runCLI(t, append(args, "01001000")...); code != tt.want {
+				t.Errorf("código de saída = %d, esperado %d", code, tt.want)
+			}
+		})
+	}
+}
+
+// Sem acordo entre as APIs em -strategy quorum, o código de saída é 5
+func TestRunQuorumExitCode(t *testing.T) {
+	viaCEP := newStub(t, 0, http.StatusOK, viaCEPFound)
+	brasilAPI := newStub(t, 0, http.StatusOK, `{"cep": "01001000", "state": "SP", "city": "São Paulo", "neighborhood": "Centro", "street": "Rua Direita"}`)
+	code, _ := runCLI(t, "-strategy", "quorum", "-providers", "viacep,brasilapi",
+		"-url", "viacep="+viaCEP.URL+"/%s", "-url", "brasilapi="+brasilAPI.URL+"/%s", "01001000")
+	if code != 5 {
+		t.Errorf("código de saída = %d, esperado 5", code)
+	}
+}
+
+func TestRunOutputFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		check  func(t *testing.T, out string)
+	}{
+		{"text", func(t *testing.T, out string) {
+			for _, want := range []string{"Buscando CEP: 01001000", "Praça da Sé", "ViaCEP"} {
+				if !strings.Contains(out, want) {
+					t.Errorf("saída sem %q:\n%s", want, out)
+				}
+			}
+		}},
+		{"oneline", func(t *testing.T, out string) {
+			if !strings.Contains(out, "Praça da Sé, Sé, São Paulo - SP, 01001-000") {
+				t.Errorf("saída sem o endereço em uma linha:\n%s", out)
+			}
+		}},
+		{"json", func(t *testing.T, out string) {
+			var result struct {
+				API        string `json:"api"`
+				CEP        string `json:"cep"`
+				Logradouro string `json:"logradouro"`
+			}
+			if err := json.Unmarshal([]byte(out), &result); err != nil {
+				t.Fatalf("saída não é JSON: %v\n%s", err, out)
+			}
+			if result.API != "ViaCEP" || result.CEP != "01001-000" || result.Logradouro != "Praça da Sé" {
+				t.Errorf("resultado = %+v", result)
+			}
+		}},
+		{"csv", func(t *testing.T, out string) {
+			lines := strings.Split(strings.TrimSpace(out), "\n")
+			if len(lines) != 2 || !strings.Contains(lines[0], "logradouro") || !strings.Contains(lines[1], "Praça da Sé") {
+				t.Errorf("esperados o cabeçalho e uma linha:\n%s", out)
+			}
+		}},
+		{"{{.CEP}};{{.Cidade}}/{{.Estado}}", func(t *testing.T, out string) {
+			if strings.TrimSpace(out) != "01001-000;São Paulo/SP" {
+				t.Errorf("saída do template = %q", out)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			srv := newStub(t, 0, http.StatusOK, viaCEPFound)
+			code, out := runCLI(t, "-providers", "viacep", "-url", "viacep="+srv.URL+"/%s", "-format", tt.format, "01001000")
+			if code != 0 {
+				t.Fatalf("código de saída = %d, esperado 0", code)
+			}
+			tt.check(t, out)
+		})
+	}
+}
+
+func TestRunCompare(t *testing.T) {
+	tests := []struct {
+		name      string
+		brasilAPI string
+		agree     bool
+		fields    []string
+	}{
+		{
+			name:      "concordam",
+			brasilAPI: `{"cep": "01001000", "state": "SP", "city": "São Paulo", "neighborhood": "Sé", "street": "Praça da Sé"}`,
+			agree:     true,
+		},
+		{
+			name:      "divergem",
+			brasilAPI: `{"cep": "01001000", "state": "SP", "city": "São Paulo", "neighborhood": "Centro", "street": "Praça da Sé"}`,
+			fields:    []string{"bairro"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viaCEP := newStub(t, 0, http.StatusOK, viaCEPFound)
+			brasilAPI := newStub(t, 20*time.Millisecond, http.StatusOK, tt.brasilAPI)
+			code, out := runCLI(t, "-compare", "-format", "json", "-providers", "viacep,brasilapi",
+				"-url", "viacep="+viaCEP.URL+"/%s", "-url", "brasilapi="+brasilAPI.URL+"/%s", "01001000")
+			if code != 0 {
+				t.Fatalf("código de saída = %d, esperado 0", code)
+			}
+
+			var report struct {
+				Concordam    bool `json:"concordam"`
+				Divergencias []struct {
+					Campo string `json:"campo"`
+				} `json:"divergencias"`
+				Resultados []json.RawMessage `json:"resultados"`
+			}
+			if err := json.Unmarshal([]byte(out), &report); err != nil {
+				t.Fatalf("saída não é JSON: %v\n%s", err, out)
+			}
+			if report.Concordam != tt.agree || len(report.Divergencias) != len(tt.fields) || len(report.Resultados) != 2 {
+				t.Errorf("relatório = %s, esperado concordam=%v e divergências em %v", out, tt.agree, tt.fields)
+			}
+			for i, d := range report.Divergencias {
+				if d.Campo != tt.fields[i] {
+					t.Errorf("divergência no campo %s, esperado %s", d.Campo, tt.fields[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRunAuthoritative(t *testing.T) {
+	const brasilAPIFound = `{"cep": "01001000", "state": "SP", "city": "São Paulo", "neighborhood": "Sé", "street": "Praça da Sé"}`
+	tests := []struct {
+		name         string
+		format       string
+		viaCEPStatus int
+		check        func(t *testing.T, out string)
+	}{
+		{"json", "json", http.StatusOK, func(t *testing.T, out string) {
+			var got map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(out), &got); err != nil {
+				t.Fatalf("saída não é um único objeto JSON: %v\n%s", err, out)
+			}
+			var fastest, authoritative struct {
+				API string `json:"api"`
+			}
+			json.Unmarshal(got["mais_rapido"], &fastest)
+			json.Unmarshal(got["autoritativo"], &authoritative)
+			if fastest.API != "Brasil API" || authoritative.API != "ViaCEP" || got["erro_autoritativo"] != nil {
+				t.Errorf("saída = %s, esperados mais_rapido da Brasil API e autoritativo do ViaCEP", out)
+			}
+		}},
+		{"json com a autoritativa falhando", "json", http.StatusInternalServerError, func(t *testing.T, out string) {
+			var got struct {
+				MaisRapido       map[string]any `json:"mais_rapido"`
+				Autoritativo     map[string]any `json:"autoritativo"`
+				ErroAutoritativo string         `json:"erro_autoritativo"`
+			}
+			if err := json.Unmarshal([]byte(out), &got); err != nil {
+				t.Fatalf("saída não é um único objeto JSON: %v\n%s", err, out)
+			}
+			if got.MaisRapido == nil || !strings.Contains(out, `"autoritativo":null`) || !strings.Contains(got.ErroAutoritativo, "500") {
+				t.Errorf("saída = %s, esperado autoritativo nulo com o erro", out)
+			}
+		}},
+		{"csv", "csv", http.StatusOK, func(t *testing.T, out string) {
+			records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+			if err != nil || len(records) != 3 {
+				t.Fatalf("esperados o cabeçalho e duas linhas: %v\n%s", err, out)
+			}
+			if records[0][0] != "resultado" || records[1][0] != "mais_rapido" || records[1][1] != "Brasil API" || records[2][0] != "autoritativo" || records[2][1] != "ViaCEP" {
+				t.Errorf("linhas sem o rótulo de cada resultado:\n%s", out)
+			}
+		}},
+		{"csv com a autoritativa falhando", "csv", http.StatusInternalServerError, func(t *testing.T, out string) {
+			records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+			if err != nil || len(records) != 3 {
+				t.Fatalf("esperados o cabeçalho e duas linhas: %v\n%s", err, out)
+			}
+			if last := records[2]; last[0] != "autoritativo" || !strings.Contains(last[len(last)-1], "500") {
+				t.Errorf("linha da autoritativa sem o erro: %v", last)
+			}
+		}},
+		{"template", "{{.Resultado}} {{.API}}", http.StatusOK, func(t *testing.T, out string) {
+			if want := "mais_rapido Brasil API\nAutoritativo: autoritativo ViaCEP\n"; out != want {
+				t.Errorf("saída = %q, esperado %q", out, want)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			brasilAPI := newStub(t, 0, http.StatusOK, brasilAPIFound)
+			viaCEP := newStub(t, 50*time.Millisecond, tt.viaCEPStatus, viaCEPFound)
+			code, out := runCLI(t, "-authoritative", "viacep", "-format", tt.format, "-providers", "brasilapi,viacep",
+				"-url", "viacep="+viaCEP.URL+"/%s", "-url", "brasilapi="+brasilAPI.URL+"/%s", "01001000")
+			if code != 0 {
+				t.Fatalf("código de saída = %d, esperado 0", code)
+			}
+			tt.check(t, out)
+		})
+	}
+}
+
+func TestRunConfidence(t *testing.T) {
+	const agreeing = `{"cep": "01001000", "state": "SP", "city": "São Paulo", "neighborhood": "Sé", "street": "Praça da Sé"}`
+	const divergent = `{"cep": "01001000", "state": "SP", "city": "São Paulo", "neighborhood": "Sé", "street": "Rua Direita"}`
+	tests := []struct {
+		name      string
+		brasilAPI string
+		nivel     string
+		text      string
+	}{
+		{"2 de 2 concordam", agreeing, "alta", "Confiança: alta (2 de 2 APIs concordam)"},
+		{"1 de 2 concorda", divergent, "baixa", "Confiança: baixa (1 de 2 APIs concordam)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viaCEP := newStub(t, 0, http.StatusOK, viaCEPFound)
+			brasilAPI := newStub(t, 20*time.Millisecond, http.StatusOK, tt.brasilAPI)
+			args := []string{"-confidence", "-providers", "viacep,brasilapi", "-url", "viacep=" + viaCEP.URL + "/%s", "-url", "brasilapi=" + brasilAPI.URL + "/%s"}
+
+			code, out := runCLI(t, append(args, "-format", "json", "01001000")...)
+			var result struct {
+				API       string `json:"api"`
+				Confianca struct {
+					Concordantes int    `json:"concordantes"`
+					Respostas    int    `json:"respostas"`
+					Nivel        string `json:"nivel"`
+				} `json:"confianca"`
+			}
+			if err := json.Unmarshal([]byte(out), &result); err != nil || code != 0 {
+				t.Fatalf("saída em JSON inválida (código %d): %v\n%s", code, err, out)
+			}
+			if result.API != "ViaCEP" || result.Confianca.Nivel != tt.nivel || result.Confianca.Respostas != 2 {
+				t.Errorf("resultado = %s, esperada a confiança %s com 2 respostas", out, tt.nivel)
+			}
+
+			code, out = runCLI(t, append(args, "01001000")...)
+			if code != 0 || !strings.Contains(out, tt.text) {
+				t.Errorf("saída em texto (código %d) sem %q:\n%s", code, tt.text, out)
+			}
+		})
+	}
+}
+
+func TestRunJSONNumbersAsStrings(t *testing.T) {
+	srv := newStub(t, 0, http.StatusOK, `{"cep": "01001-000", "logradouro": "Praça da Sé", "bairro": "Sé", "localidade": "São Paulo", "uf": "SP", "ibge": "3550308", "siafi": "7107", "ddd": "11"}`)
+	tests := []struct {
+		name string
+		args []string
+		want map[string]any // Valores esperados após o decode em map[string]any
+	}{
+		{"strings por padrão", nil, map[string]any{"ibge": "3550308", "siafi": "7107", "ddd": "11"}},
+		{"números", []string{"-json-numbers-as-strings=false"}, map[string]any{"ibge": 3550308.0, "siafi": 7107.0, "ddd": 11.0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"-providers", "viacep", "-url", "viacep=" + srv.URL + "/%s", "-format", "json"}, tt.args...)
+			code, stdout := runCLI(t, append(args, "01001000")...)
+			if code != 0 {
+				t.Fatalf("código de saída = %d, esperado 0", code)
+			}
+			var got map[string]any
+			if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+				t.Fatalf("saída não é JSON: %v\n%s", err, stdout)
+			}
+			for field, want := range tt.want {
+				if got[field] != want {
+					t.Errorf("%s = %#v, esperado %#v", field, got[field], want)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONCodeNotNumeric(t *testing.T) {
+	defer func() { jsonNumbersAsStrings = true }()
+	jsonNumbersAsStrings = false
+	if got := jsonCode("71-07"); got != "71-07" {
+		t.Errorf("jsonCode = %#v, esperado o código não numérico como string", got)
+	}
+	if got := jsonCode(""); got != nil {
+		t.Errorf("jsonCode vazio = %#v, esperado nil (omitido)", got)
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Tempo máximo para concluir as requisições em andamento ao encerrar o servidor
+const shutdownTimeout = 5 * time.Second
+
+// Corpo de erro das respostas do servidor
+type serveError struct {
+	Erro string   `json:"erro"`
+	APIs []string `json:"apis,omitempty"` // Erro de cada API, quando a consulta falhou
+}
+
+// Inicia o servidor HTTP que expõe a consulta em GET /cep/{cep} e aguarda
+// até receber SIGINT/SIGTERM (ou ficar -max-idle-time sem requisições),
+// concluindo as requisições em andamento
+func runServer(opts *options) int {
+	metrics := newServeMetrics(opts.client.Cache)
+	metrics.exemplars = opts.metricsExemplars
+	opts.client.OnOutcome = metrics.observeOutcome
+	if opts.client.SlowThreshold > 0 {
+		metrics.client = opts.client
+	}
+
+	srv := &http.Server{
+		Addr:              opts.serve,
+		Handler:           recoverPanics(newServeMux(opts, metrics)),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	if opts.traceIDHeader != "" {
+		srv.Handler = withRequestID(opts.traceIDHeader, srv.Handler)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Com -max-idle-time, o encerramento por ociosidade segue o mesmo caminho
+	// do sinal, aguardando as requisições em andamento
+	if opts.maxIdleTime > 0 {
+		watchdog := newIdleWatchdog(opts.maxIdleTime)
+		srv.Handler = watchdog.track(srv.Handler)
+		var idle context.CancelFunc
+		ctx, idle = context.WithCancel(ctx)
+		defer idle()
+		go func() {
+			if watchdog.wait(ctx) {
+				slog.Info(tr("Encerrando o servidor ocioso"), "max_idle_time", opts.maxIdleTime)
+				idle()
+			}
+		}()
+	}
+
+	// O pré-carregamento segue em segundo plano, sem atrasar o início
+	if opts.prefetchFile != "" {
+		p, err := newPrefetcher(opts)
+		if err != nil {
+			slog.Error(tr("Falha ao ler o arquivo de pré-carregamento"), "erro", err)
+			return 1
+		}
+		metrics.prefetch = p
+		go p.run(ctx)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info(tr("Servindo consultas de CEP"), "endereco", opts.serve, "rotas", "GET /cep/{cep}, GET|POST /stream, GET /healthz, GET /metrics")
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		slog.Error(tr("Erro no servidor"), "erro", err)
+		return 1
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error(tr("Erro ao encerrar o servidor"), "erro", err)
+		return 1
+	}
+	return 0
+}
+
+// Rotas do servidor
+func newServeMux(opts *options, metrics *serveMetrics) *http.ServeMux {
+	mux := http.NewServeMux()
+	lookup := metrics.instrument(func(w http.ResponseWriter, r *http.Request) {
+		handleLookup(w, r, opts)
+	})
+	if opts.tracer != nil {
+		lookup = opts.tracer.instrument("/cep/{cep}", lookup)
+	}
+	mux.HandleFunc("GET /cep/{cep}", lookup)
+	stream := metrics.instrument(func(w http.ResponseWriter, r *http.Request) {
+		handleStream(w, r, opts)
+	})
+	if opts.tracer != nil {
+		stream = opts.tracer.instrument("/stream", stream)
+	}
+	mux.HandleFunc("GET /stream", stream)
+	mux.HandleFunc("POST /stream", stream)
+	mux.HandleFunc("GET /healthz", handleHealth)
+	mux.HandleFunc("GET /metrics", metrics.handle)
+	return mux
+}
+
+// Recupera o pânico de qualquer handler (ex: um nil na formatação do
+// resultado), registrando a pilha no log e respondendo 500 com o corpo de
+// erro padrão, sem derrubar o processo. http.ErrAbortHandler, usado para
+// interromper a resposta de propósito, é repassado ao servidor.
+func recoverPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			slog.ErrorContext(r.Context(), tr("Pânico no handler"), "metodo", r.Method, "rota", r.Pattern, "erro", v, "pilha", string(debug.Stack()))
+			writeJSON(w, http.StatusInternalServerError, serveError{Erro: tr("erro interno do servidor")})
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Executa a corrida entre as APIs para o CEP da requisição, com o timeout
+// configurado, e responde com o resultado em JSON
+func handleLookup(w http.ResponseWriter, r *http.Request, opts *options) {
+	code, err := cep.Normalize(r.PathValue("cep"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, serveError{Erro: tr("CEP inválido: deve conter 8 dígitos (recebido %q)", r.PathValue("cep"))})
+		return
+	}
+
+	// Tempo máximo pedido pelo cliente, limitado ao do servidor
+	ctx := r.Context()
+	if budget, ok, err := requestBudget(r, opts); err != nil {
+		writeJSON(w, http.StatusBadRequest, serveError{Erro: err.Error()})
+		return
+	} else if ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, budget)
+		defer cancel()
+		w.Header().Set(opts.budgetHeader, strconv.FormatInt(budget.Milliseconds(), 10))
+	}
+
+	result, err := opts.client.Lookup(ctx, code)
+	if opts.webhook != nil {
+		opts.webhook.sendLookup(code, result, err)
+	}
+	if err == nil {
+		writeJSON(w, http.StatusOK, newJSONResult(result))
+		return
+	}
+
+	// Sem quórum as APIs responderam, mas divergem no endereço
+	var quorumErr *cep.QuorumError
+	if errors.As(err, &quorumErr) {
+		body := serveError{Erro: tr("menos de %d APIs concordam no endereço", quorumErr.Quorum)}
+		for _, r := range quorumErr.Results {
+			body.APIs = append(body.APIs, r.API+": "+r.FormatAddress())
+		}
+		for _, e := range quorumErr.Errs {
+			body.APIs = append(body.APIs, e.Error())
+		}
+		writeJSON(w, http.StatusConflict, body)
+		return
+	}
+
+	// Status pelo tipo da falha; o erro de cada API vai em "apis"
+	body := serveError{}
+	var lookupErr *cep.LookupError
+	if errors.As(err, &lookupErr) {
+		for _, e := range lookupErr.Errs {
+			body.APIs = append(body.APIs, e.Error())
+		}
+	}
+	switch {
+	case errors.Is(err, cep.ErrTimeout):
+		body.Erro = tr("nenhuma API respondeu a tempo")
+		writeJSON(w, http.StatusGatewayTimeout, body)
+	case errors.Is(err, cep.ErrNotFound):
+		body.Erro = tr("CEP não encontrado")
+		writeJSON(w, http.StatusNotFound, body)
+	case errors.Is(err, cep.ErrAllProvidersFailed):
+		body.Erro = tr("nenhuma API retornou o CEP")
+		writeJSON(w, http.StatusBadGateway, body)
+	default:
+		writeJSON(w, http.StatusInternalServerError, serveError{Erro: err.Error()})
+	}
+}
+
+// Tempo máximo da consulta pedido no cabeçalho -deadline-budget-header, em
+// milissegundos, limitado a -max-deadline-budget. ok é falso sem o
+// cabeçalho (ou com a opção desativada); valores que não sejam um inteiro
+// positivo são recusados.
+func requestBudget(r *http.Request, opts *options) (budget time.Duration, ok bool, err error) {
+	if opts.budgetHeader == "" {
+		return 0, false, nil
+	}
+	value := strings.TrimSpace(r.Header.Get(opts.budgetHeader))
+	if value == "" {
+		return 0, false, nil
+	}
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || ms <= 0 {
+		return 0, false, errors.New(tr("tempo inválido em %s: %q (use um número de milissegundos maior que zero)", opts.budgetHeader, value))
+	}
+	budget = time.Duration(ms) * time.Millisecond
+	if ms > opts.maxBudget.Milliseconds() {
+		budget = opts.maxBudget
+	}
+	return budget, true, nil
+}
+
+// Verificação de saúde para balanceadores e orquestradores: responde 200
+// enquanto o servidor aceita requisições, sem consultar as APIs
+func handleHealth(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Responde com o corpo em JSON
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		slog.Error(tr("Erro ao escrever a resposta"), "erro", err)
+	}
+}
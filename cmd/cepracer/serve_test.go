@@ -0,0 +1,558 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// O pânico de um handler vira uma resposta 500 com o corpo de erro padrão,
+// e o servidor segue atendendo
+func TestRecoverPanics(t *testing.T) {
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /panico", func(w http.ResponseWriter, r *http.Request) {
+		var result *jsonResult
+		_ = result.Result.CEP // nil na formatação do resultado
+	})
+	mux.HandleFunc("GET /healthz", handleHealth)
+	srv := httptest.NewServer(recoverPanics(mux))
+	defer srv.Close()
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/panico", http.StatusInternalServerError},
+		{"/healthz", http.StatusOK},
+		{"/panico", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(srv.URL + tt.path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", tt.path, err)
+		}
+		var body serveError
+		decodeErr := json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("GET %s: status %d, esperado %d", tt.path, resp.StatusCode, tt.status)
+		}
+		if tt.status == http.StatusInternalServerError && (decodeErr != nil || body.Erro == "") {
+			t.Errorf("GET %s: corpo sem o erro padrão (%v)", tt.path, decodeErr)
+		}
+	}
+	if !strings.Contains(logs.String(), "Pânico no handler") || !strings.Contains(logs.String(), "runtime/debug.Stack") {
+		t.Errorf("log sem o pânico e a pilha:\n%s", logs.String())
+	}
+}
+
+func TestHandleLookupDeadlineBudget(t *testing.T) {
+	// O stub responde em 100ms; -max-deadline-budget limita o pedido a 300ms
+	stub := newStub(t, 100*time.Millisecond, http.StatusOK, viaCEPFound)
+	opts, err := parseFlags([]string{"-serve", ":0", "-providers", "viacep", "-url", "viacep=" + stub.URL + "/%s",
+		"-retries", "0", "-cache-ttl", "0", "-timeout", "2s", "-max-deadline-budget", "300ms"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer opts.close()
+
+	tests := []struct {
+		name      string
+		budget    string
+		status    int
+		effective string // Valor do cabeçalho na resposta, vazio se ausente
+	}{
+		{"sem cabeçalho", "", http.StatusOK, ""},
+		{"dentro do limite", "250", http.StatusOK, "250"},
+		{"acima do limite", "800", http.StatusOK, "300"},
+		{"menor que a resposta da API", "20", http.StatusGatewayTimeout, "20"},
+		{"não numérico", "abc", http.StatusBadRequest, ""},
+		{"zero", "0", http.StatusBadRequest, ""},
+		{"negativo", "-5", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/cep/01001000", nil)
+			req.SetPathValue("cep", "01001000")
+			if tt.budget != "" {
+				req.Header.Set("X-Timeout-Ms", tt.budget)
+			}
+			rec := httptest.NewRecorder()
+			handleLookup(rec, req, opts)
+
+			if rec.Code != tt.status {
+				t.Errorf("status %d, esperado %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if got := rec.Header().Get("X-Timeout-Ms"); got != tt.effective {
+				t.Errorf("tempo efetivo na resposta = %q, esperado %q", got, tt.effective)
+			}
+		})
+	}
+}
+
+// O watchdog não encerra com uma requisição em andamento e ignora as
+// sondagens de /healthz
+func TestIdleWatchdog(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	watchdog := newIdleWatchdog(timeout)
+	release := make(chan struct{})
+	srv := httptest.NewServer(watchdog.track(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/lenta" {
+			<-release
+		}
+	})))
+	defer srv.Close()
+
+	idle := make(chan time.Time, 1)
+	go func() {
+		if watchdog.wait(t.Context()) {
+			idle <- time.Now()
+		}
+	}()
+	go func() {
+		if resp, err := http.Get(srv.URL + "/lenta"); err == nil {
+			resp.Body.Close()
+		}
+	}()
+
+	// Sondagens durante a requisição lenta não adiam o encerramento
+	for range 4 {
+		time.Sleep(timeout)
+		resp, err := http.Get(srv.URL + "/healthz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	select {
+	case <-idle:
+		t.Fatal("servidor considerado ocioso com uma requisição em andamento")
+	default:
+	}
+
+	released := time.Now()
+	close(release)
+	select {
+	case at := <-idle:
+		if at.Sub(released) < timeout {
+			t.Errorf("ocioso %s após a última requisição, esperado pelo menos %s", at.Sub(released), timeout)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("servidor não considerado ocioso após o fim da requisição")
+	}
+}
+
+func TestRunServerMaxIdleTime(t *testing.T) {
+	done := make(chan int, 1)
+	go func() {
+		code, _ := runCLI(t, "-serve", "127.0.0.1:0", "-max-idle-time", "50ms")
+		done <- code
+	}()
+	select {
+	case code := <-done:
+		if code != 0 {
+			t.Errorf("código de saída = %d, esperado 0 no encerramento por ociosidade", code)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("servidor não encerrou após -max-idle-time")
+	}
+
+	if _, err := parseFlags([]string{"-max-idle-time", "1m", "01001000"}); err == nil || !strings.Contains(err.Error(), "-serve") {
+		t.Errorf("erro = %v, esperado que -max-idle-time exija -serve", err)
+	}
+}
+
+func TestWithRequestID(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tests := []struct {
+		name      string
+		header    string
+		value     string
+		wantID    string // Vazio espera um identificador gerado
+		wantReply string // Cabeçalho esperado na resposta, vazio espera o identificador
+	}{
+		{"repassado", "X-Request-Id", "pedido-42", "pedido-42", ""},
+		{"ausente", "X-Request-Id", "", "", ""},
+		{"com espaço", "X-Request-Id", "pedido 42", "", ""},
+		{"longo demais", "X-Request-Id", strings.Repeat("a", maxRequestIDLength+1), "", ""},
+		{"outro cabeçalho", "X-Correlation-Id", "c0ffee", "c0ffee", ""},
+		{"traceparent", "Traceparent", traceparent, "4bf92f3577b34da6a3ce929d0e0e4736", traceparent},
+		{"traceparent ausente", "Traceparent", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := withRequestID(tt.header, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = requestIDFrom(r.Context())
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/cep/01001000", nil)
+			if tt.value != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if tt.wantID != "" && got != tt.wantID {
+				t.Errorf("identificador = %q, esperado %q", got, tt.wantID)
+			}
+			if _, ok := requestTraceID(got); tt.wantID == "" && !ok {
+				t.Errorf("identificador gerado = %q, esperado um trace-id de 32 dígitos hexadecimais", got)
+			}
+			reply := rec.Header().Get(tt.header)
+			switch {
+			case tt.header == "Traceparent" && tt.value == "":
+				if sc, ok := parseTraceparent(reply); !ok || fmt.Sprintf("%x", sc.traceID) != got {
+					t.Errorf("traceparent da resposta = %q, esperado com o trace-id %q", reply, got)
+				}
+			case tt.wantReply != "" && reply != tt.wantReply:
+				t.Errorf("cabeçalho da resposta = %q, esperado %q", reply, tt.wantReply)
+			case tt.wantReply == "" && reply != got:
+				t.Errorf("cabeçalho da resposta = %q, esperado o identificador %q", reply, got)
+			}
+		})
+	}
+}
+
+// O identificador vai nos logs da requisição e no span do servidor, como o
+// trace quando tem o formato de um trace-id
+func TestRequestIDPropagation(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(requestIDHandler{slog.NewTextHandler(&logs, nil)})
+
+	var exported bytes.Buffer
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(&exported, r.Body)
+	}))
+	defer collector.Close()
+	tracer := newOTLPTracer(collector.URL, "teste", http.DefaultTransport)
+
+	handler := withRequestID("X-Request-Id", tracer.instrument("/cep/{cep}", func(w http.ResponseWriter, r *http.Request) {
+		logger.InfoContext(r.Context(), "consulta")
+	}))
+	const id = "0af7651916cd43dd8448eb211c80319c"
+	req := httptest.NewRequest(http.MethodGet, "/cep/01001000", nil)
+	req.Header.Set("X-Request-ID", id)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	tracer.Close()
+
+	if !strings.Contains(logs.String(), "request_id="+id) {
+		t.Errorf("log sem o identificador da requisição: %s", logs.String())
+	}
+	var body otlpRequest
+	if err := json.Unmarshal(exported.Bytes(), &body); err != nil {
+		t.Fatalf("exportação OTLP inválida: %v\n%s", err, exported.String())
+	}
+	span := body.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	if span.TraceID != id {
+		t.Errorf("trace do span = %s, esperado o identificador %s", span.TraceID, id)
+	}
+	if !strings.Contains(exported.String(), `"key":"request.id","value":{"stringValue":"`+id+`"}`) {
+		t.Errorf("span sem o atributo request.id: %s", exported.String())
+	}
+}
+
+func TestHandleStream(t *testing.T) {
+	stub := newBatchStub(t, "20040020")
+	opts, err := parseFlags([]string{"-serve", ":0", "-providers", "viacep", "-url", "viacep=" + stub.URL + "/%s", "-retries", "0", "-cache-ttl", "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer opts.close()
+	srv := httptest.NewServer(newServeMux(opts, newServeMetrics(opts.client.Cache)))
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		method string
+		query  string
+		body   string
+	}{
+		{"na URL", http.MethodGet, "?ceps=01001000,20040020&cep=01310100", ""},
+		{"no corpo", http.MethodPost, "", "01001000\n20040-020, 01310100"},
+		{"em JSON", http.MethodPost, "", `["01001000", "20040020", "01310-100"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, srv.URL+"/stream"+tt.query, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+				t.Fatalf("Content-Type = %q, esperado text/event-stream", ct)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			events := strings.Split(strings.TrimSuffix(string(body), "\n\n"), "\n\n")
+			if len(events) != 4 {
+				t.Fatalf("%d eventos, esperados 3 result e 1 done:\n%s", len(events), body)
+			}
+			ceps := map[string]bool{}
+			for _, event := range events[:3] {
+				data, ok := strings.CutPrefix(event, "event: result\ndata: ")
+				if !ok {
+					t.Fatalf("evento inesperado: %q", event)
+				}
+				var got struct{ CEP, Erro string }
+				if err := json.Unmarshal([]byte(data), &got); err != nil {
+					t.Fatalf("dados do evento não são JSON: %v", err)
+				}
+				ceps[got.CEP] = got.Erro != ""
+			}
+			want := map[string]bool{"01001-000": false, "20040020": true, "01310-100": false}
+			for code, failed := range want {
+				if got, ok := ceps[code]; !ok || got != failed {
+					t.Errorf("CEP %s: falha = %v (presente: %v), esperado %v; eventos: %v", code, got, ok, failed, ceps)
+				}
+			}
+			if events[3] != `event: done`+"\n"+`data: {"total":3,"encontrados":2,"falhas":1}` {
+				t.Errorf("evento final = %q", events[3])
+			}
+		})
+	}
+
+	resp, err := http.Get(srv.URL + "/stream?ceps=123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("CEP inválido: status %d, esperado 400", resp.StatusCode)
+	}
+}
+
+// Ao desconectar, o cliente cancela as consultas restantes
+func TestHandleStreamClientDisconnect(t *testing.T) {
+	var mu sync.Mutex
+	started := 0
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		started++
+		mu.Unlock()
+		<-r.Context().Done()
+	}))
+	defer api.Close()
+	opts, err := parseFlags([]string{"-serve", ":0", "-providers", "viacep", "-url", "viacep=" + api.URL + "/%s", "-retries", "0", "-cache-ttl", "0", "-timeout", "10s", "-concurrency", "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer opts.close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/stream?ceps=01001000,01001001,01001002,01001003,01001004", nil).WithContext(ctx)
+	done := make(chan struct{})
+	go func() {
+		handleStream(httptest.NewRecorder(), req, opts)
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("handler não encerrou após a desconexão do cliente")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if started != 2 {
+		t.Errorf("%d consultas iniciadas, esperadas 2 (-concurrency) antes da desconexão", started)
+	}
+}
+
+// O pré-carregamento grava os CEPs no cache sem bloquear o início, renova
+// todos eles a cada intervalo (mesmo os que já estão no cache) dentro de
+// -prefetch-rate e para com o contexto do servidor
+func TestPrefetcher(t *testing.T) {
+	stub := newBatchStub(t, "99999999")
+	path := writeBatchFile(t, "prefetch.txt", "01001000\n20040020\n01001-000\n99999999\n")
+	opts, err := parseFlags([]string{"-serve", ":0", "-providers", "viacep", "-url", "viacep=" + stub.URL + "/%s",
+		"-retries", "0", "-prefetch-file", path, "-prefetch-interval", "50ms", "-prefetch-rate", "20"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer opts.close()
+	p, err := newPrefetcher(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.ceps) != 3 {
+		t.Fatalf("CEPs = %v, esperados 3 sem repetições", p.ceps)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	start := time.Now()
+	go func() {
+		defer close(done)
+		p.run(ctx)
+	}()
+	for p.rounds.Load() < 2 {
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("%d rodada(s) concluída(s), esperadas 2", p.rounds.Load())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	// Duas rodadas de 3 CEPs a 20 por segundo: ao menos 2 intervalos de 50ms cada
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("duas rodadas em %v, esperado ao menos 200ms com -prefetch-rate 20", elapsed)
+	}
+	counts := map[string]int{}
+	for _, code := range stub.requests() {
+		counts[code]++
+	}
+	for _, code := range p.ceps {
+		if counts[code] < 2 {
+			t.Errorf("CEP %s consultado %d vez(es), esperado em todas as rodadas", code, counts[code])
+		}
+	}
+	if _, ok, _ := opts.client.Cache.Get(context.Background(), "20040020"); !ok {
+		t.Error("CEP pré-carregado ausente do cache")
+	}
+
+	metrics := newServeMetrics(opts.client.Cache)
+	metrics.prefetch = p
+	var out bytes.Buffer
+	metrics.write(&out)
+	for _, want := range []string{"cepracer_prefetch_ceps 3\n", "cepracer_prefetch_done ", "cepracer_prefetch_rounds_total "} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("métricas sem %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "cepracer_prefetch_failures_total 0\n") {
+		t.Errorf("métricas sem as falhas do CEP inexistente:\n%s", out.String())
+	}
+
+	for _, args := range [][]string{
+		{"-prefetch-file", path, "01001000"},
+		{"-serve", ":0", "-prefetch-file", path, "-cache-ttl", "0"},
+		{"-serve", ":0", "-prefetch-file", path, "-prefetch-rate", "0"},
+	} {
+		if _, err := parseFlags(args); err == nil {
+			t.Errorf("parseFlags(%q) sem erro", args)
+		}
+	}
+}
+
+// Com -warn-slow-provider, /metrics informa a latência média e as APIs fora
+// da corrida
+func TestServeMetricsSlowProvider(t *testing.T) {
+	fast := newStub(t, 0, http.StatusOK, viaCEPFound)
+	slow := newStub(t, 30*time.Millisecond, http.StatusOK, viaCEPFound)
+	opts, err := parseFlags([]string{"-serve", ":0", "-providers", "viacep,brasilapi", "-url", "viacep=" + fast.URL + "/%s",
+		"-url", "brasilapi=" + slow.URL + "/%s", "-retries", "0", "-cache-ttl", "0", "-strategy", "quorum",
+		"-warn-slow-provider", "10ms"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer opts.close()
+	metrics := newServeMetrics(nil)
+	metrics.client = opts.client
+	for range 5 {
+		opts.client.Lookup(context.Background(), "01001000")
+	}
+
+	var out bytes.Buffer
+	metrics.write(&out)
+	for _, want := range []string{`cepracer_provider_deselected{api="Brasil API"} 1`, `cepracer_provider_deselected{api="ViaCEP"} 0`,
+		`cepracer_provider_latency_ema_seconds{api="Brasil API"} 0.0`, `cepracer_provider_latency_ema_seconds{api="ViaCEP"} `} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("métricas sem %q:\n%s", want, out.String())
+		}
+	}
+
+	if _, err := parseFlags([]string{"-warn-slow-provider", "1s", "01001000"}); err == nil || !strings.Contains(err.Error(), "-serve") {
+		t.Errorf("erro = %v, esperado que -warn-slow-provider exija -serve", err)
+	}
+	for _, alpha := range []string{"0", "1.5"} {
+		if _, err := parseFlags([]string{"-serve", ":0", "-warn-slow-provider", "1s", "-latency-ema-alpha", alpha}); err == nil {
+			t.Errorf("-latency-ema-alpha %s aceito, esperado erro", alpha)
+		}
+	}
+}
+
+// Com -metrics-exemplars, o coletor que pede OpenMetrics recebe o trace-id
+// da consulta no bucket do histograma de latência; os demais recebem o
+// formato de texto do Prometheus, sem exemplars
+func TestServeMetricsExemplars(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	stub := newStub(t, 0, http.StatusOK, viaCEPFound)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer collector.Close()
+	opts, err := parseFlags([]string{"-serve", ":0", "-providers", "viacep", "-url", "viacep=" + stub.URL + "/%s",
+		"-retries", "0", "-cache-ttl", "0", "-otlp-endpoint", collector.URL, "-metrics-exemplars"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer opts.close()
+	metrics := newServeMetrics(opts.client.Cache)
+	metrics.exemplars = opts.metricsExemplars
+	opts.client.OnOutcome = metrics.observeOutcome
+	srv := httptest.NewServer(newServeMux(opts, metrics))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/cep/01001000", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	scrape := func(accept string) (string, string) {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/metrics", nil)
+		req.Header.Set("Accept", accept)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.Header.Get("Content-Type"), string(body)
+	}
+	var contentType, body string
+	for start := time.Now(); time.Since(start) < time.Second; time.Sleep(5 * time.Millisecond) {
+		contentType, body = scrape("application/openmetrics-text;version=1.0.0,text/plain;version=0.0.4;q=0.5")
+		if strings.Contains(body, "cepracer_provider_latency_seconds_count") {
+			break
+		}
+	}
+	if !strings.HasPrefix(contentType, "application/openmetrics-text") {
+		t.Errorf("Content-Type = %q, esperado o OpenMetrics", contentType)
+	}
+	exemplar := regexp.MustCompile(`(?m)^cepracer_provider_latency_seconds_bucket\{api="ViaCEP",le="[^"]+"\} 1 # \{trace_id="` + traceID + `"\} [0-9.e-]+ [0-9.]+$`)
+	if !exemplar.MatchString(body) {
+		t.Errorf("histograma sem o exemplar com o trace-id %s:\n%s", traceID, body)
+	}
+	if !strings.Contains(body, "# TYPE cepracer_requests counter\n") || !strings.HasSuffix(body, "# EOF\n") {
+		t.Errorf("saída fora do formato OpenMetrics:\n%s", body)
+	}
+
+	contentType, body = scrape("text/plain")
+	if !strings.HasPrefix(contentType, "text/plain") || strings.Contains(body, "trace_id") || strings.Contains(body, "# EOF") {
+		t.Errorf("Content-Type %q com exemplars ou no OpenMetrics sem pedir o formato:\n%s", contentType, body)
+	}
+
+	if _, err := parseFlags([]string{"-serve", ":0", "-metrics-exemplars"}); err == nil || !strings.Contains(err.Error(), "-otlp-endpoint") {
+		t.Errorf("erro = %v, esperado que -metrics-exemplars exija -otlp-endpoint", err)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Tamanho da fila de snapshots aguardando gravação
+const snapshotQueueSize = 32
+
+// Limite de bytes lidos de uma resposta não consumida ao fechá-la
+const maxSnapshotDrain = 1 << 20
+
+// Corpo bruto da resposta de uma API, a ser gravado em disco
+type snapshot struct {
+	at       time.Time
+	provider string
+	cep      string
+	status   int
+	body     []byte
+}
+
+// Grava os snapshots de forma assíncrona, fora do caminho da requisição
+type snapshotWriter struct {
+	dir     string
+	maskCEP bool
+
+	mu     sync.Mutex
+	closed bool
+	queue  chan snapshot
+	done   chan struct{}
+}
+
+// Cria o diretório de snapshots e inicia a goroutine de gravação
+func newSnapshotWriter(dir string, maskCEP bool) (*snapshotWriter, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("snapshot: erro ao criar %s: %v", dir, err)
+	}
+
+	w := &snapshotWriter{
+		dir:     dir,
+		maskCEP: maskCEP,
+		queue:   make(chan snapshot, snapshotQueueSize),
+		done:    make(chan struct{}),
+	}
+	go w.loop()
+	return w, nil
+}
+
+func (w *snapshotWriter) loop() {
+	defer close(w.done)
+	for s := range w.queue {
+		path := filepath.Join(w.dir, w.filename(s))
+		if err := os.WriteFile(path, s.body, 0o644); err != nil {
+			slog.Error(tr("snapshot: erro ao gravar"), "arquivo", path, "erro", err)
+		}
+	}
+}
+
+// Nome do arquivo: data/hora, API, CEP (mascarado, se configurado) e status
+func (w *snapshotWriter) filename(s snapshot) string {
+	code := s.cep
+	if w.maskCEP {
+		code = strings.ReplaceAll(cep.Mask(code), "*", "x")
+	}
+	provider := strings.ToLower(strings.ReplaceAll(s.provider, " ", ""))
+	return fmt.Sprintf("%s_%s_%s_%d.json", s.at.Format("20060102T150405.000000"), provider, code, s.status)
+}
+
+// Enfileira o snapshot sem bloquear; descarta se a fila estiver cheia
+func (w *snapshotWriter) enqueue(s snapshot) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	select {
+	case w.queue <- s:
+	default:
+		slog.Warn(tr("snapshot: fila cheia, resposta descartada"), "api", s.provider)
+	}
+}
+
+// Encerra a fila e aguarda a gravação dos snapshots pendentes
+func (w *snapshotWriter) Close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+	<-w.done
+}
+
+// Transport que copia o corpo das respostas para o snapshotWriter
+// conforme elas são lidas, sem atrasar a requisição
+type snapshotTransport struct {
+	next   http.RoundTripper
+	writer *snapshotWriter
+}
+
+func (t *snapshotTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, cep := interactionKey(req.URL)
+	resp.Body = &snapshotBody{
+		ReadCloser: resp.Body,
+		writer:     t.writer,
+		snapshot:   snapshot{at: time.Now(), provider: provider, cep: cep, status: resp.StatusCode},
+	}
+	return resp, nil
+}
+
+// Corpo da resposta que acumula os bytes lidos e os envia ao ser fechado
+type snapshotBody struct {
+	io.ReadCloser
+	writer   *snapshotWriter
+	snapshot snapshot
+	buf      bytes.Buffer
+	once     sync.Once
+}
+
+func (b *snapshotBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	return n, err
+}
+
+func (b *snapshotBody) Close() error {
+	b.once.Do(func() {
+		// Respostas descartadas sem leitura (ex: status de erro) também são gravadas
+		io.Copy(&b.buf, io.LimitReader(b.ReadCloser, maxSnapshotDrain))
+		b.snapshot.body = b.buf.Bytes()
+		b.writer.enqueue(b.snapshot)
+	})
+	return b.ReadCloser.Close()
+}
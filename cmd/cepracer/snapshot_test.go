@@ -0,0 +1,120 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Conteúdo dos arquivos gravados no diretório, indexado pelo nome sem a
+// data/hora
+func readSnapshots(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string, len(entries))
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, name, _ := strings.Cut(e.Name(), "_")
+		files[name] = string(data)
+	}
+	return files
+}
+
+// O corpo é gravado tanto quando lido por inteiro quanto quando a resposta é
+// fechada sem leitura, uma única vez por resposta
+func TestSnapshotTransport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "snapshots")
+	writer, err := newSnapshotWriter(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := &snapshotTransport{writer: writer, next: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		status := http.StatusOK
+		if strings.Contains(req.URL.Path, "99999999") {
+			status = http.StatusNotFound
+		}
+		return &http.Response{
+			StatusCode: status,
+			Body:       io.NopCloser(strings.NewReader(`{"url": "` + req.URL.String() + `"}`)),
+			Request:    req,
+		}, nil
+	})}
+
+	read, _ := http.NewRequest(http.MethodGet, "https://viacep.com.br/ws/01001000/json/", nil)
+	resp, err := transport.RoundTrip(read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(resp.Body); string(body) != `{"url": "https://viacep.com.br/ws/01001000/json/"}` {
+		t.Errorf("corpo entregue = %q, esperado o da API", body)
+	}
+	resp.Body.Close()
+	resp.Body.Close()
+
+	unread, _ := http.NewRequest(http.MethodGet, "https://brasilapi.com.br/api/cep/v1/99999999", nil)
+	resp, err = transport.RoundTrip(unread)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	writer.Close()
+	// Após o encerramento, novos snapshots são ignorados
+	writer.enqueue(snapshot{at: time.Now(), provider: "ViaCEP", cep: "20040020", status: http.StatusOK})
+
+	files := readSnapshots(t, dir)
+	want := map[string]string{
+		"viacep_01001000_200.json":    `{"url": "https://viacep.com.br/ws/01001000/json/"}`,
+		"brasilapi_99999999_404.json": `{"url": "https://brasilapi.com.br/api/cep/v1/99999999"}`,
+	}
+	if len(files) != len(want) {
+		t.Errorf("arquivos gravados = %v, esperados %d", files, len(want))
+	}
+	for name, body := range want {
+		if files[name] != body {
+			t.Errorf("%s = %q, esperado %q", name, files[name], body)
+		}
+	}
+}
+
+func TestSnapshotFilename(t *testing.T) {
+	s := snapshot{at: time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC), provider: "Brasil API", cep: "01001000", status: http.StatusOK}
+	for maskCEP, want := range map[bool]string{
+		false: "20260102T030405.000006_brasilapi_01001000_200.json",
+		true:  "20260102T030405.000006_brasilapi_01001-xxx_200.json",
+	} {
+		w := &snapshotWriter{maskCEP: maskCEP}
+		if got := w.filename(s); got != want {
+			t.Errorf("filename com maskCEP=%v = %q, esperado %q", maskCEP, got, want)
+		}
+	}
+}
+
+// Com -response-snapshot-dir, a resposta da consulta está gravada quando o
+// programa termina
+func TestRunResponseSnapshotDir(t *testing.T) {
+	srv := newStub(t, 0, http.StatusOK, viaCEPFound)
+	dir := filepath.Join(t.TempDir(), "snapshots")
+	if code, _ := runCLI(t, "-providers", "viacep", "-url", "viacep="+srv.URL+"/%s", "-response-snapshot-dir", dir, "-mask-cep", "01001000"); code != 0 {
+		t.Fatalf("código de saída = %d, esperado 0", code)
+	}
+	files := readSnapshots(t, dir)
+	if len(files) != 1 {
+		t.Fatalf("arquivos gravados = %v, esperado 1", files)
+	}
+	for name, body := range files {
+		if !strings.HasSuffix(name, "_01001-xxx_200.json") || body != viaCEPFound {
+			t.Errorf("%s = %q, esperado o corpo da API com o CEP mascarado", name, body)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+)
+
+// Tempo máximo para resolver os registros SRV na inicialização
+const srvLookupTimeout = 2 * time.Second
+
+// Registro SRV configurado para uma API (id vazio aplica a todas)
+type srvProvider struct {
+	id   string
+	name string
+}
+
+// Faz o parse do valor de -srv-provider: "viacep=_cepapi._tcp.internal"
+// ou apenas "_cepapi._tcp.internal" para todas as APIs
+func parseSRVProvider(value string) (srvProvider, error) {
+	id, name, found := strings.Cut(value, "=")
+	if !found {
+		id, name = "", value
+	}
+	if name == "" {
+		return srvProvider{}, fmt.Errorf("registro SRV vazio em -srv-provider: %q", value)
+	}
+	if id != "" {
+		if !knownProvider(id) {
+			return srvProvider{}, fmt.Errorf("API desconhecida em -srv-provider: %q", id)
+		}
+	}
+	return srvProvider{id: id, name: name}, nil
+}
+
+// Resolve os registros SRV configurados e substitui o host das URLs das APIs
+// pelo alvo descoberto. Em caso de falha, mantém a URL estática.
+func discoverSRVProviders(urls map[string]string, srvs []srvProvider) {
+	ctx, cancel := context.WithTimeout(context.Background(), srvLookupTimeout)
+	defer cancel()
+
+	for _, srv := range srvs {
+		_, addrs, err := net.DefaultResolver.LookupSRV(ctx, "", "", srv.name)
+		if err != nil || len(addrs) == 0 {
+			slog.Warn(tr("SRV: falha na descoberta, usando URLs estáticas"), "srv", srv.name, "erro", err)
+			continue
+		}
+
+		// Os registros já vêm ordenados por prioridade e peso
+		host := net.JoinHostPort(strings.TrimSuffix(addrs[0].Target, "."), fmt.Sprint(addrs[0].Port))
+		for id, u := range urls {
+			if srv.id == "" || srv.id == id {
+				urls[id] = replaceURLHost(u, host)
+			}
+		}
+	}
+}
+
+// Substitui o host de uma URL (ou template de URL) mantendo esquema e caminho
+func replaceURLHost(rawURL, host string) string {
+	scheme, rest, found := strings.Cut(rawURL, "://")
+	if !found {
+		return rawURL
+	}
+	path := ""
+	if i := strings.Index(rest, "/"); i >= 0 {
+		path = rest[i:]
+	}
+	return scheme + "://" + host + path
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Limites de GET/POST /stream: CEPs por requisição e tamanho do corpo
+const (
+	sseMaxCEPs    = 1000
+	sseMaxBodyLen = 1 << 20
+)
+
+// Evento result de /stream para um CEP que falhou
+type sseError struct {
+	CEP  string `json:"cep"`
+	Erro string `json:"erro"`
+}
+
+// Consulta os CEPs da requisição, no máximo -concurrency ao mesmo tempo, e
+// envia cada resultado como um evento result de Server-Sent Events assim
+// que ele termina (na ordem de conclusão, não na da requisição), seguido de
+// um evento done com o resumo. Quando o cliente desconecta, o contexto da
+// requisição cancela as consultas em andamento e as ainda não iniciadas.
+func handleStream(w http.ResponseWriter, r *http.Request, opts *options) {
+	ceps, err := streamCEPs(w, r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, serveError{Erro: err.Error()})
+		return
+	}
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Sem o buffer de proxies como o nginx
+	w.WriteHeader(http.StatusOK)
+	rc.Flush()
+
+	ctx := r.Context()
+	items := make(chan batchItem)
+	sem := make(chan struct{}, opts.concurrency)
+	var wg sync.WaitGroup
+	go func() {
+		defer close(items)
+		for _, code := range ceps {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				item := lookupBatchItem(ctx, code, opts)
+				select {
+				case items <- item:
+				case <-ctx.Done():
+				}
+			}()
+		}
+		wg.Wait()
+	}()
+
+	summary := batchSummary{}
+	for item := range items {
+		if opts.webhook != nil {
+			opts.webhook.sendLookup(item.cep, item.result, item.err)
+		}
+		summary.Total++
+		var data any
+		if item.err != nil {
+			summary.Falhas++
+			data = sseError{CEP: maskedCEP(item.cep, opts), Erro: maskedErrorText(item.cep, item.err, opts)}
+		} else {
+			summary.Encontrados++
+			data = newJSONResult(item.result)
+		}
+		if err := writeEvent(w, "result", data); err != nil {
+			return
+		}
+		rc.Flush()
+	}
+	if ctx.Err() != nil {
+		slog.InfoContext(ctx, tr("Cliente desconectado do stream"), "consultados", summary.Total, "total", len(ceps))
+		return
+	}
+	writeEvent(w, "done", summary)
+	rc.Flush()
+}
+
+// CEPs de /stream: os parâmetros cep (repetível) e ceps (separados por
+// vírgula) da URL e, em POST, o corpo com os CEPs separados por vírgula,
+// espaço ou linha, ou um array JSON de CEPs
+func streamCEPs(w http.ResponseWriter, r *http.Request) ([]string, error) {
+	var values []string
+	query := r.URL.Query()
+	values = append(values, query["cep"]...)
+	for _, v := range query["ceps"] {
+		values = append(values, strings.Split(v, ",")...)
+	}
+	if r.Method == http.MethodPost {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, sseMaxBodyLen))
+		if err != nil {
+			return nil, errors.New(tr("erro ao ler o corpo: %v", err))
+		}
+		if text := strings.TrimSpace(string(body)); strings.HasPrefix(text, "[") {
+			var codes []string
+			if err := json.Unmarshal(body, &codes); err != nil {
+				return nil, errors.New(tr("corpo inválido: esperado um array JSON de CEPs: %v", err))
+			}
+			values = append(values, codes...)
+		} else {
+			values = append(values, strings.FieldsFunc(text, func(c rune) bool {
+				return c == ',' || c == ';' || c == ' ' || c == '\n' || c == '\r' || c == '\t'
+			})...)
+		}
+	}
+
+	ceps := make([]string, 0, len(values))
+	for _, v := range values {
+		if strings.TrimSpace(v) == "" {
+			continue
+		}
+		code, err := cep.Normalize(strings.TrimSpace(v))
+		if err != nil {
+			return nil, errors.New(tr("CEP inválido: deve conter 8 dígitos (recebido %q)", v))
+		}
+		ceps = append(ceps, code)
+	}
+	switch {
+	case len(ceps) == 0:
+		return nil, errors.New(tr("nenhum CEP informado (use ?ceps=01001000,20040020 ou os CEPs no corpo do POST)"))
+	case len(ceps) > sseMaxCEPs:
+		return nil, errors.New(tr("CEPs demais: %d (máximo %d por requisição)", len(ceps), sseMaxCEPs))
+	}
+	return ceps, nil
+}
+
+// Escreve um evento de Server-Sent Events com os dados em JSON
+func writeEvent(w io.Writer, event string, data any) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		slog.Error(tr("Erro ao gerar a saída em JSON"), "erro", err)
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, body)
+	return err
+}
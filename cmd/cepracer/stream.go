@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Tamanho máximo de uma linha da entrada no modo stream
+const maxStreamLine = 1 << 20
+
+// Resultado de um CEP no modo stream, com o objeto de entrada (quando a
+// linha é NDJSON) para o consumidor correlacionar a resposta
+type streamResult struct {
+	Entrada json.RawMessage `json:"entrada,omitempty"`
+	jsonResult
+}
+
+// Falha de uma linha no modo stream: CEP inválido ou não encontrado
+type streamError struct {
+	Entrada json.RawMessage `json:"entrada,omitempty"`
+	CEP     string          `json:"cep"`
+	Erro    string          `json:"erro"`
+}
+
+// Lê CEPs da entrada padrão, um por linha (ou objetos NDJSON com o campo
+// "cep"), e escreve um objeto JSON por linha na saída padrão à medida que
+// cada consulta termina, fora da ordem de entrada. No máximo
+// opts.concurrency CEPs são consultados ao mesmo tempo: com todas as
+// consultas em andamento, ou a saída bloqueada, a leitura da entrada
+// aguarda.
+func runStream(opts *options) int {
+	out := make(chan any)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		enc := json.NewEncoder(os.Stdout)
+		for v := range out {
+			if err := enc.Encode(v); err != nil {
+				slog.Error(tr("Erro ao gerar a saída em JSON"), "erro", err)
+			}
+		}
+	}()
+
+	var total, failed int
+	var mu sync.Mutex
+	fail := func() {
+		mu.Lock()
+		failed++
+		mu.Unlock()
+	}
+
+	sem := make(chan struct{}, opts.concurrency)
+	var wg sync.WaitGroup
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLine)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		total++
+		entrada, raw, err := parseStreamLine(text)
+		code := raw
+		if err == nil {
+			if code, err = cep.Normalize(raw); err != nil {
+				err = errors.New(tr("CEP inválido: deve conter 8 dígitos (recebido %q)", raw))
+			}
+		}
+		if err != nil {
+			slog.Warn(tr("Linha ignorada"), "arquivo", "stdin", "linha", line, "erro", err)
+			fail()
+			out <- streamError{Entrada: entrada, CEP: raw, Erro: err.Error()}
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(code string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			item := lookupBatchItem(context.Background(), code, opts)
+			if item.err != nil {
+				fail()
+				out <- streamError{Entrada: entrada, CEP: maskedCEP(code, opts), Erro: maskedErrorText(code, item.err, opts)}
+				return
+			}
+			out <- streamResult{Entrada: entrada, jsonResult: newJSONResult(item.result)}
+		}(code)
+	}
+	wg.Wait()
+	close(out)
+	<-done
+
+	if err := scanner.Err(); err != nil {
+		slog.Error(tr("Falha ao ler a entrada do stream"), "erro", err)
+		return 1
+	}
+	if failed == 0 {
+		return 0
+	}
+	slog.Error(tr("CEP(s) do stream falharam"), "falhas", failed, "total", total)
+	return 1
+}
+
+// Extrai o CEP de uma linha da entrada: o texto da linha ou, em objetos
+// JSON, o campo "cep" (texto ou número, completado com zeros à esquerda).
+// Para objetos, retorna também o objeto original.
+func parseStreamLine(text string) (json.RawMessage, string, error) {
+	if !strings.HasPrefix(text, "{") {
+		return nil, text, nil
+	}
+	entrada := json.RawMessage(text)
+	var in struct {
+		CEP any `json:"cep"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(entrada))
+	dec.UseNumber()
+	if err := dec.Decode(&in); err != nil {
+		return nil, text, errors.New(tr("objeto JSON inválido: %v", err))
+	}
+	switch v := in.CEP.(type) {
+	case string:
+		return entrada, v, nil
+	case json.Number:
+		return entrada, fmt.Sprintf("%08s", v.String()), nil
+	case nil:
+		return entrada, "", errors.New(tr("campo \"cep\" ausente"))
+	default:
+		return entrada, "", errors.New(tr("campo \"cep\" inválido: %v", v))
+	}
+}
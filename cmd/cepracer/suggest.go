@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// Sugestões exibidas por padrão no subcomando suggest
+const defaultSuggestLimit = 10
+
+// Sugere endereços para o logradouro parcial, do mais ao menos semelhante,
+// com o CEP de cada um
+func runSuggest(opts *options) int {
+	suggestions, err := opts.client.SuggestAddress(context.Background(), opts.address, opts.suggestLimit)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			slog.Error(tr("Timeout: o ViaCEP não respondeu a tempo"))
+			return 4
+		}
+		slog.Error(tr("Falha na busca por endereço"), "erro", err)
+		return 1
+	}
+	if len(suggestions) == 0 {
+		slog.Warn(tr("Nenhum endereço semelhante encontrado (a busca por endereço está disponível apenas no ViaCEP)"))
+		return 3
+	}
+
+	if opts.format == "text" {
+		fmt.Print(tr("Sugestões para %q em %s/%s\n", opts.address.Logradouro, opts.address.Cidade, opts.address.UF))
+		fmt.Println("=============================")
+	}
+	for _, s := range suggestions {
+		switch opts.format {
+		case "json":
+			if err := json.NewEncoder(os.Stdout).Encode(s); err != nil {
+				slog.Error(tr("Erro ao gerar a saída em JSON"), "erro", err)
+			}
+		case "csv":
+			printCSV(s.Result)
+		case "oneline":
+			fmt.Println(s.FormatAddress())
+		case "template":
+			printTemplate(s, opts.template)
+		default:
+			fmt.Printf("  %3.0f%%  %s\n", s.Score*100, s.FormatAddress())
+		}
+	}
+	if opts.format == "text" {
+		fmt.Println("=============================")
+	}
+	return 0
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// Indica se o valor de -format é um template (ex: '{{.CEP}};{{.Cidade}}')
+// em vez do nome de um formato
+func isFormatTemplate(format string) bool {
+	return strings.Contains(format, "{{")
+}
+
+// Interpreta o template de -format, aplicado a cada cep.Result exibido (ex:
+// {{.CEP}}, {{.Cidade}}, {{.Estado}}, {{.API}}, {{.FormatAddress}})
+func parseFormatTemplate(format string) (*template.Template, error) {
+	tmpl, err := template.New("format").Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("template inválido em -format: %v", err)
+	}
+	return tmpl, nil
+}
+
+// Exibe os dados (o cep.Result ou, em suggest e distance, a sugestão e a
+// distância) com o template de -format, terminado por uma quebra de linha
+// quando o template não a inclui. Erros na execução (ex: campo inexistente)
+// vão para o log, sem saída parcial.
+func printTemplate(data any, tmpl *template.Template) {
+	printTemplateWithPrefix("", data, tmpl)
+}
+
+// Como printTemplate, com o texto informado antes da saída do template
+func printTemplateWithPrefix(prefix string, data any, tmpl *template.Template) {
+	var buf bytes.Buffer
+	buf.WriteString(prefix)
+	if err := tmpl.Execute(&buf, data); err != nil {
+		slog.Error(tr("Erro ao aplicar o template de -format"), "erro", err)
+		return
+	}
+	if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	os.Stdout.Write(buf.Bytes())
+}
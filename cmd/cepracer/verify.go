@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Continua recebendo as respostas das demais APIs após o resultado já ter
+// sido exibido, registrando no log qualquer divergência com o vencedor.
+// A espera é limitada pelo timeout da consulta, estendido por -verify-timeout.
+func verifyAgainstRemaining(r *cep.Race) {
+	winner := r.Result
+	for other, err := range r.Remaining() {
+		if err != nil {
+			slog.Warn(tr("Verificação: API falhou"), "erro", err)
+			continue
+		}
+		if diffs := cep.Diff(winner, other); len(diffs) > 0 {
+			slog.Warn(tr("Divergência com o vencedor"), "vencedor", winner.API, "api", other.API, "campos", strings.Join(diffs, "; "), "tempos", compareLatency(winner, other))
+		} else {
+			slog.Info(tr("Verificação: resultado confirmado"), "vencedor", winner.API, "api", other.API, "tempos", compareLatency(winner, other))
+		}
+	}
+}
+
+// Descreve lado a lado o tempo de resposta das duas APIs e a diferença
+// (ex: "Brasil API 45ms x ViaCEP 120ms, +75ms")
+func compareLatency(winner, other *cep.Result) string {
+	return fmt.Sprintf("%s %s x %s %s, %s", winner.API, roundElapsed(winner.Elapsed), other.API, roundElapsed(other.Elapsed), latencyDiff(winner, other))
+}
+
+// Diferença do tempo de resposta de other em relação ao de winner (ex: "+75ms")
+func latencyDiff(winner, other *cep.Result) string {
+	diff := other.Elapsed - winner.Elapsed
+	if diff < 0 {
+		return "-" + roundElapsed(-diff).String()
+	}
+	return "+" + roundElapsed(diff).String()
+}
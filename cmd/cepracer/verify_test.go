@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"multithreading-apis/pkg/cep"
+)
+
+func TestVerifyAgainstRemaining(t *testing.T) {
+	tests := []struct {
+		name      string
+		brasilAPI string
+		status    int
+		want      []string // Trechos esperados no log
+	}{
+		{
+			name:      "confirmado",
+			brasilAPI: `{"cep": "01001000", "state": "SP", "city": "São Paulo", "neighborhood": "Sé", "street": "Praça da Sé"}`,
+			status:    http.StatusOK,
+			want:      []string{"Verificação: resultado confirmado", "vencedor=ViaCEP", `api="Brasil API"`},
+		},
+		{
+			name:      "divergente",
+			brasilAPI: `{"cep": "01001000", "state": "SP", "city": "São Paulo", "neighborhood": "Centro", "street": "Praça da Sé"}`,
+			status:    http.StatusOK,
+			want:      []string{"Divergência com o vencedor", "vencedor=ViaCEP", "campos=", "bairro"},
+		},
+		{
+			name:   "falha",
+			status: http.StatusInternalServerError,
+			want:   []string{"Verificação: API falhou"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viaCEP := newStub(t, 0, http.StatusOK, viaCEPFound)
+			// A Brasil API responde após o -timeout, dentro do -verify-timeout
+			brasilAPI := newStub(t, 150*time.Millisecond, tt.status, tt.brasilAPI)
+			c := &cep.Client{
+				URLs:          map[string]string{"viacep": viaCEP.URL + "/%s", "brasilapi": brasilAPI.URL + "/%s"},
+				Timeout:       100 * time.Millisecond,
+				VerifyTimeout: time.Second,
+			}
+			for _, id := range []string{"viacep", "brasilapi"} {
+				p, err := cep.NewProvider(id, c)
+				if err != nil {
+					t.Fatal(err)
+				}
+				c.Providers = append(c.Providers, p)
+			}
+
+			r, err := c.Race(context.Background(), "01001000")
+			if err != nil {
+				t.Fatalf("Race: %v", err)
+			}
+			defer r.Close()
+
+			var logs bytes.Buffer
+			defer slog.SetDefault(slog.Default())
+			slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+			verifyAgainstRemaining(r)
+			for _, want := range tt.want {
+				if !strings.Contains(logs.String(), want) {
+					t.Errorf("log sem %q:\n%s", want, logs.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"multithreading-apis/pkg/cep"
+)
+
+// Parâmetros da entrega ao webhook (-webhook)
+const (
+	webhookQueueSize    = 64               // Eventos aguardando entrega
+	webhookQueueTimeout = 5 * time.Second  // Espera máxima por uma vaga na fila cheia antes de descartar o evento
+	webhookTimeout      = 10 * time.Second // Tempo máximo de cada tentativa de entrega
+	webhookBackoff      = 500 * time.Millisecond
+)
+
+// Cabeçalhos enviados ao webhook: o tipo do evento e, com -webhook-secret, a
+// assinatura HMAC-SHA256 do corpo em hexadecimal (ex: "sha256=3f2a...")
+const (
+	webhookEventHeader     = "X-Cepracer-Event"
+	webhookSignatureHeader = "X-Cepracer-Signature"
+)
+
+// Evento entregue ao webhook: o resultado (ou o erro) da consulta de um CEP
+// ou, ao final do lote, o resumo
+type webhookEvent struct {
+	Evento    string        `json:"evento"` // "resultado", "erro" ou "resumo"
+	CEP       string        `json:"cep,omitempty"`
+	Resultado *jsonResult   `json:"resultado,omitempty"`
+	Erro      string        `json:"erro,omitempty"`
+	Resumo    *batchSummary `json:"resumo,omitempty"`
+}
+
+// Resumo do lote, entregue ao webhook após o último CEP
+type batchSummary struct {
+	Total       int `json:"total"`
+	Encontrados int `json:"encontrados"`
+	Falhas      int `json:"falhas"`
+}
+
+// Entrega os eventos ao webhook de forma assíncrona e em ordem, fora do
+// caminho da consulta, com novas tentativas em falhas de rede e respostas
+// 429 ou 5xx
+type webhookSender struct {
+	url       string
+	secret    []byte
+	userAgent string
+	retries   int
+	client    *http.Client
+
+	queueTimeout time.Duration // Espera máxima por uma vaga na fila cheia (webhookQueueTimeout)
+
+	// Os envios à fila compartilham a leitura, e Close, a escrita: a fila só é
+	// fechada sem enfileiramentos em andamento
+	mu      sync.RWMutex
+	closed  bool
+	queue   chan webhookEvent
+	done    chan struct{}
+	dropped atomic.Int64 // Eventos descartados com a fila cheia
+}
+
+// Inicia a goroutine de entrega ao webhook. O transport é o da rede: a
+// entrega não passa pela gravação/reprodução nem pela injeção de falhas.
+func newWebhookSender(url, secret, userAgent string, retries int, transport http.RoundTripper) *webhookSender {
+	w := &webhookSender{
+		url:       url,
+		secret:    []byte(secret),
+		userAgent: userAgent,
+		retries:   retries,
+		client:    &http.Client{Transport: transport, Timeout: webhookTimeout},
+		queue:     make(chan webhookEvent, webhookQueueSize),
+		done:      make(chan struct{}),
+
+		queueTimeout: webhookQueueTimeout,
+	}
+	go w.loop()
+	return w
+}
+
+func (w *webhookSender) loop() {
+	defer close(w.done)
+	for event := range w.queue {
+		if err := w.deliver(event); err != nil {
+			slog.Error(tr("webhook: evento não entregue"), "evento", event.Evento, "erro", err)
+		}
+	}
+}
+
+// Envia o evento, repetindo em falhas temporárias com espera dobrada a cada
+// tentativa
+func (w *webhookSender) deliver(event webhookEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("erro ao gerar o JSON: %v", err)
+	}
+
+	backoff := webhookBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := w.post(event.Evento, body)
+		if err == nil || !retry || attempt >= w.retries {
+			return err
+		}
+		slog.Debug("webhook: nova tentativa", "evento", event.Evento, "tentativa", attempt+1, "erro", err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// Faz o POST do corpo, indicando se a falha é temporária e vale uma nova
+// tentativa
+func (w *webhookSender) post(event string, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", w.userAgent)
+	req.Header.Set(webhookEventHeader, event)
+	if len(w.secret) > 0 {
+		req.Header.Set(webhookSignatureHeader, "sha256="+signWebhook(w.secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("status %d", resp.StatusCode)
+}
+
+// Assinatura HMAC-SHA256 do corpo com o segredo, em hexadecimal, para que o
+// receptor confirme a origem do evento
+func signWebhook(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Enfileira o evento, aguardando a vez se a fila estiver cheia. Com o
+// webhook lento, a espera é limitada a queueTimeout: o evento é
+// descartado e contado, sem travar as consultas nem o encerramento.
+func (w *webhookSender) enqueue(event webhookEvent) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return
+	}
+	select {
+	case w.queue <- event:
+		return
+	default:
+	}
+
+	timer := time.NewTimer(w.queueTimeout)
+	defer timer.Stop()
+	select {
+	case w.queue <- event:
+	case <-timer.C:
+		w.dropped.Add(1)
+		slog.Warn(tr("webhook: fila cheia, evento descartado"), "evento", event.Evento)
+	}
+}
+
+// Enfileira o resultado ou o erro da consulta de um CEP
+func (w *webhookSender) sendLookup(code string, result *cep.Result, err error) {
+	if err != nil {
+		w.enqueue(webhookEvent{Evento: "erro", CEP: code, Erro: batchErrorText(err)})
+		return
+	}
+	resultado := newJSONResult(result)
+	w.enqueue(webhookEvent{Evento: "resultado", CEP: code, Resultado: &resultado})
+}
+
+// Encerra a fila e aguarda a entrega dos eventos pendentes
+func (w *webhookSender) Close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+	<-w.done
+	if dropped := w.dropped.Load(); dropped > 0 {
+		slog.Error(tr("webhook: eventos descartados com a fila cheia"), "descartados", dropped)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWebhookDeliversInOrder(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event webhookEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("corpo inválido: %v", err)
+		}
+		if got := r.Header.Get(webhookEventHeader); got != event.Evento {
+			t.Errorf("%s = %q, esperado %q", webhookEventHeader, got, event.Evento)
+		}
+		mu.Lock()
+		received = append(received, event.CEP)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	w := newWebhookSender(srv.URL, "", "teste", 0, http.DefaultTransport)
+	ceps := []string{"01001000", "20040020", "30130010"}
+	for _, code := range ceps {
+		w.enqueue(webhookEvent{Evento: "erro", CEP: code, Erro: "falha"})
+	}
+	w.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != len(ceps) {
+		t.Fatalf("%d eventos entregues, esperados %d", len(received), len(ceps))
+	}
+	for i := range ceps {
+		if received[i] != ceps[i] {
+			t.Errorf("evento %d = %s, esperado %s", i, received[i], ceps[i])
+		}
+	}
+}
+
+// Com o webhook travado e a fila cheia, os envios concorrentes não se
+// bloqueiam entre si: cada um aguarda no máximo queueTimeout e descarta o
+// evento, e Close não trava
+func TestWebhookFullQueueDropsEvents(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	w := newWebhookSender(srv.URL, "", "teste", 0, http.DefaultTransport)
+	w.queueTimeout = 50 * time.Millisecond
+
+	// Um evento em entrega, a fila cheia e mais extra eventos descartados
+	const extra = 8
+	w.enqueue(webhookEvent{Evento: "erro"})
+	time.Sleep(20 * time.Millisecond)
+	for range webhookQueueSize {
+		w.enqueue(webhookEvent{Evento: "erro"})
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for range extra {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.enqueue(webhookEvent{Evento: "erro"})
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("envios com a fila cheia levaram %s, esperado até queueTimeout cada, em paralelo", elapsed)
+	}
+	if got := w.dropped.Load(); got != extra {
+		t.Errorf("%d eventos descartados, esperados %d", got, extra)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		w.Close()
+		close(closed)
+	}()
+	release <- struct{}{}
+	for range webhookQueueSize {
+		release <- struct{}{}
+	}
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close travou com a fila cheia")
+	}
+}
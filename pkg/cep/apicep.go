@@ -0,0 +1,77 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// URL do ApiCEP (%s é substituído pelo CEP com hífen, ex: 01001-000)
+const apiCEPURL = "https://cdn.apicep.com/file/apicep/%s.json"
+
+// Estrutura para parse de respostas da API - ApiCEP
+type ApiCEPResponse struct {
+	Status   int    `json:"status"`
+	OK       bool   `json:"ok"`
+	Code     string `json:"code"`
+	State    string `json:"state"`
+	City     string `json:"city"`
+	District string `json:"district"`
+	Address  string `json:"address"`
+	Message  string `json:"message"`
+}
+
+// Busca o CEP na API ApiCEP, que publica um arquivo estático por CEP
+func (c *Client) fetchApiCEP(ctx context.Context, cep string) (*Result, error) {
+	// Executa a requisição, medindo o tempo até o fim do parse da resposta.
+	// O arquivo do ApiCEP é nomeado pelo CEP com hífen.
+	resp, start, err := c.get(ctx, c.httpClient(), "ApiCEP", fmt.Sprintf(c.url("apicep"), Format(cep)))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Checa o status code da requisição
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("ApiCEP: %w", ErrCEPNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ApiCEP: %w", &httpStatusError{code: resp.StatusCode})
+	}
+
+	// Realiza leitura e parse das respostas
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("ApiCEP: erro na leitura: %v", err)
+	}
+	if body, err = c.transformBody("ApiCEP", body); err != nil {
+		return nil, err
+	}
+
+	var apiResponse ApiCEPResponse
+	if err := json.Unmarshal(body, &apiResponse); err != nil {
+		return nil, fmt.Errorf("ApiCEP: erro no parse: %v", err)
+	}
+
+	// O ApiCEP também sinaliza o CEP inexistente no corpo, com "ok": false
+	if !apiResponse.OK || apiResponse.Code == "" {
+		return nil, fmt.Errorf("ApiCEP: %w", ErrCEPNotFound)
+	}
+
+	// Resultado unificado
+	result := &Result{
+		API:        "ApiCEP",
+		CEP:        Format(apiResponse.Code),
+		Logradouro: apiResponse.Address,
+		Bairro:     apiResponse.District,
+		Cidade:     apiResponse.City,
+		Estado:     apiResponse.State,
+		Origem:     "apicep",
+		Elapsed:    time.Since(start),
+	}
+
+	return result, nil
+}
@@ -0,0 +1,80 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// URL da Brasil API (%s é substituído pelo CEP)
+const brasilAPIURL = "https://brasilapi.com.br/api/cep/v1/%s"
+
+// Estrutura para parse de respostas da API - Brasil API
+type BrasilAPIResponse struct {
+	CEP          string `json:"cep"`
+	State        string `json:"state"`
+	City         string `json:"city"`
+	Neighborhood string `json:"neighborhood"`
+	Street       string `json:"street"`
+	Service      string `json:"service"`
+
+	Location brasilAPILocation `json:"location"` // Apenas na v2
+}
+
+// Função para busca do cep utilizando a API Brasil API
+func (c *Client) fetchBrasilAPI(ctx context.Context, cep string) (*Result, error) {
+	// Executa a requisição, medindo o tempo até o fim do parse da resposta
+	// Com Client.Geo, a URL padrão é trocada pela da v2, que inclui as coordenadas
+	u := c.url("brasilapi")
+	if c.Geo && u == brasilAPIURL {
+		u = brasilAPIV2URL
+	}
+	resp, start, err := c.get(ctx, c.httpClient(), "Brasil API", fmt.Sprintf(u, cep))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Checa o status code da requisição
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("Brasil API: %w", ErrCEPNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Brasil API: %w", &httpStatusError{code: resp.StatusCode})
+	}
+
+	// Realiza leitura e parse das respostas
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Brasil API: erro na leitura: %v", err)
+	}
+	if body, err = c.transformBody("Brasil API", body); err != nil {
+		return nil, err
+	}
+
+	var apiResponse BrasilAPIResponse
+	if err := json.Unmarshal(body, &apiResponse); err != nil {
+		return nil, fmt.Errorf("Brasil API: erro no parse: %v", err)
+	}
+
+	// Resultado unificado
+	result := &Result{
+		API:        "Brasil API",
+		CEP:        Format(apiResponse.CEP),
+		Logradouro: apiResponse.Street,
+		Bairro:     apiResponse.Neighborhood,
+		Cidade:     apiResponse.City,
+		Estado:     apiResponse.State,
+		Origem:     "brasilapi",
+		Elapsed:    time.Since(start),
+	}
+	if lat, lon, ok := apiResponse.Location.parse(); ok {
+		result.Latitude, result.Longitude = lat, lon
+		result.CoordinatesSource = result.API
+	}
+
+	return result, nil
+}
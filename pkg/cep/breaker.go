@@ -0,0 +1,130 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Erro retornado, sem consultar a API, enquanto o circuito dela está aberto
+var ErrCircuitOpen = errors.New("circuito aberto")
+
+// Tempo padrão com o circuito aberto antes de testar a API novamente
+const breakerCooldown = 30 * time.Second
+
+// Circuit breakers das APIs de um Client, por nome da API. O estado é
+// compartilhado entre as consultas, para que uma API fora do ar deixe de
+// ocupar uma goroutine e o timeout de cada corrida.
+type breakerGroup struct {
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+// Retorna o breaker da API, criando-o fechado na primeira consulta
+func (g *breakerGroup) get(name string, threshold int, cooldown time.Duration) *breaker {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.breakers == nil {
+		g.breakers = make(map[string]*breaker)
+	}
+	b, ok := g.breakers[name]
+	if !ok {
+		b = &breaker{threshold: threshold, cooldown: cooldown}
+		g.breakers[name] = b
+	}
+	return b
+}
+
+// Circuit breaker de uma API: abre após threshold falhas consecutivas, recusa
+// as consultas durante cooldown e então deixa passar uma única consulta de
+// teste (meio aberto), que fecha o circuito se a API responder ou o reabre
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int       // Falhas consecutivas
+	openedAt time.Time // Abertura do circuito, zero se fechado
+	probing  bool      // Consulta de teste em andamento
+}
+
+// Indica se a API pode ser consultada. Com o circuito aberto, retorna também
+// quanto falta para a consulta de teste (0 se ela já está em andamento).
+func (b *breaker) allow() (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openedAt.IsZero() {
+		return 0, true
+	}
+	if wait := b.cooldown - time.Since(b.openedAt); wait > 0 {
+		return wait, false
+	}
+	if b.probing {
+		return 0, false
+	}
+	b.probing = true
+	return 0, true
+}
+
+// Registra o desfecho de uma consulta liberada por allow. CEP não encontrado
+// conta como resposta da API; cancelamentos (outra API venceu a corrida) e o
+// limite de requisições do próprio Client não alteram o estado.
+func (b *breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	probe := b.probing
+	b.probing = false
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(err, ErrRateLimited):
+		return
+	case err == nil || errors.Is(err, ErrCEPNotFound):
+		b.failures = 0
+		b.openedAt = time.Time{}
+		return
+	}
+
+	b.failures++
+	if probe || b.failures >= b.threshold {
+		b.openedAt = time.Now()
+	}
+}
+
+// API protegida pelo circuit breaker
+type breakerProvider struct {
+	Provider
+	breaker *breaker
+}
+
+func (p *breakerProvider) Fetch(ctx context.Context, cep string) (*Result, error) {
+	if wait, ok := p.breaker.allow(); !ok {
+		if wait > 0 {
+			precision := time.Second
+			if wait < time.Second {
+				precision = time.Millisecond
+			}
+			return nil, fmt.Errorf("%s: %w, nova tentativa em %s", p.Name(), ErrCircuitOpen, wait.Round(precision))
+		}
+		return nil, fmt.Errorf("%s: %w, consulta de teste em andamento", p.Name(), ErrCircuitOpen)
+	}
+
+	result, err := p.Provider.Fetch(ctx, cep)
+	p.breaker.record(err)
+	return result, err
+}
+
+// Aplica o circuit breaker da API, quando configurado
+func (c *Client) withBreaker(p Provider) Provider {
+	if c.BreakerThreshold <= 0 {
+		return p
+	}
+	cooldown := c.BreakerCooldown
+	if cooldown <= 0 {
+		cooldown = breakerCooldown
+	}
+	return &breakerProvider{Provider: p, breaker: c.breakers.get(p.Name(), c.BreakerThreshold, cooldown)}
+}
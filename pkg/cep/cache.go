@@ -0,0 +1,424 @@
+package cep
+
+import (
+	"bufio"
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Entradas acrescentadas ao diário do cache persistido antes de incorporá-lo
+// ao arquivo principal (ver LoadCache)
+const cacheJournalLimit = 1000
+
+// Armazenamento dos resultados por CEP normalizado, consultado antes de
+// disparar as requisições (ver Client.Cache): o Cache em memória ou o
+// RedisCache, compartilhado entre instâncias. Deve ser seguro para uso
+// concorrente; uma falha do backend não interrompe a consulta, que segue
+// para as APIs, e é apenas registrada no Client.Logger.
+type CacheBackend interface {
+	Get(ctx context.Context, cep string) (*Result, bool, error) // Cópia do resultado armazenado, marcada como vinda do cache
+	Set(ctx context.Context, cep string, result *Result) error  // Armazena uma cópia do resultado pelo TTL do backend
+	Stats() (hits, misses uint64)                               // Acertos e falhas desde a criação
+	Info(ctx context.Context) (CacheInfo, error)                // Quantidade, tamanho e idade das entradas armazenadas
+	Clear(ctx context.Context) (int, error)                     // Remove todas as entradas, retornando quantas foram removidas
+}
+
+// Resumo das entradas válidas de um CacheBackend (ver CacheBackend.Info)
+type CacheInfo struct {
+	Entries int       // Resultados armazenados
+	Size    int64     // Soma do tamanho dos resultados em JSON, em bytes
+	Oldest  time.Time // Armazenamento da entrada mais antiga, zero sem entradas ou se desconhecido
+	Newest  time.Time // Armazenamento da entrada mais recente, zero sem entradas ou se desconhecido
+}
+
+type cacheRefreshKey struct{}
+
+// Contexto em que Lookup e Race ignoram o resultado armazenado em
+// Client.Cache, consultando as APIs, e gravam o novo resultado, renovando a
+// entrada (ex: o pré-carregamento periódico de um servidor). Ao contrário de
+// trocar o Client.Cache, vale apenas para as consultas com este contexto.
+func WithCacheRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheRefreshKey{}, true)
+}
+
+// Indica se a consulta deve ignorar o resultado do cache (ver WithCacheRefresh)
+func cacheRefresh(ctx context.Context) bool {
+	refresh, _ := ctx.Value(cacheRefreshKey{}).(bool)
+	return refresh
+}
+
+// Cache em memória dos resultados, indexado pelo CEP normalizado. Seguro para
+// uso concorrente: pode ser compartilhado entre as consultas de um Client.
+// Ao atingir o tamanho máximo, descarta o resultado usado há mais tempo.
+type Cache struct {
+	ttl     time.Duration
+	maxSize int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // Elementos de lru, indexados pelo CEP
+	lru     *list.List               // Entradas da mais para a menos usada recentemente
+	hits    uint64                   // Consultas respondidas pelo cache
+	misses  uint64                   // Consultas sem resultado válido no cache
+
+	// Persistência de LoadCache: cada Set é acrescentado ao diário, e Save
+	// o incorpora ao arquivo. Os acessos ao diário são serializados por
+	// journalMu, travado antes de mu quando os dois são necessários.
+	journalMu    sync.Mutex
+	path         string   // Arquivo do cache, vazio sem persistência
+	journal      *os.File // Diário em path + ".journal"
+	journalLines int      // Entradas no diário desde a última incorporação
+}
+
+// Resultado armazenado e o instante em que foi obtido. A validade é
+// calculada com o TTL do cache, inclusive para entradas lidas do arquivo.
+type cacheEntry struct {
+	cep    string
+	result Result
+	stored time.Time
+}
+
+// Cria um cache vazio em que cada resultado vale pelo ttl informado,
+// limitado a maxSize resultados (0 não limita)
+func NewCache(ttl time.Duration, maxSize int) *Cache {
+	return &Cache{ttl: ttl, maxSize: maxSize, entries: make(map[string]*list.Element), lru: list.New()}
+}
+
+// Retorna uma cópia do resultado armazenado, marcada como vinda do cache.
+// Entradas expiradas são removidas. Nunca retorna erro.
+func (c *Cache) Get(_ context.Context, cep string) (*Result, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[cep]
+	if !ok {
+		c.misses++
+		return nil, false, nil
+	}
+	entry := elem.Value.(*cacheEntry)
+	if time.Since(entry.stored) > c.ttl {
+		// A entrada com validadores fica para a revalidação (ver Validated)
+		if entry.result.Validators.IsZero() {
+			c.remove(elem)
+		}
+		c.misses++
+		return nil, false, nil
+	}
+	c.lru.MoveToFront(elem)
+	c.hits++
+
+	result := entry.result
+	result.Cached = true
+	return &result, true, nil
+}
+
+// Retorna uma cópia do resultado armazenado com os validadores da API,
+// válido ou expirado, para a requisição condicional (ver RevalidatingCache).
+// Não conta como acerto nem falha. Nunca retorna erro.
+func (c *Cache) Validated(_ context.Context, cep string) (*Result, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[cep]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := elem.Value.(*cacheEntry)
+	if entry.result.Validators.IsZero() {
+		return nil, false, nil
+	}
+	result := entry.result
+	return &result, true, nil
+}
+
+// Retorna quantas consultas foram respondidas pelo cache (hits) e quantas não
+// encontraram um resultado válido (misses) desde a criação
+func (c *Cache) Stats() (hits, misses uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// Retorna a quantidade, o tamanho em JSON e os instantes de armazenamento
+// da entrada mais antiga e da mais recente, sem contar as expiradas
+func (c *Cache) Info(_ context.Context) (CacheInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var info CacheInfo
+	for elem := c.lru.Front(); elem != nil; elem = elem.Next() {
+		e := elem.Value.(*cacheEntry)
+		if time.Since(e.stored) > c.ttl {
+			continue
+		}
+		data, err := json.Marshal(&e.result)
+		if err != nil {
+			return CacheInfo{}, fmt.Errorf("cache: erro ao gerar a entrada de %s: %v", e.cep, err)
+		}
+		info.Entries++
+		info.Size += int64(len(data))
+		if info.Oldest.IsZero() || e.stored.Before(info.Oldest) {
+			info.Oldest = e.stored
+		}
+		if e.stored.After(info.Newest) {
+			info.Newest = e.stored
+		}
+	}
+	return info, nil
+}
+
+// Remove todas as entradas, retornando quantas havia (inclusive as
+// expiradas). No cache de LoadCache, o arquivo também é esvaziado, com o
+// diário, e o erro indica que a gravação falhou.
+func (c *Cache) Clear(_ context.Context) (int, error) {
+	c.mu.Lock()
+	removed := c.lru.Len()
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.mu.Unlock()
+
+	if c.path == "" {
+		return removed, nil
+	}
+	return removed, c.Save(c.path)
+}
+
+// Armazena uma cópia do resultado pelo TTL configurado. No cache de
+// LoadCache, a entrada também é acrescentada ao diário, e o erro indica que
+// a gravação falhou (a entrada fica apenas em memória).
+func (c *Cache) Set(_ context.Context, cep string, result *Result) error {
+	entry := &cacheEntry{cep: cep, result: *result, stored: time.Now()}
+	c.mu.Lock()
+	c.put(entry)
+	c.mu.Unlock()
+
+	if c.path == "" {
+		return nil
+	}
+	compact, err := c.appendJournal(entry)
+	if err != nil || !compact {
+		return err
+	}
+	return c.Save(c.path)
+}
+
+// Acrescenta a entrada ao diário, indicando se ele atingiu o limite e deve
+// ser incorporado ao arquivo
+func (c *Cache) appendJournal(entry *cacheEntry) (compact bool, err error) {
+	line, err := json.Marshal(newCacheFileEntry(entry))
+	if err != nil {
+		return false, fmt.Errorf("cache: erro ao gerar a entrada de %s: %v", entry.cep, err)
+	}
+
+	c.journalMu.Lock()
+	defer c.journalMu.Unlock()
+	if c.journal == nil {
+		journal, err := os.OpenFile(c.path+".journal", os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return false, fmt.Errorf("cache: erro ao abrir o diário de %s: %v", c.path, err)
+		}
+		c.journal = journal
+	}
+	if _, err := c.journal.Write(append(line, '\n')); err != nil {
+		return false, fmt.Errorf("cache: erro ao gravar o diário de %s: %v", c.path, err)
+	}
+	c.journalLines++
+	return c.journalLines >= cacheJournalLimit, nil
+}
+
+// Insere a entrada como a mais recente, descartando as usadas há mais tempo
+// além do tamanho máximo. Deve ser chamada com mu travado.
+func (c *Cache) put(entry *cacheEntry) {
+	if elem, ok := c.entries[entry.cep]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[entry.cep] = c.lru.PushFront(entry)
+
+	// Descarta os resultados usados há mais tempo além do tamanho máximo
+	for c.maxSize > 0 && c.lru.Len() > c.maxSize {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *Cache) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*cacheEntry).cep)
+}
+
+// Entrada do arquivo de cache
+type cacheFileEntry struct {
+	CEP          string      `json:"cep"`
+	Resultado    Result      `json:"resultado"`
+	ArmazenadoEm time.Time   `json:"armazenado_em"`
+	Validadores  *Validators `json:"validadores,omitempty"` // Validadores da resposta, com Client.Revalidate
+}
+
+func newCacheFileEntry(e *cacheEntry) cacheFileEntry {
+	entry := cacheFileEntry{CEP: e.cep, Resultado: e.result, ArmazenadoEm: e.stored}
+	if !e.result.Validators.IsZero() {
+		v := e.result.Validators
+		entry.Validadores = &v
+	}
+	return entry
+}
+
+// Indica se a entrada deve ser mantida no arquivo: válida ou, expirada, com
+// os validadores para a revalidação
+func (e *cacheEntry) keep(ttl time.Duration) bool {
+	return time.Since(e.stored) <= ttl || !e.result.Validators.IsZero()
+}
+
+// Carrega o cache persistido em path e mantém a persistência até Close.
+// Um arquivo inexistente resulta em um cache vazio; entradas mais antigas que
+// ttl são descartadas, exceto as com validadores (ver Client.Revalidate).
+//
+// O arquivo é JSON, sem dependências como SQLite ou BoltDB: reescrevê-lo a
+// cada resultado custaria caro, então cada Set apenas acrescenta uma linha
+// ao diário path + ".journal", que é incorporado ao arquivo (reescrito
+// atomicamente) por Save e a cada 1000 entradas. Uma interrupção abrupta
+// perde no máximo a linha em gravação, e a linha incompleta é ignorada na
+// próxima carga.
+func LoadCache(path string, ttl time.Duration, maxSize int) (*Cache, error) {
+	c := NewCache(ttl, maxSize)
+	c.path = path
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("cache: erro ao ler %s: %v", path, err)
+	}
+
+	var entries []cacheFileEntry
+	if err == nil {
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("cache: arquivo %s inválido: %v", path, err)
+		}
+	}
+
+	// As entradas do diário são posteriores às do arquivo
+	journaled, err := readCacheJournal(path + ".journal")
+	if err != nil {
+		return nil, err
+	}
+	c.journalLines = len(journaled)
+	entries = append(entries, journaled...)
+
+	// O arquivo vai da entrada menos para a mais usada recentemente
+	for _, e := range entries {
+		e.Resultado.Cached = false
+		if e.Validadores != nil {
+			e.Resultado.Validators = *e.Validadores
+		}
+		entry := &cacheEntry{cep: e.CEP, result: e.Resultado, stored: e.ArmazenadoEm}
+		if entry.keep(ttl) {
+			c.put(entry)
+		}
+	}
+	return c, nil
+}
+
+// Lê as entradas do diário do cache, uma por linha, ignorando as incompletas
+// (ex: interrompidas durante a gravação). Um diário inexistente não tem
+// entradas.
+func readCacheJournal(path string) ([]cacheFileEntry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cache: erro ao ler %s: %v", path, err)
+	}
+	defer f.Close()
+
+	var entries []cacheFileEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var e cacheFileEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.CEP == "" {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cache: erro ao ler %s: %v", path, err)
+	}
+	return entries, nil
+}
+
+// Grava as entradas válidas do cache (e as expiradas com validadores) em
+// path, substituindo o arquivo atomicamente para não corrompê-lo se o
+// programa for interrompido. No arquivo de LoadCache, o diário passa a estar incorporado e é esvaziado.
+func (c *Cache) Save(path string) error {
+	c.journalMu.Lock()
+	defer c.journalMu.Unlock()
+
+	c.mu.Lock()
+	entries := make([]cacheFileEntry, 0, c.lru.Len())
+	for elem := c.lru.Back(); elem != nil; elem = elem.Prev() {
+		e := elem.Value.(*cacheEntry)
+		if e.keep(c.ttl) {
+			entries = append(entries, newCacheFileEntry(e))
+		}
+	}
+	c.mu.Unlock()
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("cache: erro ao gerar %s: %v", path, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("cache: erro ao gravar %s: %v", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("cache: erro ao gravar %s: %v", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("cache: erro ao gravar %s: %v", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("cache: erro ao gravar %s: %v", path, err)
+	}
+
+	if path != c.path {
+		return nil
+	}
+	c.journalLines = 0
+	if c.journal != nil {
+		if err := c.journal.Truncate(0); err != nil {
+			return fmt.Errorf("cache: erro ao esvaziar o diário de %s: %v", path, err)
+		}
+		return nil
+	}
+	if err := os.Remove(path + ".journal"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("cache: erro ao esvaziar o diário de %s: %v", path, err)
+	}
+	return nil
+}
+
+// Fecha o diário do cache de LoadCache, sem incorporá-lo ao arquivo (ver
+// Save). Sem efeito nos demais caches.
+func (c *Cache) Close() error {
+	c.journalMu.Lock()
+	defer c.journalMu.Unlock()
+	if c.journal == nil {
+		return nil
+	}
+	err := c.journal.Close()
+	c.journal = nil
+	return err
+}
+
+// Registra no Logger a falha do backend do cache
+func (c *Client) logCacheError(ctx context.Context, err error) {
+	c.logger().Warn(ctx, "cache", "erro", err)
+}
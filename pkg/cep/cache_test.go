@@ -0,0 +1,278 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoadCachePersistence(t *testing.T) {
+	tests := []struct {
+		name string
+		save bool   // Encerra com Save, incorporando o diário ao arquivo
+		tail string // Linha incompleta no fim do diário (ex: interrompida na gravação)
+	}{
+		{name: "encerramento com Save", save: true},
+		{name: "interrupção sem Save"},
+		{name: "interrupção durante a gravação", tail: `{"cep": "20040`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cep.db")
+			ctx := context.Background()
+
+			c, err := LoadCache(path, time.Hour, 0)
+			if err != nil {
+				t.Fatalf("LoadCache: %v", err)
+			}
+			for _, code := range []string{"01001000", "20040020"} {
+				if err := c.Set(ctx, code, &Result{API: "ViaCEP", CEP: Format(code)}); err != nil {
+					t.Fatalf("Set: %v", err)
+				}
+			}
+			if tt.save {
+				if err := c.Save(path); err != nil {
+					t.Fatalf("Save: %v", err)
+				}
+			}
+			c.Close()
+			if tt.tail != "" {
+				f, err := os.OpenFile(path+".journal", os.O_WRONLY|os.O_APPEND, 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+				f.WriteString(tt.tail)
+				f.Close()
+			}
+
+			loaded, err := LoadCache(path, time.Hour, 0)
+			if err != nil {
+				t.Fatalf("LoadCache após reinício: %v", err)
+			}
+			defer loaded.Close()
+			for _, code := range []string{"01001000", "20040020"} {
+				result, ok, _ := loaded.Get(ctx, code)
+				if !ok || result.CEP != Format(code) || !result.Cached {
+					t.Errorf("Get(%s) = %v, %v, esperado o resultado persistido", code, result, ok)
+				}
+			}
+		})
+	}
+}
+
+// O diário é incorporado ao arquivo a cada cacheJournalLimit entradas
+func TestCacheJournalCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cep.db")
+	c, err := LoadCache(path, time.Hour, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	for i := range cacheJournalLimit {
+		code := fmt.Sprintf("0100%04d", i)
+		if err := c.Set(context.Background(), code, &Result{CEP: code}); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("arquivo não gravado ao atingir o limite do diário: %v", err)
+	}
+	if info, err := os.Stat(path + ".journal"); err != nil || info.Size() != 0 {
+		t.Errorf("diário não esvaziado após a incorporação: %v, %v", info, err)
+	}
+}
+
+func TestCacheInfoAndClear(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cep.db")
+	ctx := context.Background()
+	c, err := LoadCache(path, time.Hour, 0)
+	if err != nil {
+		t.Fatalf("LoadCache: %v", err)
+	}
+	defer c.Close()
+
+	if info, err := c.Info(ctx); err != nil || info != (CacheInfo{}) {
+		t.Errorf("Info no cache vazio = %+v, %v, esperado zero", info, err)
+	}
+	before := time.Now()
+	for _, code := range []string{"01001000", "20040020", "70040010"} {
+		if err := c.Set(ctx, code, &Result{API: "ViaCEP", CEP: Format(code)}); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+
+	info, err := c.Info(ctx)
+	if err != nil {
+		t.Fatalf("Info: %v", err)
+	}
+	if info.Entries != 3 || info.Size <= 0 {
+		t.Errorf("Info = %+v, esperadas 3 entradas com tamanho", info)
+	}
+	if info.Oldest.Before(before) || info.Newest.Before(info.Oldest) || time.Since(info.Newest) > time.Minute {
+		t.Errorf("Info: mais antiga %v e mais recente %v fora do intervalo das gravações", info.Oldest, info.Newest)
+	}
+
+	removed, err := c.Clear(ctx)
+	if err != nil || removed != 3 {
+		t.Fatalf("Clear = %d, %v, esperadas 3 entradas removidas", removed, err)
+	}
+	if _, ok, _ := c.Get(ctx, "01001000"); ok {
+		t.Error("entrada ainda no cache após Clear")
+	}
+
+	// O arquivo e o diário também são esvaziados
+	loaded, err := LoadCache(path, time.Hour, 0)
+	if err != nil {
+		t.Fatalf("LoadCache após Clear: %v", err)
+	}
+	defer loaded.Close()
+	if info, _ := loaded.Info(ctx); info.Entries != 0 {
+		t.Errorf("%d entradas no arquivo após Clear, esperado nenhuma", info.Entries)
+	}
+}
+
+func TestWithCacheRefresh(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(viaCEPPracaDaSe)
+	}))
+	defer srv.Close()
+	cache := NewCache(time.Hour, 0)
+	c := &Client{Providers: stubProviders([]string{"A"}, srv), Timeout: time.Second, Cache: cache}
+	ctx := context.Background()
+
+	if _, err := c.Lookup(ctx, "01001000"); err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+	result, err := c.Lookup(ctx, "01001000")
+	if err != nil || !result.Cached {
+		t.Fatalf("segundo Lookup = %+v, %v, esperado o resultado do cache", result, err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Fatalf("%d requisições antes da renovação, esperada 1", n)
+	}
+
+	// A renovação consulta a API mesmo com o resultado no cache e o regrava
+	result, err = c.Lookup(WithCacheRefresh(ctx), "01001000")
+	if err != nil || result.Cached {
+		t.Fatalf("Lookup com WithCacheRefresh = %+v, %v, esperado o resultado da API", result, err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("%d requisições após a renovação, esperadas 2", n)
+	}
+	if result, ok, _ := cache.Get(ctx, "01001000"); !ok || result.API != "A" {
+		t.Errorf("cache após a renovação = %+v, %v, esperado o resultado renovado", result, ok)
+	}
+}
+
+func TestRevalidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		validators  Validators
+		conditional bool // A consulta após o TTL envia os cabeçalhos condicionais e recebe o 304
+	}{
+		{"etag", Validators{ETag: `"v1"`}, true},
+		{"last-modified", Validators{LastModified: "Wed, 01 Oct 2025 00:00:00 GMT"}, true},
+		{"sem validadores", Validators{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var full, notModified atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.validators.ETag != "" {
+					w.Header().Set("ETag", tt.validators.ETag)
+				}
+				if tt.validators.LastModified != "" {
+					w.Header().Set("Last-Modified", tt.validators.LastModified)
+				}
+				etag, since := r.Header.Get("If-None-Match"), r.Header.Get("If-Modified-Since")
+				if (etag != "" && etag == tt.validators.ETag) || (since != "" && since == tt.validators.LastModified) {
+					notModified.Add(1)
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				full.Add(1)
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(viaCEPPracaDaSe)
+			}))
+			defer srv.Close()
+			const ttl = 50 * time.Millisecond
+			c := newViaCEPClient(t, srv)
+			c.Cache = NewCache(ttl, 0)
+			c.Revalidate = true
+			ctx := context.Background()
+
+			first, err := c.Lookup(ctx, "01001000")
+			if err != nil {
+				t.Fatalf("Lookup: %v", err)
+			}
+			if first.Validators != tt.validators {
+				t.Errorf("validadores = %+v, esperados %+v", first.Validators, tt.validators)
+			}
+
+			// Após o TTL, o 304 reaproveita o resultado armazenado
+			time.Sleep(ttl + 10*time.Millisecond)
+			second, err := c.Lookup(ctx, "01001000")
+			if err != nil || second.Cached || second.Logradouro != "Praça da Sé" {
+				t.Fatalf("Lookup após o TTL = %+v, %v, esperado o resultado da API", second, err)
+			}
+			wantFull, wantNotModified := int32(1), int32(1)
+			if !tt.conditional {
+				wantFull, wantNotModified = 2, 0
+			}
+			if full.Load() != wantFull || notModified.Load() != wantNotModified {
+				t.Fatalf("%d respostas completas e %d 304, esperadas %d e %d", full.Load(), notModified.Load(), wantFull, wantNotModified)
+			}
+
+			// O TTL foi renovado: a consulta seguinte vem do cache
+			third, err := c.Lookup(ctx, "01001000")
+			if err != nil || !third.Cached {
+				t.Errorf("Lookup após a revalidação = %+v, %v, esperado o resultado do cache", third, err)
+			}
+		})
+	}
+}
+
+// Os validadores são persistidos com a entrada, que continua disponível
+// para a revalidação após o TTL, inclusive depois de recarregada
+func TestCacheValidatorsPersisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cep.db")
+	const ttl = 20 * time.Millisecond
+	ctx := context.Background()
+	cache, err := LoadCache(path, ttl, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validated := Result{API: "ViaCEP", CEP: "01001-000", Validators: Validators{ETag: `"v1"`}}
+	cache.Set(ctx, "01001000", &validated)
+	cache.Set(ctx, "20040020", &Result{API: "ViaCEP", CEP: "20040-020"})
+	time.Sleep(ttl + 10*time.Millisecond)
+	if err := cache.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	cache.Close()
+
+	loaded, err := LoadCache(path, ttl, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loaded.Close()
+	if _, ok, _ := loaded.Get(ctx, "01001000"); ok {
+		t.Error("entrada expirada retornada por Get")
+	}
+	if result, ok, _ := loaded.Validated(ctx, "01001000"); !ok || result.Validators != validated.Validators {
+		t.Errorf("Validated = %+v, %v, esperada a entrada expirada com os validadores", result, ok)
+	}
+	if _, ok, _ := loaded.Validated(ctx, "20040020"); ok {
+		t.Error("entrada expirada sem validadores mantida no arquivo")
+	}
+}
@@ -0,0 +1,306 @@
+// Pacote cep consulta o endereço de um CEP disparando a busca em várias APIs
+// simultaneamente e retornando o resultado da mais rápida.
+//
+//	result, err := cep.Lookup(ctx, "01001-000")
+//
+// Para configurar o client HTTP, as URLs, as APIs participantes e demais
+// opções, use um Client (ver NewClient).
+package cep
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Erro retornado quando o protocolo HTTP negociado difere do exigido
+var ErrHTTPVersionMismatch = errors.New("versão HTTP inesperada")
+
+// Erro retornado quando a API informa que o CEP não existe
+var ErrCEPNotFound = errors.New("CEP não encontrado")
+
+// Erro retornado quando o corpo da resposta é recusado pelo Transform de
+// RequestOptions, antes do parse
+var ErrInvalidResponse = errors.New("resposta inválida")
+
+// Falhas da consulta expostas por *LookupError para errors.Is, uma por
+// consulta: o CEP não existe em nenhuma API (ErrNotFound, o mesmo valor de
+// ErrCEPNotFound), o tempo limite foi atingido sem resultado (ErrTimeout) ou
+// todas as APIs falharam por outro motivo, como rede, status HTTP ou
+// resposta inválida (ErrAllProvidersFailed). Os erros de cada API continuam
+// acessíveis por errors.Is/errors.As.
+var (
+	ErrNotFound           = ErrCEPNotFound
+	ErrTimeout            = errors.New("nenhuma API respondeu a tempo")
+	ErrAllProvidersFailed = errors.New("nenhuma API retornou o CEP")
+)
+
+// Estrutura unificada com o resultado de qualquer uma das APIs
+type Result struct {
+	API        string `json:"api"`
+	CEP        string `json:"cep"`
+	Logradouro string `json:"logradouro"`
+	Bairro     string `json:"bairro"`
+	Cidade     string `json:"cidade"`
+	Estado     string `json:"estado"`
+	Origem     string `json:"origem"`          // Identificador da API (ex: "brasilapi", "viacep")
+	IBGE       string `json:"ibge,omitempty"`  // Código do município no IBGE, quando informado pela API (ou com Client.IBGE)
+	SIAFI      string `json:"siafi,omitempty"` // Código do município no SIAFI, quando informado pela API
+	DDD        string `json:"ddd,omitempty"`   // DDD da região, quando informado pela API
+
+	SomenteMunicipio  bool            `json:"somente_municipio,omitempty"` // Resultado aproximado, apenas com cidade e estado
+	Geometry          json.RawMessage `json:"area,omitempty"`              // Área de entrega aproximada (GeoJSON), quando disponível
+	TimeZone          string          `json:"fuso,omitempty"`              // Fuso horário IANA derivado do estado, quando solicitado
+	Municipality      *Municipality   `json:"municipio,omitempty"`         // Dados do município no IBGE, quando solicitados (ver Client.IBGE)
+	Latitude          float64         `json:"latitude,omitempty"`          // Coordenadas do endereço, quando disponíveis (ver Client.Geo)
+	Longitude         float64         `json:"longitude,omitempty"`
+	CoordinatesSource string          `json:"origem_coordenadas,omitempty"` // Fonte das coordenadas: a API do resultado ou o geocodificador
+	Elapsed           time.Duration   `json:"-"`                            // Tempo de resposta da API, da requisição ao fim do parse
+	StartedAt         time.Time       `json:"-"`                            // Início da busca na corrida, incluindo novas tentativas (zero fora dela)
+	FinishedAt        time.Time       `json:"-"`                            // Fim da busca na corrida (zero fora dela)
+	Cached            bool            `json:"cache,omitempty"`              // Resultado obtido do cache, sem consultar as APIs
+	Validators        Validators      `json:"-"`                            // Validadores HTTP da resposta, com Client.Revalidate
+	Confidence        *Confidence     `json:"confianca,omitempty"`          // Concordância das demais APIs com o resultado, quando calculada (ver Race.Confidence)
+}
+
+// Indica se o resultado tem coordenadas
+func (r *Result) HasCoordinates() bool {
+	return r.Latitude != 0 || r.Longitude != 0
+}
+
+// Tempo de resposta da API em milissegundos, com precisão de microssegundos
+func (r *Result) LatencyMS() float64 {
+	return float64(r.Elapsed.Microseconds()) / 1000
+}
+
+// Indica se o resultado é incompleto: sem logradouro e sem bairro
+func (r *Result) isThin() bool {
+	return strings.TrimSpace(r.Logradouro) == "" && strings.TrimSpace(r.Bairro) == ""
+}
+
+// Compõe o endereço em uma única linha, ignorando as partes vazias
+// (ex: "Praça da Sé, Sé, São Paulo - SP, 01001-000")
+func (r *Result) FormatAddress() string {
+	return joinNonEmpty(", ", r.Logradouro, r.Bairro, joinNonEmpty(" - ", r.Cidade, r.Estado), r.CEP)
+}
+
+// Junta os valores não vazios com o separador informado
+func joinNonEmpty(sep string, values ...string) string {
+	var parts []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+// Remove hífens, pontos e espaços do CEP (ex: "01.001-000") e valida que
+// restaram exatamente 8 dígitos
+func Normalize(cep string) (string, error) {
+	cleaned := strings.NewReplacer("-", "", ".", "", " ", "").Replace(strings.TrimSpace(cep))
+	if len(cleaned) != 8 {
+		return "", fmt.Errorf("CEP inválido: deve conter 8 dígitos (recebido %q)", cep)
+	}
+	for _, c := range cleaned {
+		if c < '0' || c > '9' {
+			return "", fmt.Errorf("CEP inválido: deve conter 8 dígitos (recebido %q)", cep)
+		}
+	}
+	return cleaned, nil
+}
+
+// Formata o CEP como NNNNN-NNN, aceitando-o com ou sem hífen e pontos.
+// Valores que não tenham 8 dígitos são mantidos como recebidos.
+func Format(cep string) string {
+	digits, err := Normalize(cep)
+	if err != nil {
+		return cep
+	}
+	return digits[:5] + "-" + digits[5:]
+}
+
+// Mascara os últimos dígitos do CEP, mantendo o prefixo para depuração
+// (ex: "01001000" ou "01001-000" -> "01001-***")
+func Mask(cep string) string {
+	digits := strings.ReplaceAll(cep, "-", "")
+	if len(digits) != 8 {
+		return "*****-***"
+	}
+	return digits[:5] + "-***"
+}
+
+// Erro retornado quando nenhuma API retorna o CEP, com a falha de cada uma.
+// Satisfaz errors.Is(err, ErrNotFound) apenas quando todas as APIs
+// informaram que o CEP não existe; do contrário, a falha é tratada como
+// temporária e satisfaz ErrTimeout ou ErrAllProvidersFailed.
+type LookupError struct {
+	Timeout bool    // O tempo limite foi atingido antes de todas as APIs responderem
+	Errs    []error // Erro de cada API que respondeu
+}
+
+// Indica se todas as APIs concordam que o CEP não existe
+func (e *LookupError) NotFound() bool {
+	return !e.Timeout && len(e.Errs) > 0 && allNotFound(e.Errs)
+}
+
+func (e *LookupError) Error() string {
+	var b strings.Builder
+	switch {
+	case e.Timeout:
+		b.WriteString("Timeout: Nenhuma API respondeu a tempo")
+	case e.NotFound():
+		b.WriteString("Falha: CEP não encontrado em nenhuma API")
+	default:
+		b.WriteString("Falha: nenhuma API retornou o CEP")
+	}
+	for _, err := range e.Errs {
+		b.WriteString("\n  - ")
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// Expõe ErrTimeout ou ErrAllProvidersFailed e os erros das APIs para
+// errors.Is/errors.As. Se alguma API falhou por outro motivo, os "não
+// encontrado" das demais são omitidos.
+func (e *LookupError) Unwrap() []error {
+	if e.NotFound() {
+		return e.Errs
+	}
+	errs := []error{ErrAllProvidersFailed}
+	if e.Timeout {
+		errs[0] = ErrTimeout
+	}
+	for _, err := range e.Errs {
+		if !errors.Is(err, ErrCEPNotFound) {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Indica se todos os erros informam que o CEP não foi encontrado
+func allNotFound(errs []error) bool {
+	for _, err := range errs {
+		if !errors.Is(err, ErrCEPNotFound) {
+			return false
+		}
+	}
+	return true
+}
+
+// Campos principais comparados entre as APIs, na ordem em que são exibidos
+var comparedFields = []string{"cep", "logradouro", "bairro", "cidade", "estado"}
+
+// Valores dos campos principais do resultado, na ordem de comparedFields
+func (r *Result) comparedValues() []string {
+	return []string{strings.ReplaceAll(r.CEP, "-", ""), r.Logradouro, r.Bairro, r.Cidade, r.Estado}
+}
+
+// Indica se dois valores de um campo principal são equivalentes
+func sameValue(a, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
+
+// Compara os campos principais de dois resultados, ignorando diferenças de
+// caixa, espaços e formatação do CEP. Retorna a descrição de cada divergência.
+func Diff(a, b *Result) []string {
+	va, vb := a.comparedValues(), b.comparedValues()
+
+	var diffs []string
+	for i, name := range comparedFields {
+		if !sameValue(va[i], vb[i]) {
+			diffs = append(diffs, fmt.Sprintf("%s: %q x %q", name, va[i], vb[i]))
+		}
+	}
+	return diffs
+}
+
+// Divergência de um campo principal entre as APIs
+type FieldDivergence struct {
+	Field  string       `json:"campo"`
+	Values []FieldValue `json:"valores"` // Valor de cada API, na ordem dos resultados
+}
+
+// Valor de um campo informado por uma API
+type FieldValue struct {
+	API   string `json:"api"`
+	Value string `json:"valor"`
+}
+
+// Compara os campos principais de todos os resultados entre si, com as
+// mesmas regras de Diff, e retorna os campos em que alguma API diverge das
+// demais
+func Compare(results []*Result) []FieldDivergence {
+	values := make([][]string, len(results))
+	for i, r := range results {
+		values[i] = r.comparedValues()
+	}
+
+	var divergences []FieldDivergence
+	for f, name := range comparedFields {
+		divergent := false
+		for i := 1; i < len(results); i++ {
+			if !sameValue(values[0][f], values[i][f]) {
+				divergent = true
+				break
+			}
+		}
+		if !divergent {
+			continue
+		}
+
+		d := FieldDivergence{Field: name}
+		for i, r := range results {
+			d.Values = append(d.Values, FieldValue{API: r.API, Value: values[i][f]})
+		}
+		divergences = append(divergences, d)
+	}
+	return divergences
+}
+
+// Texto que aceita no JSON tanto string quanto número, normalizando para
+// string. Protege os campos numéricos (IBGE, DDD...) de mudanças no formato.
+type flexString string
+
+func (f *flexString) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*f = ""
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*f = flexString(s)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("esperado texto ou número, recebido %s", data)
+	}
+	*f = flexString(n.String())
+	return nil
+}
+
+// Booleano que aceita no JSON tanto true quanto "true". O ViaCEP já
+// retornou o campo "erro" nos dois formatos.
+type flexBool bool
+
+func (f *flexBool) UnmarshalJSON(data []byte) error {
+	var b bool
+	if err := json.Unmarshal(data, &b); err == nil {
+		*f = flexBool(b)
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("esperado booleano, recebido %s", data)
+	}
+	*f = s == "true"
+	return nil
+}
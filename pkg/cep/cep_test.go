@@ -0,0 +1,143 @@
+package cep
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFormatAddress(t *testing.T) {
+	tests := []struct {
+		name   string
+		result Result
+		want   string
+	}{
+		{
+			name:   "completo",
+			result: Result{Logradouro: "Praça da Sé", Bairro: "Sé", Cidade: "São Paulo", Estado: "SP", CEP: "01001-000"},
+			want:   "Praça da Sé, Sé, São Paulo - SP, 01001-000",
+		},
+		{
+			name:   "sem logradouro e bairro",
+			result: Result{Cidade: "São Paulo", Estado: "SP", CEP: "01001-000"},
+			want:   "São Paulo - SP, 01001-000",
+		},
+		{
+			name:   "sem cidade",
+			result: Result{Logradouro: "Praça da Sé", Estado: "SP", CEP: "01001-000"},
+			want:   "Praça da Sé, SP, 01001-000",
+		},
+		{
+			name:   "com espaços nas partes vazias",
+			result: Result{Logradouro: " ", Bairro: "Sé", Cidade: "São Paulo", Estado: "SP"},
+			want:   "Sé, São Paulo - SP",
+		},
+		{
+			name:   "vazio",
+			result: Result{},
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.result.FormatAddress(); got != tt.want {
+				t.Errorf("FormatAddress() = %q, esperado %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		invalid bool
+	}{
+		{in: "01001000", want: "01001000"},
+		{in: "01001-000", want: "01001000"},
+		{in: " 01001000 ", want: "01001000"},
+		{in: "01.001-000", want: "01001000"},
+		{in: "01001 000", want: "01001000"},
+		{in: "1234-567", invalid: true},
+		{in: "010010000", invalid: true},
+		{in: "abc", invalid: true},
+		{in: "0100100a", invalid: true},
+		{in: "01001-00O", invalid: true},
+		{in: "", invalid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Normalize(tt.in)
+			if tt.invalid {
+				if err == nil || !strings.Contains(err.Error(), "CEP inválido: deve conter 8 dígitos") {
+					t.Errorf("Normalize(%q) = %q, %v; esperado CEP inválido", tt.in, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Normalize(%q) = %q, %v; esperado %q", tt.in, got, err, tt.want)
+			}
+		})
+	}
+}
+
+// O CEP inválido é recusado antes de qualquer requisição às APIs
+func TestLookupInvalidCEPSkipsRequests(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer srv.Close()
+	c := newViaCEPClient(t, srv)
+
+	for _, in := range []string{"1234-567", "abc", "0100100a"} {
+		if _, err := c.Lookup(context.Background(), in); err == nil {
+			t.Errorf("Lookup(%q): esperado erro", in)
+		}
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("%d requisições às APIs com CEPs inválidos, esperada nenhuma", got)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	viaCEP := &Result{API: "ViaCEP", CEP: "13333-140", Logradouro: "Rua Um", Bairro: "Centro", Cidade: "Indaiatuba", Estado: "SP"}
+	tests := []struct {
+		name   string
+		other  Result
+		fields []string
+	}{
+		{
+			name:  "iguais",
+			other: Result{API: "Brasil API", CEP: "13333-140", Logradouro: "Rua Um", Bairro: "Centro", Cidade: "Indaiatuba", Estado: "SP"},
+		},
+		{
+			name:  "caixa, espaços e hífen do CEP",
+			other: Result{API: "Brasil API", CEP: "13333140", Logradouro: "rua um ", Bairro: "CENTRO", Cidade: "Indaiatuba", Estado: "sp"},
+		},
+		{
+			name:   "CEP e bairro divergentes",
+			other:  Result{API: "Brasil API", CEP: "13335-320", Logradouro: "Rua Um", Bairro: "Jardim Regina", Cidade: "Indaiatuba", Estado: "SP"},
+			fields: []string{"cep", "bairro"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			divergences := Compare([]*Result{viaCEP, &tt.other})
+			if len(divergences) != len(tt.fields) {
+				t.Fatalf("divergências = %+v, esperadas em %v", divergences, tt.fields)
+			}
+			for i, d := range divergences {
+				if d.Field != tt.fields[i] || len(d.Values) != 2 || d.Values[0].API != "ViaCEP" || d.Values[1].API != "Brasil API" {
+					t.Errorf("divergência %d = %+v, esperada no campo %s com o valor de cada API", i, d, tt.fields[i])
+				}
+			}
+			if diffs := Diff(viaCEP, &tt.other); len(diffs) != len(tt.fields) {
+				t.Errorf("Diff = %v, esperadas %d diferenças", diffs, len(tt.fields))
+			}
+		})
+	}
+}
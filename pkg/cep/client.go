@@ -0,0 +1,267 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// User-Agent padrão das requisições às APIs
+const DefaultUserAgent = "fc-desafio-2/1.0"
+
+// Client de consulta de CEP. O valor zero é utilizável: consulta as APIs
+// registradas com o transport padrão, sem timeout próprio nem novas tentativas.
+type Client struct {
+	HTTPClient  *http.Client      // Client usado nas requisições às APIs, nil usa um client compartilhado
+	URLs        map[string]string // URL de cada API por identificador (ex: "viacep"), %s é substituído pelo CEP; ausentes usam a padrão
+	Timeout     time.Duration     // Tempo máximo da corrida, 0 usa apenas o prazo do contexto
+	UserAgent   string            // User-Agent das requisições, vazio usa DefaultUserAgent
+	Retries     int               // Novas tentativas por API em falhas temporárias (rede e 5xx)
+	HTTPVersion string            // Protocolo exigido nas respostas (ex: "HTTP/2.0"), vazio desativa a checagem
+
+	ProviderRetries map[string]int // Novas tentativas por nome da API (ex: "ViaCEP"), substituindo Retries
+	RetryBackoff    time.Duration  // Espera antes da primeira nova tentativa, dobrada a cada uma, 0 usa 100ms
+
+	ProviderTimeouts map[string]time.Duration // Tempo máximo por nome da API (ex: "ViaCEP"), incluindo as novas tentativas, dentro de Timeout
+	VerifyTimeout    time.Duration            // Prazo adicional, após Timeout, das APIs ainda sem resposta quando o resultado é escolhido (Race.Remaining e Race.Authoritative), 0 encerra todas no Timeout
+
+	BreakerThreshold int           // Falhas consecutivas que abrem o circuito de uma API, 0 desativa
+	BreakerCooldown  time.Duration // Tempo com o circuito aberto antes da consulta de teste, 0 usa 30s
+
+	SlowThreshold time.Duration // Retira da corrida a API cuja latência média (ver ProviderLatencies) supera esse tempo, 0 desativa
+	SlowCooldown  time.Duration // Tempo fora da corrida de uma API lenta, 0 usa 1min
+	LatencyAlpha  float64       // Peso de cada medição na latência média, de 0 a 1 (maior reage mais rápido), 0 usa 0.2
+
+	RateLimits map[string]RateLimit // Limite de requisições por nome da API (ex: "ViaCEP"), ausentes não são limitadas
+
+	ProviderRequests map[string]RequestOptions // Cabeçalhos, parâmetros de query e token incluídos nas requisições, por nome da API (ex: "ViaCEP")
+
+	Strategy   Strategy      // Estratégia de disparo das APIs, o valor zero é a corrida (StrategyRace)
+	Quorum     int           // APIs que precisam concordar em StrategyQuorum, 0 usa 2
+	HedgeDelay time.Duration // Na corrida, dispara as APIs escalonadas, na ordem de Providers: a seguinte só após esse intervalo sem resultado (ou na falha da anterior); 0 dispara todas juntas
+
+	Providers     []Provider // APIs da corrida, nil usa todas as registradas (ver RegisterProvider)
+	Authoritative Provider   // API cujo resultado é entregue à parte em Race.Authoritative, nil desativa
+
+	Selector             Selector      // Política de escolha do resultado, nil usa a mais rápida
+	RetryOnEmptyFields   bool          // Prefere o resultado de outra API quando o primeiro vier sem logradouro e bairro
+	PreferComplete       time.Duration // Janela extra para aguardar um resultado mais completo, 0 desativa
+	MunicipalityFallback bool          // Retorna cidade/estado pelo prefixo quando nenhuma API encontra o CEP
+	OfflineFallback      bool          // Retorna cidade/estado da base offline (Origem "offline") quando nenhuma API responde, por falha de rede, 5xx ou timeout
+	OfflineDB            *OfflineDB    // Base do OfflineFallback (ver LoadOfflineDB), nil usa a embutida, com as capitais e as faixas de cada estado
+	ValidateState        bool          // Descarta, como falha da API (ErrStateMismatch), os resultados com o estado inconsistente com a faixa do CEP (ver StateOf)
+
+	GeoDB    *GeoDB       // Base de áreas de entrega que complementa o resultado, nil desativa
+	TimeZone bool         // Complementa o resultado com o fuso horário do estado
+	Cache    CacheBackend // Cache dos resultados por CEP (*Cache em memória ou *RedisCache), nil desativa
+
+	Revalidate bool // Guarda no cache os validadores das respostas (ETag e Last-Modified) e, após o TTL, pergunta à API se o resultado mudou, com um Cache que implemente RevalidatingCache
+
+	Geo      bool     // Complementa o resultado com latitude e longitude: a Brasil API passa a consultar a v2, e os demais resultados usam o Geocoder
+	Geocoder Geocoder // Geocodificador quando a API vencedora não informa coordenadas, nil usa o Nominatim
+
+	IBGE    bool   // Complementa o resultado com região, mesorregião e população do município na API do IBGE
+	IBGEURL string // URL base da API do IBGE, vazio usa a oficial (ver IBGEURL)
+
+	DDDURL string // URL da consulta de DDD em LookupDDD (%s é substituído pelo DDD), vazio usa a da Brasil API (ver DDDURL)
+
+	Logger    Logger        // Registra cada requisição (debug) e o desfecho de cada API na corrida (ex: NewSlogLogger), nil desativa
+	MaskCEP   bool          // Mascara os últimos dígitos do CEP no Logger, em OnOutcome e nos erros das requisições às APIs
+	OnOutcome func(Outcome) // Recebe o desfecho de cada API na corrida (ex: métricas), chamada concorrentemente; nil desativa
+	Tracer    Tracer        // Rastreamento de cada consulta e das requisições às APIs (ex: OpenTelemetry), nil desativa
+
+	flights  flightGroup  // Consultas de Lookup em andamento, por CEP
+	breakers breakerGroup // Circuit breakers das APIs, por nome
+	limiters limiterGroup // Limites de requisições das APIs, por nome
+
+	latencies latencyGroup // Latência média das APIs com SlowThreshold, por nome
+
+	municipalities municipalityCache // Municípios já consultados no IBGE
+	ddds           dddCache          // DDDs já consultados em LookupDDD
+}
+
+// Cria um Client que consulta as APIs reais com o timeout padrão de 1 segundo,
+// 2 novas tentativas e circuit breaker após 5 falhas consecutivas. O client
+// HTTP é compartilhado entre as consultas, reaproveitando conexões.
+func NewClient() *Client {
+	return &Client{
+		HTTPClient: &http.Client{Transport: NewHTTPTransport(), Timeout: 1500 * time.Millisecond},
+		URLs:       DefaultURLs(),
+		Timeout:    1 * time.Second,
+		UserAgent:  DefaultUserAgent,
+		Retries:    2,
+
+		BreakerThreshold: 5,
+	}
+}
+
+// Busca o CEP com um Client criado por NewClient, que consulta as APIs reais
+func Lookup(ctx context.Context, cep string) (*Result, error) {
+	return NewClient().Lookup(ctx, cep)
+}
+
+// Busca o CEP disparando a consulta em todas as APIs simultaneamente e
+// retorna o resultado escolhido (por padrão, o da mais rápida). As
+// requisições perdedoras são canceladas. Chamadas simultâneas para o mesmo
+// CEP compartilham uma única corrida, limitada pelo Timeout do Client e
+// cancelada apenas quando todas as chamadas desistem: o cancelamento ou o
+// prazo do contexto de uma chamada não afeta as demais. Quando nenhuma API
+// retorna o CEP, o erro é um *LookupError; o prazo do contexto esgotado
+// também é um *LookupError (ErrTimeout), e o contexto cancelado retorna
+// context.Canceled.
+func (c *Client) Lookup(ctx context.Context, cep string) (*Result, error) {
+	normalized, err := Normalize(cep)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.flights.do(ctx, normalized, func(ctx context.Context) (*Result, error) {
+		r, err := c.Race(ctx, normalized)
+		if err != nil {
+			return nil, err
+		}
+		r.Close()
+		return r.Result, nil
+	})
+	if err != nil && err == ctx.Err() && errors.Is(err, context.DeadlineExceeded) {
+		return nil, &LookupError{Timeout: true}
+	}
+	return result, err
+}
+
+// Parâmetros do pool de conexões reutilizadas entre as consultas
+const (
+	maxIdleConns        = 100 // Conexões ociosas no total, somando todas as APIs
+	maxIdleConnsPerHost = 16
+	idleConnTimeout     = 90 * time.Second
+	keepAliveInterval   = 30 * time.Second
+
+	// Limites para estabelecer uma conexão nova, bem abaixo dos padrões do
+	// net/http (30s e 10s): uma API que não aceita conexões falha cedo em vez
+	// de depender só do timeout da consulta
+	dialTimeout         = 5 * time.Second
+	tlsHandshakeTimeout = 5 * time.Second
+)
+
+// Cria o transport HTTP das APIs, com pool de conexões e keep-alive para
+// reaproveitar conexões (e handshakes TLS) entre as consultas, e timeouts
+// de conexão e handshake ajustados
+func NewHTTPTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: dialTimeout, KeepAlive: keepAliveInterval}).DialContext
+	transport.TLSHandshakeTimeout = tlsHandshakeTimeout
+	transport.MaxIdleConns = maxIdleConns
+	transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	transport.IdleConnTimeout = idleConnTimeout
+	return transport
+}
+
+// Client HTTP usado quando Client.HTTPClient não é informado
+var defaultHTTPClient = &http.Client{Transport: NewHTTPTransport()}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return defaultHTTPClient
+}
+
+// URL configurada para a API, ou a padrão do registro
+func (c *Client) url(id string) string {
+	if u, ok := c.URLs[id]; ok {
+		return u
+	}
+	return DefaultURLs()[id]
+}
+
+// Executa a requisição GET a uma API com o contexto da consulta, os
+// cabeçalhos comuns a todas as APIs e as opções de ProviderRequests,
+// checando o protocolo negociado quando exigido. Retorna também o início da requisição, para medir o tempo de
+// resposta até o fim do parse. Os erros são identificados pelo nome da API.
+func (c *Client) get(ctx context.Context, client *http.Client, api, url string) (*http.Response, time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("%s: erro na requisição: %v", api, err)
+	}
+	userAgent := c.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+	c.applyRequestOptions(req, api)
+	rv := revalidationFrom(ctx)
+	rv.prepare(req)
+
+	// Um span por tentativa, filho do span da consulta
+	_, span := c.startSpan(ctx, "GET "+api, SpanClient,
+		slog.String("cep.api", api), slog.String("http.request.method", "GET"), slog.String("url.full", c.maskText(ctx, url)))
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	elapsed := time.Since(start)
+	span.SetAttributes(slog.Float64("cep.duration_ms", float64(elapsed.Microseconds())/1000))
+	if err != nil {
+		err = redactURLError(err, c.maskText(ctx, url))
+		c.logAttempt(ctx, api, url, 0, elapsed, err)
+		span.End(errors.New(c.maskText(ctx, err.Error())))
+		return nil, start, fmt.Errorf("%s: erro HTTP: %w", api, err)
+	}
+	traceStatus(ctx, resp.StatusCode)
+	c.logAttempt(ctx, api, url, resp.StatusCode, elapsed, nil)
+	span.SetAttributes(slog.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.End(&httpStatusError{code: resp.StatusCode})
+	} else {
+		span.End(nil)
+	}
+
+	// Checa o protocolo negociado, quando exigido
+	if c.HTTPVersion != "" && resp.Proto != c.HTTPVersion {
+		resp.Body.Close()
+		return nil, start, fmt.Errorf("%s: %w: esperado %s, recebido %s", api, ErrHTTPVersionMismatch, c.HTTPVersion, resp.Proto)
+	}
+
+	// O 304 da requisição condicional confirma o resultado armazenado
+	if rv.observe(resp) {
+		resp.Body.Close()
+		return nil, start, fmt.Errorf("%s: %w", api, errNotModified)
+	}
+	return resp, start, nil
+}
+
+// Complementa o resultado com os dados opcionais configurados
+func (c *Client) enrich(result *Result, cep string) {
+	if c.GeoDB != nil {
+		if geometry, ok := c.GeoDB.lookup(cep); ok {
+			result.Geometry = geometry
+		}
+	}
+	if c.TimeZone {
+		if tz, ok := ufTimeZones[strings.ToUpper(result.Estado)]; ok {
+			result.TimeZone = tz.zone
+		}
+	}
+}
+
+// Cria a política de seleção do resultado conforme as opções
+func (c *Client) selector() Selector {
+	switch {
+	case c.Selector != nil:
+		return c.Selector
+	case c.Strategy == StrategyQuorum:
+		quorum := c.Quorum
+		if quorum <= 0 {
+			quorum = defaultQuorum
+		}
+		return &quorumSelector{quorum: quorum}
+	case c.PreferComplete > 0:
+		return &completeSelector{window: c.PreferComplete}
+	default:
+		return &fastestSelector{retryOnEmptyFields: c.RetryOnEmptyFields}
+	}
+}
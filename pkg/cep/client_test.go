@@ -0,0 +1,317 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Client que consulta apenas o ViaCEP, na URL do stub
+func newViaCEPClient(t *testing.T, srv *httptest.Server) *Client {
+	t.Helper()
+	c := &Client{URLs: map[string]string{"viacep": srv.URL + "/%s"}, Timeout: time.Second}
+	p, err := NewProvider("viacep", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Providers = []Provider{p}
+	return c
+}
+
+func TestHTTPVersion(t *testing.T) {
+	http1 := newJSONStub(t, 0, http.StatusOK, viaCEPPracaDaSe)
+
+	http2 := httptest.NewUnstartedServer(http1.Config.Handler)
+	http2.EnableHTTP2 = true
+	http2.StartTLS()
+	defer http2.Close()
+
+	tests := []struct {
+		name     string
+		srv      *httptest.Server
+		version  string
+		mismatch bool
+	}{
+		{"sem checagem", http1, "", false},
+		{"HTTP/1.1 exigido", http1, "HTTP/1.1", false},
+		{"HTTP/2 exigido, servidor só HTTP/1.1", http1, "HTTP/2.0", true},
+		{"HTTP/2 exigido e negociado", http2, "HTTP/2.0", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newViaCEPClient(t, tt.srv)
+			c.HTTPClient = tt.srv.Client()
+			c.HTTPVersion = tt.version
+
+			result, err := c.Lookup(context.Background(), "01001000")
+			if tt.mismatch {
+				if !errors.Is(err, ErrHTTPVersionMismatch) {
+					t.Fatalf("erro = %v, esperado ErrHTTPVersionMismatch", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if result.Logradouro != "Praça da Sé" {
+				t.Errorf("logradouro = %q", result.Logradouro)
+			}
+		})
+	}
+}
+
+// O timeout do client HTTP encerra a requisição mesmo sem prazo no contexto,
+// com um servidor que ignora o cancelamento e nunca responde
+func TestTransformBody(t *testing.T) {
+	// Espelho quase compatível com o ViaCEP: cep e localidade com outros nomes
+	srv := newJSONStub(t, 0, http.StatusOK, map[string]string{
+		"zip": "01001-000", "logradouro": "Praça da Sé", "cidade": "São Paulo", "uf": "SP",
+	})
+	renameKeys := func(body []byte) ([]byte, error) {
+		var fields map[string]any
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return nil, err
+		}
+		for from, to := range map[string]string{"zip": "cep", "cidade": "localidade"} {
+			if v, ok := fields[from]; ok {
+				fields[to] = v
+				delete(fields, from)
+			}
+		}
+		return json.Marshal(fields)
+	}
+
+	tests := []struct {
+		name      string
+		transform func([]byte) ([]byte, error)
+		wantErr   error
+	}{
+		{"renomeia os campos", renameKeys, nil},
+		{"sem transformação", nil, ErrCEPNotFound},
+		{"erro na transformação", func([]byte) ([]byte, error) { return nil, errors.New("formato desconhecido") }, ErrInvalidResponse},
+		{"pânico na transformação", func([]byte) ([]byte, error) { panic("mapa nil") }, ErrInvalidResponse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newViaCEPClient(t, srv)
+			c.ProviderRequests = map[string]RequestOptions{"ViaCEP": {Transform: tt.transform}}
+
+			result, err := c.Lookup(context.Background(), "01001000")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("erro = %v, esperado %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if result.CEP != "01001-000" || result.Cidade != "São Paulo" || result.Logradouro != "Praça da Sé" {
+				t.Errorf("resultado = %+v, esperado os campos renomeados", result)
+			}
+		})
+	}
+}
+
+func TestHTTPClientTimeoutBackstop(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	tests := []struct {
+		name          string
+		clientTimeout time.Duration
+		timeout       time.Duration
+		wantErr       error
+	}{
+		{"sem prazo no contexto", 50 * time.Millisecond, 0, ErrAllProvidersFailed},
+		{"contexto com prazo maior", 50 * time.Millisecond, time.Hour, ErrAllProvidersFailed},
+		{"contexto com prazo menor", time.Hour, 50 * time.Millisecond, ErrTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newViaCEPClient(t, srv)
+			c.HTTPClient = &http.Client{Timeout: tt.clientTimeout}
+			c.Timeout = tt.timeout
+
+			start := time.Now()
+			_, err := c.Lookup(context.Background(), "01001000")
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("consulta levou %s, esperado o fim no menor dos timeouts", elapsed)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("erro = %v, esperado %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	notFound := map[string]bool{"erro": true}
+	tests := []struct {
+		name       string
+		stubs      func(t *testing.T) []*httptest.Server
+		winner     string
+		wantErr    error
+		notWantErr error
+		errs       int // Erros das APIs em *LookupError
+	}{
+		{
+			name: "uma API responde, a outra falha",
+			stubs: func(t *testing.T) []*httptest.Server {
+				return []*httptest.Server{
+					newJSONStub(t, 0, http.StatusInternalServerError, nil),
+					newJSONStub(t, 10*time.Millisecond, http.StatusOK, viaCEPPracaDaSe),
+				}
+			},
+			winner: "B",
+		},
+		{
+			name: "nenhuma API encontra o CEP",
+			stubs: func(t *testing.T) []*httptest.Server {
+				return []*httptest.Server{
+					newJSONStub(t, 0, http.StatusOK, notFound),
+					newJSONStub(t, 0, http.StatusOK, notFound),
+				}
+			},
+			wantErr:    ErrNotFound,
+			notWantErr: ErrAllProvidersFailed,
+			errs:       2,
+		},
+		{
+			name: "não encontrado e falha",
+			stubs: func(t *testing.T) []*httptest.Server {
+				return []*httptest.Server{
+					newJSONStub(t, 0, http.StatusOK, notFound),
+					newJSONStub(t, 0, http.StatusBadGateway, nil),
+				}
+			},
+			wantErr:    ErrAllProvidersFailed,
+			notWantErr: ErrNotFound,
+			errs:       2,
+		},
+		{
+			name: "nenhuma API responde a tempo",
+			stubs: func(t *testing.T) []*httptest.Server {
+				return []*httptest.Server{
+					newJSONStub(t, time.Second, http.StatusOK, viaCEPPracaDaSe),
+					newJSONStub(t, time.Second, http.StatusOK, viaCEPPracaDaSe),
+				}
+			},
+			wantErr:    ErrTimeout,
+			notWantErr: ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{Providers: stubProviders([]string{"A", "B"}, tt.stubs(t)...), Timeout: 100 * time.Millisecond}
+
+			result, err := c.Lookup(context.Background(), "01001-000")
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("erro inesperado: %v", err)
+				}
+				if result.API != tt.winner || result.CEP != "01001-000" {
+					t.Errorf("resultado = %s de %s, esperado 01001-000 de %s", result.CEP, result.API, tt.winner)
+				}
+				return
+			}
+
+			if !errors.Is(err, tt.wantErr) || errors.Is(err, tt.notWantErr) {
+				t.Fatalf("erro = %v, esperado %v e não %v", err, tt.wantErr, tt.notWantErr)
+			}
+			var lookupErr *LookupError
+			if !errors.As(err, &lookupErr) {
+				t.Fatalf("erro %T, esperado *LookupError", err)
+			}
+			if len(lookupErr.Errs) != tt.errs {
+				t.Errorf("%d erros das APIs, esperados %d: %v", len(lookupErr.Errs), tt.errs, lookupErr.Errs)
+			}
+		})
+	}
+}
+
+// As buscas de cada API retornam o resultado diretamente, sem a corrida
+func TestFetchBrasilAPI(t *testing.T) {
+	srv := newJSONStub(t, 0, http.StatusOK, BrasilAPIResponse{CEP: "01001000", State: "SP", City: "São Paulo", Neighborhood: "Sé", Street: "Praça da Sé"})
+	c := &Client{URLs: map[string]string{"brasilapi": srv.URL + "/%s"}}
+
+	result, err := c.fetchBrasilAPI(context.Background(), "01001000")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	want := Result{API: "Brasil API", CEP: "01001-000", Logradouro: "Praça da Sé", Bairro: "Sé", Cidade: "São Paulo", Estado: "SP"}
+	if result.API != want.API || result.CEP != want.CEP || result.FormatAddress() != want.FormatAddress() {
+		t.Errorf("resultado = %+v, esperado %+v", result, want)
+	}
+}
+
+// Transport que conta as requisições antes de repassá-las
+type countingTransport struct {
+	requests atomic.Int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// Com o client HTTP e as URLs injetados, as APIs registradas consultam os
+// stubs, e vence a que responder primeiro
+func TestInjectedClientAndURLs(t *testing.T) {
+	tests := []struct {
+		name              string
+		viaCEP, brasilAPI time.Duration // Atraso de cada stub
+		winner            string
+	}{
+		{"ViaCEP mais rápido", 0, 50 * time.Millisecond, "ViaCEP"},
+		{"Brasil API mais rápida", 50 * time.Millisecond, 0, "Brasil API"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failing := newJSONStub(t, 0, http.StatusInternalServerError, nil)
+			urls := make(map[string]string)
+			for id := range DefaultURLs() {
+				urls[id] = failing.URL + "/" + id + "/%s"
+			}
+			urls["viacep"] = newJSONStub(t, tt.viaCEP, http.StatusOK, viaCEPPracaDaSe).URL + "/%s"
+			brasilAPI := BrasilAPIResponse{CEP: "01001000", State: "SP", City: "São Paulo", Neighborhood: "Sé", Street: "Praça da Sé"}
+			urls["brasilapi"] = newJSONStub(t, tt.brasilAPI, http.StatusOK, brasilAPI).URL + "/%s"
+
+			transport := &countingTransport{}
+			c := &Client{HTTPClient: &http.Client{Transport: transport}, URLs: urls, Timeout: time.Second}
+			result, err := c.Lookup(context.Background(), "01001000")
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if result.API != tt.winner {
+				t.Errorf("vencedora = %s, esperada %s", result.API, tt.winner)
+			}
+			if transport.requests.Load() == 0 {
+				t.Error("o client HTTP injetado não foi usado")
+			}
+		})
+	}
+}
+
+// O client padrão consulta os endpoints reais, com HTTPS
+func TestNewClientDefaults(t *testing.T) {
+	c := NewClient()
+	if c.HTTPClient == nil || c.Timeout != time.Second {
+		t.Errorf("client HTTP = %v, timeout = %s; esperados o client compartilhado e 1s", c.HTTPClient, c.Timeout)
+	}
+	for _, p := range RegisteredProviders() {
+		if u := c.URLs[p.ID]; u != p.URL || !strings.HasPrefix(u, "https://") {
+			t.Errorf("URL de %s = %q, esperada a padrão %q", p.ID, u, p.URL)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package cep
+
+// Níveis de Confidence.Level
+const (
+	ConfidenceHigh   = "alta"
+	ConfidenceMedium = "media"
+	ConfidenceLow    = "baixa"
+)
+
+// Confiança no resultado escolhido, pela concordância das APIs que
+// retornaram o CEP nos campos principais (CEP, logradouro, bairro, cidade e
+// estado, com as regras de Diff):
+//   - alta: ao menos duas APIs responderam e todas concordam (ex: 2/2, 3/3);
+//   - media: ao menos duas concordam, mas alguma diverge (ex: 2/3);
+//   - baixa: nenhuma outra API confirma o resultado (ex: 1/1, 1/2).
+type Confidence struct {
+	Agreeing  int    `json:"concordantes"` // APIs que concordam com o resultado, inclusive a que o retornou
+	Responded int    `json:"respostas"`    // APIs que retornaram o CEP
+	Level     string `json:"nivel"`        // ConfidenceHigh, ConfidenceMedium ou ConfidenceLow
+}
+
+// Calcula a confiança de result pelos resultados das demais APIs (sem o
+// próprio result)
+func ScoreConfidence(result *Result, others []*Result) *Confidence {
+	c := &Confidence{Agreeing: 1, Responded: 1 + len(others)}
+	for _, other := range others {
+		if len(Diff(result, other)) == 0 {
+			c.Agreeing++
+		}
+	}
+	switch {
+	case c.Agreeing < 2:
+		c.Level = ConfidenceLow
+	case c.Agreeing == c.Responded:
+		c.Level = ConfidenceHigh
+	default:
+		c.Level = ConfidenceMedium
+	}
+	return c
+}
+
+// Aguarda as respostas das demais APIs em Remaining, até o fim do prazo da
+// corrida, e registra a confiança do resultado escolhido em
+// Result.Confidence. As APIs que falharam não contam, assim como as que não
+// chegaram a ser consultadas (StrategyFallback e o disparo escalonado de
+// HedgeDelay consultam as demais apenas em falhas). Retorna nil, sem
+// esperar, para os resultados do cache e do fallback por município, que não
+// vêm da corrida. Consome Remaining.
+func (r *Race) Confidence() *Confidence {
+	if r.Result.Cached || r.Result.SomenteMunicipio {
+		return nil
+	}
+	var others []*Result
+	for other, err := range r.Remaining() {
+		if err == nil {
+			others = append(others, other)
+		}
+	}
+	r.Result.Confidence = ScoreConfidence(r.Result, others)
+	return r.Result.Confidence
+}
@@ -0,0 +1,97 @@
+package cep
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestScoreConfidence(t *testing.T) {
+	sé := &Result{API: "A", CEP: "01001-000", Logradouro: "Praça da Sé", Bairro: "Sé", Cidade: "São Paulo", Estado: "SP"}
+	same := &Result{API: "B", CEP: "01001000", Logradouro: "PRAÇA DA SÉ", Bairro: "Sé", Cidade: "São Paulo", Estado: "SP"}
+	other := &Result{API: "C", CEP: "01001-000", Logradouro: "Rua Direita", Bairro: "Sé", Cidade: "São Paulo", Estado: "SP"}
+
+	tests := []struct {
+		name      string
+		others    []*Result
+		agreeing  int
+		responded int
+		level     string
+	}{
+		{"apenas uma API", nil, 1, 1, ConfidenceLow},
+		{"2 de 2 concordam", []*Result{same}, 2, 2, ConfidenceHigh},
+		{"1 de 2 concorda", []*Result{other}, 1, 2, ConfidenceLow},
+		{"2 de 3 concordam", []*Result{same, other}, 2, 3, ConfidenceMedium},
+		{"3 de 3 concordam", []*Result{same, same}, 3, 3, ConfidenceHigh},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ScoreConfidence(sé, tt.others)
+			if got.Agreeing != tt.agreeing || got.Responded != tt.responded || got.Level != tt.level {
+				t.Errorf("ScoreConfidence = %+v, esperado %d/%d %s", got, tt.agreeing, tt.responded, tt.level)
+			}
+		})
+	}
+}
+
+func TestRaceConfidence(t *testing.T) {
+	divergent := viaCEPPracaDaSe
+	divergent.Logradouro = "Rua Direita"
+
+	tests := []struct {
+		name   string
+		second any // Resposta da API mais lenta
+		level  string
+		agree  int
+	}{
+		{"2 de 2 concordam", viaCEPPracaDaSe, ConfidenceHigh, 2},
+		{"1 de 2 concorda", divergent, ConfidenceLow, 1},
+		{"a outra API falha", nil, ConfidenceLow, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fast := newJSONStub(t, 0, http.StatusOK, viaCEPPracaDaSe)
+			var slow *httptest.Server
+			if tt.second != nil {
+				slow = newJSONStub(t, 30*time.Millisecond, http.StatusOK, tt.second)
+			} else {
+				slow = newJSONStub(t, 30*time.Millisecond, http.StatusInternalServerError, map[string]string{})
+			}
+			c := &Client{Providers: stubProviders([]string{"Fast", "Slow"}, fast, slow), Timeout: 2 * time.Second}
+
+			r, err := c.Race(context.Background(), "01001000")
+			if err != nil {
+				t.Fatalf("Race: %v", err)
+			}
+			defer r.Close()
+			got := r.Confidence()
+			if got == nil || got.Level != tt.level || got.Agreeing != tt.agree || r.Result.Confidence != got {
+				t.Fatalf("Confidence = %+v, esperado %s com %d concordante(s), registrado no resultado", got, tt.level, tt.agree)
+			}
+			wantResponded := 2
+			if tt.second == nil {
+				wantResponded = 1
+			}
+			if got.Responded != wantResponded {
+				t.Errorf("respostas = %d, esperadas %d (as falhas não contam)", got.Responded, wantResponded)
+			}
+		})
+	}
+}
+
+// Resultados do cache não passam pela corrida: sem confiança
+func TestRaceConfidenceCached(t *testing.T) {
+	c := &Client{Providers: stubProviders([]string{"A"}, newJSONStub(t, 0, http.StatusOK, viaCEPPracaDaSe)), Timeout: time.Second, Cache: NewCache(time.Hour, 0)}
+	for range 2 {
+		r, err := c.Race(context.Background(), "01001000")
+		if err != nil {
+			t.Fatalf("Race: %v", err)
+		}
+		defer r.Close()
+		if conf := r.Confidence(); r.Result.Cached && conf != nil {
+			t.Errorf("Confidence do resultado do cache = %+v, esperado nil", conf)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// URL da consulta de DDD da Brasil API (%s é substituído pelo DDD)
+const DDDURL = "https://brasilapi.com.br/api/ddd/v1/%s"
+
+// Erro retornado quando a API informa que o DDD não existe
+var ErrDDDNotFound = errors.New("DDD não encontrado")
+
+// Estado e cidades atendidos por um código de área (DDD)
+type DDDInfo struct {
+	API     string        `json:"api"`
+	DDD     string        `json:"ddd"`
+	Estado  string        `json:"estado"`
+	Cidades []string      `json:"cidades"`         // Em ordem alfabética, como informadas pela API (em maiúsculas)
+	Elapsed time.Duration `json:"-"`               // Tempo de resposta da API, da requisição ao fim do parse
+	Cached  bool          `json:"cache,omitempty"` // Obtido de uma consulta anterior do Client, sem consultar a API
+}
+
+// Tempo de resposta da API em milissegundos, com precisão de microssegundos
+func (d *DDDInfo) LatencyMS() float64 {
+	return float64(d.Elapsed.Microseconds()) / 1000
+}
+
+// Resposta de /ddd/v1 da Brasil API
+type brasilAPIDDDResponse struct {
+	State  string   `json:"state"`
+	Cities []string `json:"cities"`
+}
+
+// DDDs já consultados por um Client
+type dddCache struct {
+	mu      sync.Mutex
+	entries map[string]DDDInfo
+}
+
+func (d *dddCache) get(ddd string) (*DDDInfo, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	info, ok := d.entries[ddd]
+	if !ok {
+		return nil, false
+	}
+	info.Cidades = slices.Clone(info.Cidades)
+	info.Cached = true
+	return &info, true
+}
+
+func (d *dddCache) set(info *DDDInfo) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.entries == nil {
+		d.entries = make(map[string]DDDInfo)
+	}
+	entry := *info
+	entry.Cidades = slices.Clone(info.Cidades)
+	d.entries[info.DDD] = entry
+}
+
+// Remove parênteses, espaços e o zero do prefixo de longa distância do DDD
+// (ex: "(011)") e valida que restaram 2 dígitos, sem zeros
+func NormalizeDDD(ddd string) (string, error) {
+	cleaned := strings.NewReplacer("(", "", ")", "", " ", "").Replace(strings.TrimSpace(ddd))
+	if len(cleaned) == 3 && cleaned[0] == '0' {
+		cleaned = cleaned[1:]
+	}
+	if len(cleaned) != 2 || cleaned[0] < '1' || cleaned[0] > '9' || cleaned[1] < '1' || cleaned[1] > '9' {
+		return "", fmt.Errorf("DDD inválido: deve conter 2 dígitos (recebido %q)", ddd)
+	}
+	return cleaned, nil
+}
+
+// Busca o DDD com um Client criado por NewClient, que consulta a API real
+func LookupDDD(ctx context.Context, ddd string) (*DDDInfo, error) {
+	return NewClient().LookupDDD(ctx, ddd)
+}
+
+// Busca o estado e as cidades de um DDD na Brasil API, com o client HTTP, o
+// Timeout, o limite de requisições (RateLimits) e as opções de requisição
+// (ProviderRequests) da Brasil API. Os DDDs encontrados ficam guardados no
+// Client, e as consultas seguintes do mesmo DDD não acessam a API. Quando o
+// DDD não existe, o erro é ErrDDDNotFound.
+func (c *Client) LookupDDD(ctx context.Context, ddd string) (*DDDInfo, error) {
+	normalized, err := NormalizeDDD(ddd)
+	if err != nil {
+		return nil, err
+	}
+	if info, ok := c.ddds.get(normalized); ok {
+		return info, nil
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	const api = "Brasil API"
+	if limit, ok := c.RateLimits[api]; ok && limit.PerSecond > 0 {
+		if err := c.limiters.get(api, limit).wait(ctx, api, limit.FailFast); err != nil {
+			return nil, err
+		}
+	}
+
+	u := c.DDDURL
+	if u == "" {
+		u = DDDURL
+	}
+	resp, start, err := c.get(ctx, c.httpClient(), api, fmt.Sprintf(u, url.PathEscape(normalized)))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s: %w", api, ErrDDDNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %w", api, &httpStatusError{code: resp.StatusCode})
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: erro na leitura: %v", api, err)
+	}
+	var apiResponse brasilAPIDDDResponse
+	if err := json.Unmarshal(body, &apiResponse); err != nil {
+		return nil, fmt.Errorf("%s: erro no parse: %v", api, err)
+	}
+	if apiResponse.State == "" {
+		return nil, fmt.Errorf("%s: %w", api, ErrDDDNotFound)
+	}
+
+	info := &DDDInfo{
+		API:     api,
+		DDD:     normalized,
+		Estado:  strings.ToUpper(apiResponse.State),
+		Cidades: apiResponse.Cities,
+		Elapsed: time.Since(start),
+	}
+	slices.SortFunc(info.Cidades, func(a, b string) int { return strings.Compare(foldName(a), foldName(b)) })
+	c.ddds.set(info)
+	return info, nil
+}
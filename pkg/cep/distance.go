@@ -0,0 +1,66 @@
+package cep
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// Raio médio da Terra, em quilômetros
+const earthRadiusKM = 6371.0
+
+// Distância em linha reta entre dois CEPs, calculada por Client.Distance
+type CEPDistance struct {
+	From *Result `json:"origem"`
+	To   *Result `json:"destino"`
+	KM   float64 `json:"distancia_km"`
+}
+
+// Calcula a distância entre dois CEPs com um client padrão (ver NewClient)
+// e as coordenadas ativas (Client.Geo)
+func Distance(ctx context.Context, cep1, cep2 string) (*CEPDistance, error) {
+	c := NewClient()
+	c.Geo = true
+	return c.Distance(ctx, cep1, cep2)
+}
+
+// Consulta os dois CEPs simultaneamente e calcula a distância em linha reta
+// (fórmula de haversine), em quilômetros, entre as coordenadas dos
+// resultados. Exige Client.Geo: sem coordenadas para algum dos CEPs, o erro
+// envolve ErrNoCoordinates.
+func (c *Client) Distance(ctx context.Context, cep1, cep2 string) (*CEPDistance, error) {
+	ceps := [2]string{cep1, cep2}
+	var results [2]*Result
+	var errs [2]error
+	var wg sync.WaitGroup
+	for i, code := range ceps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = c.Lookup(ctx, code)
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("CEP %s: %w", ceps[i], err)
+		}
+		if !results[i].HasCoordinates() {
+			return nil, fmt.Errorf("CEP %s: %w", results[i].CEP, ErrNoCoordinates)
+		}
+	}
+	from, to := results[0], results[1]
+	return &CEPDistance{From: from, To: to, KM: Haversine(from.Latitude, from.Longitude, to.Latitude, to.Longitude)}, nil
+}
+
+// Distância em linha reta, em quilômetros, entre duas coordenadas em graus,
+// pela fórmula de haversine sobre uma esfera com o raio médio da Terra
+func Haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKM * math.Asin(math.Sqrt(min(h, 1)))
+}
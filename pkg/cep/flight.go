@@ -0,0 +1,85 @@
+package cep
+
+import (
+	"context"
+	"sync"
+)
+
+// Agrupa as consultas simultâneas ao mesmo CEP em uma única corrida: a
+// primeira dispara e todas aguardam o mesmo resultado (singleflight)
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// Consulta em andamento, concluída quando done é fechado
+type flight struct {
+	done    chan struct{}
+	result  *Result
+	err     error
+	cancel  context.CancelFunc // Cancela a consulta compartilhada
+	waiters int                // Chamadas ainda aguardando o resultado
+}
+
+// Executa fn para a chave, ou aguarda a execução já em andamento. A execução
+// compartilhada não depende do contexto de nenhuma das chamadas: recebe um
+// contexto com os valores do da primeira, mas sem o cancelamento e o prazo
+// dele, e só é cancelada quando todas as chamadas desistem. Cada chamada
+// aguarda até o fim do próprio contexto, retornando então ctx.Err(). Quem
+// aguarda recebe uma cópia do resultado, que pode ser alterada livremente.
+func (g *flightGroup) do(ctx context.Context, key string, fn func(ctx context.Context) (*Result, error)) (*Result, error) {
+	g.mu.Lock()
+	f, ok := g.flights[key]
+	if ok {
+		f.waiters++
+	} else {
+		shared, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight{done: make(chan struct{}), cancel: cancel, waiters: 1}
+		if g.flights == nil {
+			g.flights = make(map[string]*flight)
+		}
+		g.flights[key] = f
+		go g.run(key, f, shared, fn)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		if f.result == nil {
+			return nil, f.err
+		}
+		result := *f.result
+		return &result, f.err
+
+	case <-ctx.Done():
+		// Sem ninguém aguardando, a consulta é cancelada e deixa de ser
+		// compartilhada com as chamadas seguintes
+		g.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			f.cancel()
+			g.forget(key, f)
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// Executa a consulta compartilhada e a remove do grupo ao concluir
+func (g *flightGroup) run(key string, f *flight, ctx context.Context, fn func(ctx context.Context) (*Result, error)) {
+	defer f.cancel()
+	f.result, f.err = fn(ctx)
+
+	g.mu.Lock()
+	g.forget(key, f)
+	g.mu.Unlock()
+	close(f.done)
+}
+
+// Remove a consulta do grupo, se ainda for a registrada para a chave. Deve
+// ser chamada com g.mu travado.
+func (g *flightGroup) forget(key string, f *flight) {
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+}
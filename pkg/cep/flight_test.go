@@ -0,0 +1,118 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLookupCoalescing(t *testing.T) {
+	tests := []struct {
+		name    string
+		first   func() (context.Context, context.CancelFunc) // Contexto da primeira chamada
+		wantErr error
+	}{
+		{
+			name: "primeira chamada cancelada",
+			first: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), time.Hour)
+			},
+			wantErr: context.Canceled,
+		},
+		{
+			name: "prazo da primeira chamada esgotado",
+			first: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 20*time.Millisecond)
+			},
+			wantErr: ErrTimeout,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				time.Sleep(100 * time.Millisecond)
+				w.Write([]byte(`{"cep": "01001-000", "logradouro": "Praça da Sé", "bairro": "Sé", "localidade": "São Paulo", "uf": "SP"}`))
+			}))
+			defer srv.Close()
+
+			c := &Client{Timeout: time.Second}
+			c.Providers = stubProviders([]string{"Stub"}, srv)
+
+			firstCtx, cancel := tt.first()
+			defer cancel()
+			var wg sync.WaitGroup
+			var firstErr error
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, firstErr = c.Lookup(firstCtx, "01001000")
+			}()
+			time.Sleep(10 * time.Millisecond)
+
+			// A segunda chamada compartilha a corrida e não é afetada pela primeira
+			var second *Result
+			var secondErr error
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				second, secondErr = c.Lookup(context.Background(), "01001000")
+			}()
+			time.Sleep(20 * time.Millisecond)
+			cancel()
+			wg.Wait()
+
+			if !errors.Is(firstErr, tt.wantErr) {
+				t.Errorf("primeira chamada: erro = %v, esperado %v", firstErr, tt.wantErr)
+			}
+			if tt.wantErr == context.Canceled && errors.Is(firstErr, ErrTimeout) {
+				t.Errorf("primeira chamada: cancelamento reportado como timeout: %v", firstErr)
+			}
+			if secondErr != nil || second == nil || second.Logradouro != "Praça da Sé" {
+				t.Errorf("segunda chamada: resultado = %v, erro = %v", second, secondErr)
+			}
+			if got := requests.Load(); got != 1 {
+				t.Errorf("%d requisições à API, esperada 1 corrida compartilhada", got)
+			}
+		})
+	}
+}
+
+// Sem ninguém aguardando, a corrida compartilhada é cancelada e a chamada
+// seguinte dispara uma nova
+func TestLookupCoalescingAllCallersGone(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+		w.Write([]byte(`{"cep": "01001-000", "logradouro": "Praça da Sé", "bairro": "Sé", "localidade": "São Paulo", "uf": "SP"}`))
+	}))
+	defer srv.Close()
+
+	c := &Client{Timeout: time.Second}
+	c.Providers = stubProviders([]string{"Stub"}, srv)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.Lookup(ctx, "01001000"); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("erro = %v, esperado ErrTimeout", err)
+	}
+
+	result, err := c.Lookup(context.Background(), "01001000")
+	if err != nil || result.Logradouro != "Praça da Sé" {
+		t.Fatalf("resultado = %v, erro = %v", result, err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("%d requisições à API, esperadas 2", got)
+	}
+}
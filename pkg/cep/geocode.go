@@ -0,0 +1,137 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// URL da Brasil API v2, que inclui as coordenadas do CEP, consultada no lugar
+// da v1 quando Client.Geo está ativo (%s é substituído pelo CEP)
+const brasilAPIV2URL = "https://brasilapi.com.br/api/cep/v2/%s"
+
+// URL de busca do Nominatim (OpenStreetMap), o geocodificador padrão
+const NominatimURL = "https://nominatim.openstreetmap.org/search"
+
+// Erro retornado pelo geocodificador quando o endereço não tem coordenadas
+var ErrNoCoordinates = errors.New("coordenadas não encontradas")
+
+// Geocodificador que obtém as coordenadas do endereço de um resultado,
+// usado quando a API vencedora não as informa (ver Client.Geo)
+type Geocoder interface {
+	Name() string
+	Geocode(ctx context.Context, result *Result) (lat, lon float64, err error)
+}
+
+// Geocodificador pelo Nominatim (OpenStreetMap), a partir do logradouro,
+// cidade e estado do resultado. A política de uso do serviço público limita
+// as consultas a 1 por segundo; para volumes maiores, informe uma instância
+// própria em URL.
+type NominatimGeocoder struct {
+	c   *Client
+	URL string // URL de busca, vazio usa NominatimURL
+}
+
+// Cria o geocodificador pelo Nominatim usando o client HTTP e o User-Agent
+// do Client (nil usa o valor zero)
+func NewNominatimGeocoder(c *Client) *NominatimGeocoder {
+	if c == nil {
+		c = &Client{}
+	}
+	return &NominatimGeocoder{c: c}
+}
+
+func (g *NominatimGeocoder) Name() string {
+	return "Nominatim"
+}
+
+// Resposta da busca do Nominatim (format=jsonv2), com as coordenadas em texto
+type nominatimPlace struct {
+	Lat string `json:"lat"`
+	Lon string `json:"lon"`
+}
+
+func (g *NominatimGeocoder) Geocode(ctx context.Context, result *Result) (float64, float64, error) {
+	base := g.URL
+	if base == "" {
+		base = NominatimURL
+	}
+	query := url.Values{"format": {"jsonv2"}, "limit": {"1"}, "country": {"Brasil"}}
+	if result.Logradouro != "" {
+		query.Set("street", result.Logradouro)
+	}
+	query.Set("city", result.Cidade)
+	query.Set("state", result.Estado)
+
+	resp, _, err := g.c.get(ctx, g.c.httpClient(), g.Name(), base+"?"+query.Encode())
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, 0, fmt.Errorf("%s: %w", g.Name(), &httpStatusError{code: resp.StatusCode})
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: erro na leitura: %v", g.Name(), err)
+	}
+	var places []nominatimPlace
+	if err := json.Unmarshal(body, &places); err != nil {
+		return 0, 0, fmt.Errorf("%s: erro no parse: %v", g.Name(), err)
+	}
+	if len(places) == 0 {
+		return 0, 0, fmt.Errorf("%s: %w", g.Name(), ErrNoCoordinates)
+	}
+	lat, latErr := strconv.ParseFloat(places[0].Lat, 64)
+	lon, lonErr := strconv.ParseFloat(places[0].Lon, 64)
+	if latErr != nil || lonErr != nil {
+		return 0, 0, fmt.Errorf("%s: coordenadas inválidas: %q, %q", g.Name(), places[0].Lat, places[0].Lon)
+	}
+	return lat, lon, nil
+}
+
+// Complementa o resultado sem coordenadas com as do geocodificador
+// (Client.Geocoder, nil usa o Nominatim). A falha é apenas registrada no
+// Logger: o resultado continua válido sem as coordenadas.
+func (c *Client) geocode(ctx context.Context, result *Result) {
+	if !c.Geo || result.HasCoordinates() || result.SomenteMunicipio {
+		return
+	}
+	var geocoder Geocoder = c.Geocoder
+	if geocoder == nil {
+		geocoder = NewNominatimGeocoder(c)
+	}
+
+	lat, lon, err := geocoder.Geocode(ctx, result)
+	if err != nil {
+		c.logger().Warn(ctx, "geocodificação", "geocodificador", geocoder.Name(), "erro", err)
+		return
+	}
+	result.Latitude, result.Longitude = lat, lon
+	result.CoordinatesSource = geocoder.Name()
+}
+
+// Coordenadas da resposta da Brasil API v2, em texto e vazias quando o CEP
+// não tem localização
+type brasilAPILocation struct {
+	Coordinates struct {
+		Latitude  string `json:"latitude"`
+		Longitude string `json:"longitude"`
+	} `json:"coordinates"`
+}
+
+// Converte as coordenadas da Brasil API, indicando se são válidas
+func (l brasilAPILocation) parse() (lat, lon float64, ok bool) {
+	lat, latErr := strconv.ParseFloat(l.Coordinates.Latitude, 64)
+	lon, lonErr := strconv.ParseFloat(l.Coordinates.Longitude, 64)
+	if latErr != nil || lonErr != nil || (lat == 0 && lon == 0) {
+		return 0, 0, false
+	}
+	return lat, lon, true
+}
@@ -0,0 +1,69 @@
+package cep
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Base de áreas de entrega em GeoJSON, indexada pelo prefixo de CEP
+// informado na propriedade "cep_prefix" de cada feature
+type GeoDB struct {
+	geometries map[string]json.RawMessage
+}
+
+// Estrutura mínima de uma FeatureCollection GeoJSON
+type featureCollection struct {
+	Features []struct {
+		Properties struct {
+			CEPPrefix string `json:"cep_prefix"`
+		} `json:"properties"`
+		Geometry json.RawMessage `json:"geometry"`
+	} `json:"features"`
+}
+
+// Carrega a base GeoJSON do arquivo informado
+func LoadGeoDB(path string) (*GeoDB, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("geojson: erro ao ler %s: %v", path, err)
+	}
+
+	var fc featureCollection
+	if err := json.Unmarshal(data, &fc); err != nil {
+		return nil, fmt.Errorf("geojson: arquivo %s inválido: %v", path, err)
+	}
+
+	db := &GeoDB{geometries: make(map[string]json.RawMessage, len(fc.Features))}
+	for _, f := range fc.Features {
+		prefix := strings.ReplaceAll(f.Properties.CEPPrefix, "-", "")
+		if prefix == "" || len(f.Geometry) == 0 {
+			continue
+		}
+		db.geometries[prefix] = f.Geometry
+	}
+	return db, nil
+}
+
+// Busca a geometria do maior prefixo da base que corresponda ao CEP
+func (db *GeoDB) lookup(cep string) (json.RawMessage, bool) {
+	digits := strings.ReplaceAll(cep, "-", "")
+	for n := len(digits); n > 0; n-- {
+		if geometry, ok := db.geometries[digits[:n]]; ok {
+			return geometry, true
+		}
+	}
+	return nil, false
+}
+
+// Retorna o tipo da geometria (ex: "Polygon") para exibição
+func GeometryType(geometry json.RawMessage) string {
+	var g struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(geometry, &g); err != nil || g.Type == "" {
+		return "desconhecido"
+	}
+	return g.Type
+}
@@ -0,0 +1,252 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// URL base da API de serviços de dados do IBGE (localidades e agregados)
+const IBGEURL = "https://servicodados.ibge.gov.br/api"
+
+// Caminhos da API do IBGE: o município pelo código, os municípios de uma UF
+// e a população residente no Censo 2022 (agregado 4709, variável 93)
+const (
+	ibgeMunicipalityPath = "/v1/localidades/municipios/%s"
+	ibgeStatePath        = "/v1/localidades/estados/%s/municipios"
+	ibgePopulationPath   = "/v3/agregados/4709/periodos/2022/variaveis/93?localidades=N6[%s]"
+	ibgePopulationCensus = "2022"
+	ibgeAPIName          = "IBGE"
+)
+
+// Dados do município no IBGE, complementados com Client.IBGE
+type Municipality struct {
+	IBGE         string `json:"ibge"`
+	Nome         string `json:"nome"`
+	Microrregiao string `json:"microrregiao,omitempty"`
+	Mesorregiao  string `json:"mesorregiao,omitempty"`
+	Regiao       string `json:"regiao,omitempty"`    // Região do país (ex: "Sudeste")
+	Populacao    int    `json:"populacao,omitempty"` // População residente no Censo 2022, 0 se indisponível
+}
+
+// Municípios do IBGE já consultados por um Client, por código do IBGE, e os
+// códigos dos municípios de cada UF, para os resultados sem o código
+type municipalityCache struct {
+	mu     sync.Mutex
+	byCode map[string]*Municipality
+	byUF   map[string]map[string]string // Código do IBGE por nome normalizado do município
+}
+
+func (m *municipalityCache) get(code string) (*Municipality, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mun, ok := m.byCode[code]
+	return mun, ok
+}
+
+func (m *municipalityCache) set(mun *Municipality) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.byCode == nil {
+		m.byCode = make(map[string]*Municipality)
+	}
+	m.byCode[mun.IBGE] = mun
+}
+
+// Resposta de /localidades/municipios, apenas com os campos utilizados
+type ibgeMunicipalityResponse struct {
+	ID           int    `json:"id"`
+	Nome         string `json:"nome"`
+	Microrregiao *struct {
+		Nome        string `json:"nome"`
+		Mesorregiao struct {
+			Nome string `json:"nome"`
+			UF   struct {
+				Regiao struct {
+					Nome string `json:"nome"`
+				} `json:"regiao"`
+			} `json:"UF"`
+		} `json:"mesorregiao"`
+	} `json:"microrregiao"`
+}
+
+// Resposta de /agregados: as séries de cada variável, com o valor por período
+type ibgeAggregateResponse []struct {
+	Resultados []struct {
+		Series []struct {
+			Serie map[string]string `json:"serie"`
+		} `json:"series"`
+	} `json:"resultados"`
+}
+
+// Complementa o resultado com os dados do município no IBGE. Sem o código
+// do IBGE na resposta da API, o município é identificado pelo nome na UF. A
+// falha é apenas registrada no Logger, como na geocodificação.
+func (c *Client) enrichMunicipality(ctx context.Context, result *Result) {
+	if !c.IBGE || result.SomenteMunicipio {
+		return
+	}
+
+	code := result.IBGE
+	if code == "" {
+		found, err := c.ibgeCodeByName(ctx, result.Estado, result.Cidade)
+		if err != nil {
+			c.logIBGEError(ctx, err)
+			return
+		}
+		code = found
+	}
+
+	mun, ok := c.municipalities.get(code)
+	if !ok {
+		fetched, err := c.fetchMunicipality(ctx, code)
+		if err != nil {
+			c.logIBGEError(ctx, err)
+			return
+		}
+		mun = fetched
+		c.municipalities.set(mun)
+	}
+	if result.IBGE == "" {
+		result.IBGE = mun.IBGE
+	}
+	result.Municipality = mun
+}
+
+// Registra no Logger a falha da consulta ao IBGE
+func (c *Client) logIBGEError(ctx context.Context, err error) {
+	c.logger().Warn(ctx, "município", "api", ibgeAPIName, "erro", err)
+}
+
+// URL da API do IBGE com o caminho informado
+func (c *Client) ibgeURL(path string, args ...any) string {
+	base := c.IBGEURL
+	if base == "" {
+		base = IBGEURL
+	}
+	return strings.TrimSuffix(base, "/") + fmt.Sprintf(path, args...)
+}
+
+// Consulta a API do IBGE e faz o parse da resposta em JSON
+func (c *Client) getIBGE(ctx context.Context, u string, into any) error {
+	resp, _, err := c.get(ctx, c.httpClient(), ibgeAPIName, u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %w", ibgeAPIName, &httpStatusError{code: resp.StatusCode})
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s: erro na leitura: %v", ibgeAPIName, err)
+	}
+	if err := json.Unmarshal(body, into); err != nil {
+		return fmt.Errorf("%s: erro no parse: %v", ibgeAPIName, err)
+	}
+	return nil
+}
+
+// Busca o município e a população pelo código do IBGE. A população é
+// opcional: se a consulta falhar, o município é retornado sem ela.
+func (c *Client) fetchMunicipality(ctx context.Context, code string) (*Municipality, error) {
+	var raw json.RawMessage
+	if err := c.getIBGE(ctx, c.ibgeURL(ibgeMunicipalityPath, url.PathEscape(code)), &raw); err != nil {
+		return nil, err
+	}
+	// Códigos inexistentes retornam 200 com uma lista vazia
+	var resp ibgeMunicipalityResponse
+	if !strings.HasPrefix(strings.TrimSpace(string(raw)), "[") {
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			return nil, fmt.Errorf("%s: erro no parse: %v", ibgeAPIName, err)
+		}
+	}
+	if resp.ID == 0 {
+		return nil, fmt.Errorf("%s: município %s não encontrado", ibgeAPIName, code)
+	}
+
+	mun := &Municipality{IBGE: strconv.Itoa(resp.ID), Nome: resp.Nome}
+	if m := resp.Microrregiao; m != nil {
+		mun.Microrregiao = m.Nome
+		mun.Mesorregiao = m.Mesorregiao.Nome
+		mun.Regiao = m.Mesorregiao.UF.Regiao.Nome
+	}
+
+	var population ibgeAggregateResponse
+	if err := c.getIBGE(ctx, c.ibgeURL(ibgePopulationPath, mun.IBGE), &population); err != nil {
+		c.logIBGEError(ctx, err)
+		return mun, nil
+	}
+	for _, v := range population {
+		for _, r := range v.Resultados {
+			for _, s := range r.Series {
+				if n, err := strconv.Atoi(s.Serie[ibgePopulationCensus]); err == nil {
+					mun.Populacao = n
+				}
+			}
+		}
+	}
+	return mun, nil
+}
+
+// Identifica o código do IBGE do município pelo nome, entre os municípios da
+// UF (consultados uma única vez por Client)
+func (c *Client) ibgeCodeByName(ctx context.Context, uf, city string) (string, error) {
+	uf = strings.ToUpper(strings.TrimSpace(uf))
+	if uf == "" || strings.TrimSpace(city) == "" {
+		return "", errors.New("IBGE: resultado sem cidade e estado para identificar o município")
+	}
+
+	c.municipalities.mu.Lock()
+	codes, ok := c.municipalities.byUF[uf]
+	c.municipalities.mu.Unlock()
+	if !ok {
+		var list []struct {
+			ID   int    `json:"id"`
+			Nome string `json:"nome"`
+		}
+		if err := c.getIBGE(ctx, c.ibgeURL(ibgeStatePath, url.PathEscape(uf)), &list); err != nil {
+			return "", err
+		}
+		codes = make(map[string]string, len(list))
+		for _, m := range list {
+			codes[foldName(m.Nome)] = strconv.Itoa(m.ID)
+		}
+
+		c.municipalities.mu.Lock()
+		if c.municipalities.byUF == nil {
+			c.municipalities.byUF = make(map[string]map[string]string)
+		}
+		c.municipalities.byUF[uf] = codes
+		c.municipalities.mu.Unlock()
+	}
+
+	code, ok := codes[foldName(city)]
+	if !ok {
+		return "", fmt.Errorf("IBGE: município %q não encontrado em %s", city, uf)
+	}
+	return code, nil
+}
+
+// Remove acentos, caixa e espaços extras do nome, para comparar os nomes de
+// municípios entre as APIs e o IBGE (ex: "São Paulo" e "SAO PAULO")
+var nameFolder = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "ê", "e", "è", "e", "ë", "e",
+	"í", "i", "î", "i", "ì", "i", "ï", "i",
+	"ó", "o", "ô", "o", "õ", "o", "ò", "o", "ö", "o",
+	"ú", "u", "û", "u", "ù", "u", "ü", "u",
+	"ç", "c", "ñ", "n", "'", "", "`", "",
+)
+
+func foldName(name string) string {
+	return nameFolder.Replace(strings.Join(strings.Fields(strings.ToLower(name)), " "))
+}
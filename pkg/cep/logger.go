@@ -0,0 +1,98 @@
+package cep
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Registro das requisições e dos desfechos do Client (ver Client.Logger),
+// com os atributos em pares chave-valor, como no slog (ex: "api", "ViaCEP",
+// "tempo", "12ms"). Adapta o Client a outras bibliotecas de log (ex: zap ou
+// logrus); NewSlogLogger usa o slog e NopLogger descarta os registros.
+type Logger interface {
+	Debug(ctx context.Context, msg string, args ...any)
+	Info(ctx context.Context, msg string, args ...any)
+	Warn(ctx context.Context, msg string, args ...any)
+	Error(ctx context.Context, msg string, args ...any)
+}
+
+// Logger que descarta todos os registros, equivalente a Client.Logger nil
+var NopLogger Logger = nopLogger{}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(context.Context, string, ...any) {}
+func (nopLogger) Info(context.Context, string, ...any)  {}
+func (nopLogger) Warn(context.Context, string, ...any)  {}
+func (nopLogger) Error(context.Context, string, ...any) {}
+
+// Retorna um Logger sobre o *slog.Logger, com o contexto repassado ao
+// handler; nil usa slog.Default()
+func NewSlogLogger(l *slog.Logger) Logger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return slogLogger{l}
+}
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func (s slogLogger) Debug(ctx context.Context, msg string, args ...any) {
+	s.l.DebugContext(ctx, msg, args...)
+}
+
+func (s slogLogger) Info(ctx context.Context, msg string, args ...any) {
+	s.l.InfoContext(ctx, msg, args...)
+}
+
+func (s slogLogger) Warn(ctx context.Context, msg string, args ...any) {
+	s.l.WarnContext(ctx, msg, args...)
+}
+
+func (s slogLogger) Error(ctx context.Context, msg string, args ...any) {
+	s.l.ErrorContext(ctx, msg, args...)
+}
+
+// Permite que o Client evite montar os atributos de níveis descartados
+func (s slogLogger) Enabled(ctx context.Context, level slog.Level) bool {
+	return s.l.Enabled(ctx, level)
+}
+
+// Indica se o Logger registra o nível. Os Loggers sem o método Enabled (ex:
+// adaptadores de outras bibliotecas) recebem todos os níveis; nil não
+// registra nenhum.
+func logEnabled(ctx context.Context, l Logger, level slog.Level) bool {
+	if l == nil {
+		return false
+	}
+	if e, ok := l.(interface {
+		Enabled(context.Context, slog.Level) bool
+	}); ok {
+		return e.Enabled(ctx, level)
+	}
+	return true
+}
+
+// Registra a mensagem no método do Logger correspondente ao nível
+func logAt(ctx context.Context, l Logger, level slog.Level, msg string, args ...any) {
+	switch {
+	case level >= slog.LevelError:
+		l.Error(ctx, msg, args...)
+	case level >= slog.LevelWarn:
+		l.Warn(ctx, msg, args...)
+	case level >= slog.LevelInfo:
+		l.Info(ctx, msg, args...)
+	default:
+		l.Debug(ctx, msg, args...)
+	}
+}
+
+// Logger do Client, NopLogger quando não configurado
+func (c *Client) logger() Logger {
+	if c.Logger == nil {
+		return NopLogger
+	}
+	return c.Logger
+}
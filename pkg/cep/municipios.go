@@ -0,0 +1,69 @@
+package cep
+
+import "strconv"
+
+// Faixa de prefixos de CEP (5 primeiros dígitos) pertencente a um município
+type municipalityRange struct {
+	start, end int
+	cidade     string
+	estado     string
+}
+
+// Faixas de CEP das capitais estaduais, usadas no fallback em nível de município
+var municipalityRanges = []municipalityRange{
+	{1000, 5999, "São Paulo", "SP"},
+	{8000, 8499, "São Paulo", "SP"},
+	{20000, 23799, "Rio de Janeiro", "RJ"},
+	{29000, 29099, "Vitória", "ES"},
+	{30000, 31999, "Belo Horizonte", "MG"},
+	{40000, 42599, "Salvador", "BA"},
+	{49000, 49099, "Aracaju", "SE"},
+	{50000, 52999, "Recife", "PE"},
+	{57000, 57099, "Maceió", "AL"},
+	{58000, 58099, "João Pessoa", "PB"},
+	{59000, 59139, "Natal", "RN"},
+	{60000, 61599, "Fortaleza", "CE"},
+	{64000, 64099, "Teresina", "PI"},
+	{65000, 65109, "São Luís", "MA"},
+	{66000, 66999, "Belém", "PA"},
+	{68900, 68914, "Macapá", "AP"},
+	{69000, 69099, "Manaus", "AM"},
+	{69300, 69339, "Boa Vista", "RR"},
+	{69900, 69923, "Rio Branco", "AC"},
+	{70000, 72799, "Brasília", "DF"},
+	{73000, 73699, "Brasília", "DF"},
+	{74000, 74899, "Goiânia", "GO"},
+	{76800, 76834, "Porto Velho", "RO"},
+	{77000, 77249, "Palmas", "TO"},
+	{78000, 78099, "Cuiabá", "MT"},
+	{79000, 79124, "Campo Grande", "MS"},
+	{80000, 82999, "Curitiba", "PR"},
+	{88000, 88099, "Florianópolis", "SC"},
+	{90000, 91999, "Porto Alegre", "RS"},
+}
+
+// Busca o município pelo prefixo do CEP, retornando um resultado parcial
+// apenas com cidade e estado preenchidos
+func lookupMunicipality(cep string) (*Result, bool) {
+	if len(cep) != 8 {
+		return nil, false
+	}
+	prefix, err := strconv.Atoi(cep[:5])
+	if err != nil {
+		return nil, false
+	}
+
+	for _, r := range municipalityRanges {
+		if prefix >= r.start && prefix <= r.end {
+			return &Result{
+				API:              "Tabela de municípios",
+				CEP:              Format(cep),
+				Cidade:           r.cidade,
+				Estado:           r.estado,
+				Origem:           "municipio",
+				SomenteMunicipio: true,
+			}, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,144 @@
+package cep
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Base offline de faixas de CEP por município e estado, consultada quando
+// nenhuma API responde (ver Client.OfflineFallback). Na sobreposição de
+// faixas, vale a mais estreita.
+type OfflineDB struct {
+	ranges []offlineRange
+}
+
+// Faixa de CEPs completos (8 dígitos, como inteiros) de um município ou,
+// com a cidade vazia, de um estado
+type offlineRange struct {
+	start, end int
+	cidade     string
+	estado     string
+}
+
+// Base embutida no binário: as faixas das capitais e as de cada estado
+var embeddedOfflineDB = newEmbeddedOfflineDB()
+
+func newEmbeddedOfflineDB() *OfflineDB {
+	db := &OfflineDB{}
+	for _, r := range append(append([]municipalityRange(nil), municipalityRanges...), stateRanges...) {
+		db.ranges = append(db.ranges, offlineRange{start: r.start * 1000, end: r.end*1000 + 999, cidade: r.cidade, estado: r.estado})
+	}
+	return db
+}
+
+// Carrega uma base offline em CSV (ex: baixada de uma fonte mais detalhada
+// que a embutida), com uma faixa por linha: início, fim, cidade e UF (ex:
+// "13330000,13339999,Indaiatuba,SP"). Os limites são CEPs completos ou
+// prefixos de 5 dígitos; a cidade vazia indica uma faixa do estado inteiro.
+// Linhas iniciadas por # e um cabeçalho na primeira linha são ignorados.
+func LoadOfflineDB(path string) (*OfflineDB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("base offline: erro ao ler %s: %v", path, err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comment = '#'
+	r.FieldsPerRecord = 4
+	r.TrimLeadingSpace = true
+	db := &OfflineDB{}
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("base offline: arquivo %s inválido: %v", path, err)
+		}
+		start, startErr := parseRangeBound(record[0], false)
+		end, endErr := parseRangeBound(record[1], true)
+		if startErr != nil || endErr != nil {
+			if line == 1 {
+				continue // Cabeçalho
+			}
+			return nil, fmt.Errorf("base offline: faixa inválida na linha %d de %s: %q a %q", line, path, record[0], record[1])
+		}
+		estado := strings.ToUpper(strings.TrimSpace(record[3]))
+		if start > end || len(estado) != 2 {
+			return nil, fmt.Errorf("base offline: faixa inválida na linha %d de %s", line, path)
+		}
+		db.ranges = append(db.ranges, offlineRange{start: start, end: end, cidade: strings.TrimSpace(record[2]), estado: estado})
+	}
+	if len(db.ranges) == 0 {
+		return nil, fmt.Errorf("base offline: nenhuma faixa em %s", path)
+	}
+	return db, nil
+}
+
+// Limite de uma faixa: CEP completo ou prefixo de 5 dígitos, completado com
+// 000 no início ou 999 no fim da faixa
+func parseRangeBound(value string, end bool) (int, error) {
+	value = strings.NewReplacer("-", "", ".", "").Replace(strings.TrimSpace(value))
+	if len(value) == 5 {
+		if end {
+			value += "999"
+		} else {
+			value += "000"
+		}
+	}
+	if len(value) != 8 {
+		return 0, fmt.Errorf("limite inválido: %q", value)
+	}
+	return strconv.Atoi(value)
+}
+
+// Busca a faixa mais estreita que contém o CEP, retornando um resultado
+// parcial apenas com cidade (se conhecida) e estado
+func (db *OfflineDB) lookup(cep string) (*Result, bool) {
+	n, err := strconv.Atoi(cep)
+	if err != nil || len(cep) != 8 {
+		return nil, false
+	}
+	var best *offlineRange
+	for i := range db.ranges {
+		r := &db.ranges[i]
+		if n >= r.start && n <= r.end && (best == nil || r.end-r.start < best.end-best.start) {
+			best = r
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+	return &Result{
+		API:              "Base offline",
+		CEP:              Format(cep),
+		Cidade:           best.cidade,
+		Estado:           best.estado,
+		Origem:           "offline",
+		SomenteMunicipio: true,
+	}, true
+}
+
+// Base offline do Client: a de Client.OfflineDB ou, sem ela, a embutida
+func (c *Client) offlineDB() *OfflineDB {
+	if c.OfflineDB != nil {
+		return c.OfflineDB
+	}
+	return embeddedOfflineDB
+}
+
+// Indica se alguma API informou que o CEP não existe
+func anyNotFound(errs []error) bool {
+	for _, err := range errs {
+		if errors.Is(err, ErrCEPNotFound) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// URL do OpenCEP (%s é substituído pelo CEP)
+const openCEPURL = "https://opencep.com/v1/%s"
+
+// Estrutura para parse de respostas da API - OpenCEP
+type OpenCEPResponse struct {
+	CEP         string     `json:"cep"`
+	Logradouro  string     `json:"logradouro"`
+	Complemento string     `json:"complemento"`
+	Bairro      string     `json:"bairro"`
+	Localidade  string     `json:"localidade"`
+	UF          string     `json:"uf"`
+	IBGE        flexString `json:"ibge"`
+}
+
+// Busca o CEP na API OpenCEP
+func (c *Client) fetchOpenCEP(ctx context.Context, cep string) (*Result, error) {
+	// Executa a requisição, medindo o tempo até o fim do parse da resposta
+	resp, start, err := c.get(ctx, c.httpClient(), "OpenCEP", fmt.Sprintf(c.url("opencep"), cep))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Checa o status code da requisição
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("OpenCEP: %w", ErrCEPNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OpenCEP: %w", &httpStatusError{code: resp.StatusCode})
+	}
+
+	// Realiza leitura e parse das respostas
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("OpenCEP: erro na leitura: %v", err)
+	}
+	if body, err = c.transformBody("OpenCEP", body); err != nil {
+		return nil, err
+	}
+
+	var apiResponse OpenCEPResponse
+	if err := json.Unmarshal(body, &apiResponse); err != nil {
+		return nil, fmt.Errorf("OpenCEP: erro no parse: %v", err)
+	}
+	if apiResponse.CEP == "" {
+		return nil, fmt.Errorf("OpenCEP: %w", ErrCEPNotFound)
+	}
+
+	// Resultado unificado
+	result := &Result{
+		API:        "OpenCEP",
+		CEP:        Format(apiResponse.CEP),
+		Logradouro: apiResponse.Logradouro,
+		Bairro:     apiResponse.Bairro,
+		Cidade:     apiResponse.Localidade,
+		Estado:     apiResponse.UF,
+		Origem:     "opencep",
+		IBGE:       string(apiResponse.IBGE),
+		Elapsed:    time.Since(start),
+	}
+
+	return result, nil
+}
@@ -0,0 +1,75 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// URL do Postmon (%s é substituído pelo CEP)
+const postmonURL = "https://api.postmon.com.br/v1/cep/%s"
+
+// Estrutura para parse de respostas da API - Postmon
+type PostmonResponse struct {
+	CEP        string `json:"cep"`
+	Logradouro string `json:"logradouro"`
+	Bairro     string `json:"bairro"`
+	Cidade     string `json:"cidade"`
+	Estado     string `json:"estado"`
+	CidadeInfo struct {
+		CodigoIBGE flexString `json:"codigo_ibge"`
+	} `json:"cidade_info"`
+}
+
+// Busca o CEP na API Postmon
+func (c *Client) fetchPostmon(ctx context.Context, cep string) (*Result, error) {
+	// Executa a requisição, medindo o tempo até o fim do parse da resposta
+	resp, start, err := c.get(ctx, c.httpClient(), "Postmon", fmt.Sprintf(c.url("postmon"), cep))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Checa o status code da requisição
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("Postmon: %w", ErrCEPNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Postmon: %w", &httpStatusError{code: resp.StatusCode})
+	}
+
+	// Realiza leitura e parse das respostas
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Postmon: erro na leitura: %v", err)
+	}
+	if body, err = c.transformBody("Postmon", body); err != nil {
+		return nil, err
+	}
+
+	var apiResponse PostmonResponse
+	if err := json.Unmarshal(body, &apiResponse); err != nil {
+		return nil, fmt.Errorf("Postmon: erro no parse: %v", err)
+	}
+	if apiResponse.CEP == "" {
+		return nil, fmt.Errorf("Postmon: %w", ErrCEPNotFound)
+	}
+
+	// Resultado unificado
+	result := &Result{
+		API:        "Postmon",
+		CEP:        Format(apiResponse.CEP),
+		Logradouro: apiResponse.Logradouro,
+		Bairro:     apiResponse.Bairro,
+		Cidade:     apiResponse.Cidade,
+		Estado:     apiResponse.Estado,
+		Origem:     "postmon",
+		IBGE:       string(apiResponse.CidadeInfo.CodigoIBGE),
+		Elapsed:    time.Since(start),
+	}
+
+	return result, nil
+}
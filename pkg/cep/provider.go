@@ -0,0 +1,235 @@
+package cep
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// API de consulta de CEP que participa da corrida
+type Provider interface {
+	Name() string
+	Fetch(ctx context.Context, cep string) (*Result, error)
+}
+
+// Construtor de uma API a partir das configurações do Client (client HTTP,
+// URLs, User-Agent...)
+type ProviderFactory func(c *Client) Provider
+
+// Dados de uma API registrada
+type ProviderInfo struct {
+	ID   string // Identificador usado nas opções e em Client.URLs (ex: "viacep")
+	Name string // Nome exibido nos resultados e erros (ex: "ViaCEP")
+	URL  string // URL padrão, %s é substituído pelo CEP
+}
+
+// APIs registradas, na ordem em que participam da corrida
+var (
+	registryMu sync.RWMutex
+	registry   = []registeredProvider{
+		{ProviderInfo{"brasilapi", "Brasil API", brasilAPIURL}, func(c *Client) Provider { return &brasilAPIProvider{c: c} }},
+		{ProviderInfo{"viacep", "ViaCEP", viaCEPURL}, func(c *Client) Provider { return &viaCEPProvider{c: c} }},
+		{ProviderInfo{"opencep", "OpenCEP", openCEPURL}, func(c *Client) Provider { return &openCEPProvider{c: c} }},
+		{ProviderInfo{"apicep", "ApiCEP", apiCEPURL}, func(c *Client) Provider { return &apiCEPProvider{c: c} }},
+		{ProviderInfo{"postmon", "Postmon", postmonURL}, func(c *Client) Provider { return &postmonProvider{c: c} }},
+	}
+)
+
+type registeredProvider struct {
+	info    ProviderInfo
+	factory ProviderFactory
+}
+
+// Registra uma nova API, que passa a participar das corridas dos Clients sem
+// Providers configurados. Entra em pânico se o identificador for vazio ou já
+// estiver registrado; deve ser chamada na inicialização (ex: em init).
+func RegisterProvider(info ProviderInfo, factory ProviderFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if info.ID == "" || factory == nil {
+		panic("cep: RegisterProvider sem identificador ou construtor")
+	}
+	for _, p := range registry {
+		if p.info.ID == info.ID {
+			panic("cep: API registrada duas vezes: " + info.ID)
+		}
+	}
+	registry = append(registry, registeredProvider{info: info, factory: factory})
+}
+
+// Lista as APIs registradas, na ordem de registro
+func RegisteredProviders() []ProviderInfo {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	infos := make([]ProviderInfo, len(registry))
+	for i, p := range registry {
+		infos[i] = p.info
+	}
+	return infos
+}
+
+// URLs padrão indexadas pelo identificador da API
+func DefaultURLs() map[string]string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	urls := make(map[string]string, len(registry))
+	for _, p := range registry {
+		urls[p.info.ID] = p.info.URL
+	}
+	return urls
+}
+
+// Cria a API registrada com o identificador informado, usando as
+// configurações do Client (nil usa o valor zero)
+func NewProvider(id string, c *Client) (Provider, error) {
+	if c == nil {
+		c = &Client{}
+	}
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for _, p := range registry {
+		if p.info.ID == id {
+			return p.factory(c), nil
+		}
+	}
+	return nil, fmt.Errorf("API desconhecida: %q", id)
+}
+
+// Cria as APIs que participam da corrida, com a revalidação condicional, o
+// limite de requisições, as novas tentativas, o tempo máximo por API, o
+// circuit breaker, a retirada por latência alta e a validação do estado
+// aplicados, e identifica a autoritativa (nil se desativada). A autoritativa que não estiver em
+// Providers também participa da corrida.
+func (c *Client) buildProviders() (providers []Provider, authoritative Provider) {
+	configured := c.Providers
+	if configured == nil {
+		registryMu.RLock()
+		for _, p := range registry {
+			configured = append(configured, p.factory(c))
+		}
+		registryMu.RUnlock()
+	}
+
+	found := false
+	for _, p := range configured {
+		wrapped := c.withStateCheck(c.withSlowCheck(c.withBreaker(c.withProviderTimeout(c.withRetries(c.withRateLimit(c.withRevalidation(p)))))))
+		if c.Authoritative != nil && p == c.Authoritative {
+			authoritative, found = wrapped, true
+		}
+		providers = append(providers, wrapped)
+	}
+	if c.Authoritative != nil && !found {
+		authoritative = c.withStateCheck(c.withSlowCheck(c.withBreaker(c.withProviderTimeout(c.withRetries(c.withRateLimit(c.withRevalidation(c.Authoritative)))))))
+		providers = append(providers, authoritative)
+	}
+	return providers, authoritative
+}
+
+// Aplica as novas tentativas em falhas temporárias, quando configuradas
+// para a API
+func (c *Client) withRetries(p Provider) Provider {
+	retries, ok := c.ProviderRetries[p.Name()]
+	if !ok {
+		retries = c.Retries
+	}
+	if retries <= 0 {
+		return p
+	}
+	backoff := c.RetryBackoff
+	if backoff <= 0 {
+		backoff = retryBackoff
+	}
+	return &retryProvider{Provider: p, retries: retries, backoff: backoff}
+}
+
+// API com tempo máximo próprio, menor que o da corrida
+type timeoutProvider struct {
+	Provider
+	timeout time.Duration
+}
+
+func (p *timeoutProvider) Fetch(ctx context.Context, cep string) (*Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	return p.Provider.Fetch(ctx, cep)
+}
+
+// Aplica o tempo máximo da API, quando configurado
+func (c *Client) withProviderTimeout(p Provider) Provider {
+	timeout, ok := c.ProviderTimeouts[p.Name()]
+	if !ok || timeout <= 0 {
+		return p
+	}
+	return &timeoutProvider{Provider: p, timeout: timeout}
+}
+
+// Brasil API (https://brasilapi.com.br)
+type brasilAPIProvider struct {
+	c *Client
+}
+
+func (p *brasilAPIProvider) Name() string {
+	return "Brasil API"
+}
+
+func (p *brasilAPIProvider) Fetch(ctx context.Context, cep string) (*Result, error) {
+	return p.c.fetchBrasilAPI(ctx, cep)
+}
+
+// ViaCEP (https://viacep.com.br)
+type viaCEPProvider struct {
+	c *Client
+}
+
+func (p *viaCEPProvider) Name() string {
+	return "ViaCEP"
+}
+
+func (p *viaCEPProvider) Fetch(ctx context.Context, cep string) (*Result, error) {
+	return p.c.fetchViaCEP(ctx, cep)
+}
+
+// OpenCEP (https://opencep.com)
+type openCEPProvider struct {
+	c *Client
+}
+
+func (p *openCEPProvider) Name() string {
+	return "OpenCEP"
+}
+
+func (p *openCEPProvider) Fetch(ctx context.Context, cep string) (*Result, error) {
+	return p.c.fetchOpenCEP(ctx, cep)
+}
+
+// ApiCEP (https://apicep.com)
+type apiCEPProvider struct {
+	c *Client
+}
+
+func (p *apiCEPProvider) Name() string {
+	return "ApiCEP"
+}
+
+func (p *apiCEPProvider) Fetch(ctx context.Context, cep string) (*Result, error) {
+	return p.c.fetchApiCEP(ctx, cep)
+}
+
+// Postmon (https://postmon.com.br)
+type postmonProvider struct {
+	c *Client
+}
+
+func (p *postmonProvider) Name() string {
+	return "Postmon"
+}
+
+func (p *postmonProvider) Fetch(ctx context.Context, cep string) (*Result, error) {
+	return p.c.fetchPostmon(ctx, cep)
+}
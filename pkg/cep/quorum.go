@@ -0,0 +1,83 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Erro de StrategyQuorum quando as APIs não concordam no endereço, exposto
+// por *QuorumError para errors.Is
+var ErrNoQuorum = errors.New("quórum não atingido")
+
+// Quórum padrão de StrategyQuorum: APIs que precisam concordar no endereço
+const defaultQuorum = 2
+
+// Falha de StrategyQuorum: as APIs responderam, mas menos de Quorum delas
+// concordam no logradouro, cidade e estado
+type QuorumError struct {
+	Quorum  int       // APIs que precisavam concordar
+	Results []*Result // Resultados recebidos, na ordem de chegada
+	Errs    []error   // Erro de cada API que falhou
+	Timeout bool      // O tempo limite foi atingido antes de todas as APIs responderem
+}
+
+func (e *QuorumError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Conflito: menos de %d APIs concordam no endereço", e.Quorum)
+	if e.Timeout {
+		b.WriteString(" (tempo limite atingido)")
+	}
+	for _, r := range e.Results {
+		b.WriteString("\n  - ")
+		b.WriteString(r.API + ": " + r.FormatAddress())
+	}
+	for _, err := range e.Errs {
+		b.WriteString("\n  - ")
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// Expõe ErrNoQuorum e os erros das APIs para errors.Is/errors.As
+func (e *QuorumError) Unwrap() []error {
+	return append([]error{ErrNoQuorum}, e.Errs...)
+}
+
+// Aceita um resultado apenas quando quorum APIs concordam no logradouro,
+// cidade e estado (ignorando caixa, acentos e espaços extras), escolhendo o
+// mais rápido entre os que concordam. Sem acordo, os resultados recebidos
+// compõem o relatório do conflito.
+type quorumSelector struct {
+	quorum int
+}
+
+func (s *quorumSelector) Select(ctx context.Context, pending int, chResultCEP <-chan *Result, chError <-chan error) (*Result, []*Result, []error) {
+	var received []*Result
+	var errs []error
+	agreeing := make(map[string][]*Result)
+	for ; pending > 0; pending-- {
+		select {
+		case result := <-chResultCEP:
+			received = append(received, result)
+			key := quorumKey(result)
+			agreeing[key] = append(agreeing[key], result)
+			if len(agreeing[key]) >= s.quorum {
+				return agreeing[key][0], received, errs
+			}
+
+		case err := <-chError:
+			errs = append(errs, err)
+
+		case <-ctx.Done():
+			return nil, received, errs
+		}
+	}
+	return nil, received, errs
+}
+
+// Endereço normalizado comparado entre as APIs no quórum
+func quorumKey(r *Result) string {
+	return foldName(r.Logradouro) + "|" + foldName(r.Cidade) + "|" + foldName(r.Estado)
+}
@@ -0,0 +1,471 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Erro de Race.Authoritative quando não há resposta autoritativa a aguardar:
+// API autoritativa desativada ou resultado obtido sem consultar as APIs
+var ErrNoAuthoritative = errors.New("nenhuma API autoritativa na corrida")
+
+// Corrida entre as APIs de uma consulta, retornada por Client.Race: cada API
+// roda em sua própria goroutine e envia o resultado ou o erro pelos canais.
+// Além do resultado escolhido, permite aguardar as APIs que ainda não
+// responderam. Deve ser encerrada com Close.
+type Race struct {
+	Result *Result // Resultado escolhido pela política de seleção
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// Canais de comunição entre as goroutines
+	chResultCEP chan *Result
+	chError     chan error
+
+	// Resultado da API autoritativa, entregue à parte do escolhido (nil se desativado)
+	chAuthoritative chan authoritativeOutcome
+
+	pending  int       // Respostas ainda não consumidas dos canais
+	rejected []*Result // Resultados consumidos pela seleção e não escolhidos, ainda não entregues em Remaining
+
+	// Registro do desfecho de cada API (Client.Logger e Client.OnOutcome)
+	logger    Logger
+	onOutcome func(Outcome)
+	logCEP    string         // CEP exibido no log, mascarado com Client.MaskCEP
+	decided   chan struct{}  // Fechado quando a política de seleção escolhe (ou não) um resultado
+	logs      sync.WaitGroup // Desfechos ainda não registrados
+	traced    bool           // Client.Tracer configurado: as requisições geram spans
+	maskCEP   bool           // Client.MaskCEP: o CEP é mascarado nos erros das requisições e nos desfechos
+
+	// Disparo escalonado das APIs (Client.HedgeDelay)
+	failed   chan struct{}  // Sinaliza a falha de uma API, antecipando o disparo da próxima
+	launcher sync.WaitGroup // Goroutine que dispara as APIs escalonadas
+}
+
+// Erro enviado em nome das APIs que não chegaram a ser disparadas no modo
+// escalonado, para que a contagem de respostas pendentes feche. Não é
+// entregue em Remaining.
+var errNotLaunched = errors.New("API não consultada: resultado escolhido antes do disparo")
+
+// Resposta da API autoritativa: o resultado ou o erro retornado
+type authoritativeOutcome struct {
+	result *Result
+	err    error
+}
+
+// Estratégia de disparo das APIs em Client.Race e Client.Lookup
+type Strategy int
+
+const (
+	// Dispara todas as APIs juntas (ou escalonadas, com Client.HedgeDelay) e
+	// fica com a resposta escolhida pela política de seleção
+	StrategyRace Strategy = iota
+
+	// Consulta uma API por vez, na ordem de Client.Providers, passando à
+	// seguinte apenas em erro ou timeout (ver Client.ProviderTimeouts). O
+	// resultado é sempre o da API de maior prioridade que responder.
+	StrategyFallback
+
+	// Dispara todas as APIs juntas e aceita o resultado apenas quando
+	// Client.Quorum delas concordam no logradouro, cidade e estado; sem
+	// acordo, a consulta falha com *QuorumError
+	StrategyQuorum
+)
+
+// Disparo das APIs conforme a estratégia: escalonado, com o intervalo entre
+// os disparos (0 aguarda a falha da anterior), ou todas juntas
+func (c *Client) staggering() (staggered bool, delay time.Duration) {
+	switch {
+	case c.Strategy == StrategyFallback:
+		return true, 0
+	case c.HedgeDelay > 0:
+		return true, c.HedgeDelay
+	}
+	return false, 0
+}
+
+// Dispara uma goroutine por API participante: todas de uma vez ou
+// escalonadas (ver Client.HedgeDelay e StrategyFallback). O resultado da API
+// autoritativa (se não for nil) também é entregue à parte em chAuthoritative.
+func (c *Client) startRace(ctx context.Context, cancel context.CancelFunc, cep string, providers []Provider, authoritative Provider, staggered bool, delay time.Duration) *Race {
+	logCEP := c.logCEP(cep)
+	r := &Race{
+		ctx:         ctx,
+		cancel:      cancel,
+		chResultCEP: make(chan *Result, len(providers)),
+		chError:     make(chan error, len(providers)),
+		pending:     len(providers),
+		logger:      c.Logger,
+		onOutcome:   c.OnOutcome,
+		logCEP:      logCEP,
+		traced:      c.Tracer != nil,
+		maskCEP:     c.MaskCEP,
+		decided:     make(chan struct{}),
+	}
+	if authoritative != nil {
+		r.chAuthoritative = make(chan authoritativeOutcome, 1)
+	}
+
+	if !staggered || len(providers) < 2 {
+		for _, p := range providers {
+			r.launch(p, p == authoritative, cep)
+		}
+		return r
+	}
+
+	// A autoritativa é aguardada à parte e é disparada de imediato
+	var rest []Provider
+	for _, p := range providers {
+		if p == authoritative {
+			r.launch(p, true, cep)
+			continue
+		}
+		rest = append(rest, p)
+	}
+	r.failed = make(chan struct{}, len(providers))
+	r.launcher.Add(1)
+	go r.launchStaggered(rest, cep, delay)
+	return r
+}
+
+// Dispara a goroutine de busca de uma API
+func (r *Race) launch(p Provider, authoritative bool, cep string) {
+	if r.reportsOutcomes() {
+		r.logs.Add(1)
+	}
+	go r.fetch(p, authoritative, cep)
+}
+
+// Dispara as APIs na ordem informada, a primeira de imediato e cada uma das
+// seguintes após o intervalo sem que um resultado seja escolhido, ou assim
+// que uma das já disparadas falhar (delay 0 aguarda apenas a falha).
+// Escolhido o resultado (ou encerrada a corrida), as APIs restantes não são
+// consultadas.
+func (r *Race) launchStaggered(providers []Provider, cep string, delay time.Duration) {
+	defer r.launcher.Done()
+
+	launched := 0
+	defer func() {
+		for range providers[launched:] {
+			r.chError <- errNotLaunched
+		}
+	}()
+
+	for i, p := range providers {
+		if i > 0 {
+			var elapsed <-chan time.Time
+			if delay > 0 {
+				elapsed = time.After(delay)
+			}
+			select {
+			case <-elapsed:
+			case <-r.failed:
+			case <-r.decided:
+				return
+			case <-r.ctx.Done():
+				return
+			}
+			if r.ctx.Err() != nil {
+				return
+			}
+		}
+		r.launch(p, false, cep)
+		launched++
+	}
+}
+
+// Executa a busca de uma API e envia a resposta para a corrida
+func (r *Race) fetch(p Provider, authoritative bool, cep string) {
+	ctx := r.ctx
+	var trace *fetchTrace
+	if r.tracesFetches() {
+		trace = &fetchTrace{cep: cep, logCEP: r.logCEP}
+		ctx = withFetchTrace(ctx, trace)
+	}
+
+	start := time.Now()
+	result, err := p.Fetch(ctx, cep)
+	end := time.Now()
+	if result != nil {
+		result.StartedAt, result.FinishedAt = start, end
+	}
+	if r.reportsOutcomes() {
+		go r.reportOutcome(p, trace, end.Sub(start), result, err)
+	}
+	if authoritative {
+		r.chAuthoritative <- authoritativeOutcome{result: result, err: err}
+	}
+	if err != nil {
+		r.chError <- err
+		if r.failed != nil {
+			r.failed <- struct{}{}
+		}
+		return
+	}
+
+	select {
+	case r.chResultCEP <- result:
+		// Resultado enviado com sucesso
+	case <-r.ctx.Done():
+		// Contexto cancelado
+	}
+}
+
+// Busca o CEP disparando a consulta em todas as APIs simultaneamente e
+// aplica a política de seleção, o fallback por município e os complementos
+// configurados. Diferente de Lookup, as requisições das demais APIs
+// continuam em andamento até Close, para que o resultado delas possa ser
+// aguardado com Authoritative ou Remaining. Quando nenhuma API retorna o
+// CEP, o erro é um *LookupError (ou *QuorumError com StrategyQuorum) e a
+// corrida já está encerrada.
+func (c *Client) Race(ctx context.Context, cep string) (*Race, error) {
+	normalized, err := Normalize(cep)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, span := c.startSpan(ctx, "cep.lookup", SpanInternal, slog.String("cep", c.logCEP(normalized)))
+	r, err := c.race(ctx, normalized)
+	var result *Result
+	if r != nil {
+		result = r.Result
+	}
+	c.endLookupSpan(span, normalized, result, err)
+	return r, err
+}
+
+// Corrida de Race, com o CEP já normalizado
+func (c *Client) race(ctx context.Context, normalized string) (*Race, error) {
+
+	// Resultado em cache dispensa as requisições, exceto na renovação
+	if c.Cache != nil && !cacheRefresh(ctx) {
+		result, ok, err := c.Cache.Get(ctx, normalized)
+		if err != nil {
+			c.logCacheError(ctx, err)
+		}
+		if ok {
+			return &Race{Result: result}, nil
+		}
+	}
+	if c.Cache != nil {
+		ctx = c.withStaleResult(ctx, normalized)
+	}
+
+	raceCtx, cancel := c.withVerifyTimeout(ctx)
+	providers, authoritative := c.buildProviders()
+	staggered, delay := c.staggering()
+	r := c.startRace(raceCtx, cancel, normalized, providers, authoritative, staggered, delay)
+
+	// A seleção termina no Timeout; com VerifyTimeout, as APIs restantes seguem após ela
+	ctx = raceCtx
+	if c.VerifyTimeout > 0 && c.Timeout > 0 {
+		var cancelSelect context.CancelFunc
+		ctx, cancelSelect = context.WithTimeout(raceCtx, c.Timeout)
+		defer cancelSelect()
+	}
+
+	// Aguarda as respostas das APIs até a política de seleção escolher um resultado
+	selector := c.selector()
+	result, received, errs := selector.Select(ctx, r.pending, r.chResultCEP, r.chError)
+	r.Result = result
+	close(r.decided)
+
+	// As respostas consumidas pela seleção deixam de estar pendentes; as
+	// recusadas por ela (ex: incompletas) seguem para Remaining
+	r.pending -= len(received) + len(errs)
+	for _, other := range received {
+		if other != result {
+			r.rejected = append(r.rejected, other)
+		}
+	}
+	if result != nil {
+		c.enrich(result, normalized)
+		c.geocode(r.ctx, result)
+		c.enrichMunicipality(r.ctx, result)
+		if c.Cache != nil {
+			// Sem o prazo da corrida, possivelmente já no fim: o backend tem o próprio tempo máximo
+			if err := c.Cache.Set(context.WithoutCancel(ctx), normalized, result); err != nil {
+				c.logCacheError(ctx, err)
+			}
+		}
+		return r, nil
+	}
+
+	timeout := ctx.Err() != nil
+	r.Close()
+	// O quórum, sem acordo, reporta o conflito
+	if quorum, ok := selector.(*quorumSelector); ok && len(received) > 0 {
+		return nil, &QuorumError{Quorum: quorum.quorum, Results: received, Errs: errs, Timeout: timeout}
+	}
+
+	// Nenhuma API respondeu (e nenhuma informou que o CEP não existe):
+	// resultado aproximado da base offline
+	if c.OfflineFallback && !anyNotFound(errs) {
+		if result, ok := c.offlineDB().lookup(normalized); ok {
+			c.enrich(result, normalized)
+			return &Race{Result: result}, nil
+		}
+	}
+	if timeout {
+		return nil, &LookupError{Timeout: true, Errs: errs}
+	}
+
+	// Todas falharam: se nenhuma encontrou o CEP, tenta o fallback por município
+	if c.MunicipalityFallback && len(errs) == len(providers) && allNotFound(errs) {
+		if result, ok := lookupMunicipality(normalized); ok {
+			c.enrich(result, normalized)
+			return &Race{Result: result}, nil
+		}
+	}
+	return nil, &LookupError{Errs: errs}
+}
+
+// Contexto da corrida, limitado por Client.Timeout quando configurado e
+// cancelado por Race.Close
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.Timeout > 0 {
+		return context.WithTimeout(ctx, c.Timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// Contexto da corrida em Client.Race: o de withTimeout, estendido por
+// Client.VerifyTimeout para as APIs que ainda não responderam
+func (c *Client) withVerifyTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.VerifyTimeout > 0 && c.Timeout > 0 {
+		return context.WithTimeout(ctx, c.Timeout+c.VerifyTimeout)
+	}
+	return c.withTimeout(ctx)
+}
+
+// Aguarda a resposta da API autoritativa (Client.Authoritative), limitada
+// pelo prazo da corrida. Retorna o erro do contexto se o prazo terminar
+// antes e ErrNoAuthoritative se não houver API autoritativa na corrida.
+func (r *Race) Authoritative() (*Result, error) {
+	if r.chAuthoritative == nil {
+		return nil, ErrNoAuthoritative
+	}
+	select {
+	case outcome := <-r.chAuthoritative:
+		return outcome.result, outcome.err
+	case <-r.ctx.Done():
+		return nil, r.ctx.Err()
+	}
+}
+
+// Percorre as respostas das APIs além do resultado escolhido, na ordem de
+// chegada: primeiro as que a seleção recebeu e não escolheu (ex: os
+// resultados incompletos de RetryOnEmptyFields e PreferComplete) e depois as
+// que ainda não responderam, até o fim do prazo da corrida ou até todas
+// responderem. Cada item traz o resultado ou o erro de uma API. Os erros já
+// recebidos pela seleção não são entregues.
+func (r *Race) Remaining() iter.Seq2[*Result, error] {
+	return func(yield func(*Result, error) bool) {
+		if r.ctx == nil {
+			return
+		}
+		for len(r.rejected) > 0 {
+			other := r.rejected[0]
+			r.rejected = r.rejected[1:]
+			if !yield(other, nil) {
+				return
+			}
+		}
+		for ; r.pending > 0; r.pending-- {
+			select {
+			case other := <-r.chResultCEP:
+				if !yield(other, nil) {
+					r.pending--
+					return
+				}
+			case err := <-r.chError:
+				if errors.Is(err, errNotLaunched) {
+					continue
+				}
+				if !yield(nil, err) {
+					r.pending--
+					return
+				}
+			case <-r.ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// Cancela as requisições ainda em andamento e aguarda o registro do
+// desfecho de cada API no Logger e em OnOutcome. Pode ser chamada mais de
+// uma vez.
+func (r *Race) Close() {
+	if r == nil || r.cancel == nil {
+		return
+	}
+	r.cancel()
+	r.launcher.Wait()
+	r.logs.Wait()
+}
+
+// Resultados de todas as APIs de uma consulta, retornados por Client.LookupAll
+type AllResults struct {
+	Results []*Result // Resultados na ordem de chegada: o primeiro é o mais rápido
+	Errs    []error   // Erro de cada API que falhou
+	Timeout bool      // O tempo limite foi atingido antes de todas as APIs responderem
+}
+
+// Aguarda a resposta de todas as APIs (dentro do prazo), em vez da corrida,
+// permitindo comparar os resultados (ver Diff). O cache e a API autoritativa
+// não são usados. Quando nenhuma API retorna o CEP, o erro é um *LookupError.
+func (c *Client) LookupAll(ctx context.Context, cep string) (*AllResults, error) {
+	normalized, err := Normalize(cep)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, span := c.startSpan(ctx, "cep.lookup_all", SpanInternal, slog.String("cep", c.logCEP(normalized)))
+	all, err := c.lookupAll(ctx, normalized)
+	var fastest *Result
+	if all != nil {
+		fastest = all.Results[0]
+	}
+	c.endLookupSpan(span, normalized, fastest, err)
+	return all, err
+}
+
+// Consulta de LookupAll, com o CEP já normalizado
+func (c *Client) lookupAll(ctx context.Context, normalized string) (*AllResults, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	providers, _ := c.buildProviders()
+	r := c.startRace(ctx, cancel, normalized, providers, nil, false, 0)
+	defer r.Close()
+
+	all := &AllResults{}
+	for ; r.pending > 0 && !all.Timeout; r.pending-- {
+		select {
+		case result := <-r.chResultCEP:
+			all.Results = append(all.Results, result)
+		case err := <-r.chError:
+			all.Errs = append(all.Errs, err)
+		case <-r.ctx.Done():
+			all.Timeout = true
+		}
+	}
+
+	// O primeiro resultado (o mais rápido) é a referência da comparação
+	if len(all.Results) > 0 {
+		r.Result = all.Results[0]
+	}
+	close(r.decided)
+
+	if len(all.Results) == 0 {
+		return nil, &LookupError{Timeout: all.Timeout, Errs: all.Errs}
+	}
+	for _, result := range all.Results {
+		c.enrich(result, normalized)
+	}
+	return all, nil
+}
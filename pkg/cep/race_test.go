@@ -0,0 +1,569 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Resposta completa do ViaCEP para 01001-000
+var viaCEPPracaDaSe = ViaCEPResponse{CEP: "01001-000", Logradouro: "Praça da Sé", Bairro: "Sé", Localidade: "São Paulo", UF: "SP"}
+
+// Resposta do ViaCEP sem logradouro e bairro (CEP geral do município)
+var viaCEPThin = ViaCEPResponse{CEP: "01001-000", Localidade: "São Paulo", UF: "SP"}
+
+// Stub de uma API que responde o corpo em JSON após o atraso informado (ou
+// no cancelamento da requisição)
+func newJSONStub(t *testing.T, delay time.Duration, status int, body any) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// API no formato do ViaCEP, consultada na URL do stub, com nome próprio
+type stubProvider struct {
+	name string
+	c    *Client
+}
+
+func (p *stubProvider) Name() string {
+	return p.name
+}
+
+func (p *stubProvider) Fetch(ctx context.Context, cep string) (*Result, error) {
+	result, err := p.c.fetchViaCEP(ctx, cep)
+	if result != nil {
+		result.API = p.name
+	}
+	return result, err
+}
+
+// Cria uma API por stub, nomeadas na ordem informada
+func stubProviders(names []string, servers ...*httptest.Server) []Provider {
+	providers := make([]Provider, len(servers))
+	for i, srv := range servers {
+		c := &Client{URLs: map[string]string{"viacep": srv.URL + "/%s"}}
+		providers[i] = &stubProvider{name: names[i], c: c}
+	}
+	return providers
+}
+
+func TestRaceRemainingAfterSelection(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*Client)
+		winner    string
+		remaining []string
+	}{
+		{
+			name:      "mais rápida",
+			configure: func(c *Client) {},
+			winner:    "Thin",
+			remaining: []string{"Full"},
+		},
+		{
+			name:      "RetryOnEmptyFields",
+			configure: func(c *Client) { c.RetryOnEmptyFields = true },
+			winner:    "Full",
+			remaining: []string{"Thin"},
+		},
+		{
+			name:      "PreferComplete",
+			configure: func(c *Client) { c.PreferComplete = 200 * time.Millisecond },
+			winner:    "Full",
+			remaining: []string{"Thin"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thin := newJSONStub(t, 10*time.Millisecond, http.StatusOK, viaCEPThin)
+			full := newJSONStub(t, 30*time.Millisecond, http.StatusOK, viaCEPPracaDaSe)
+
+			c := &Client{
+				Providers:     stubProviders([]string{"Thin", "Full"}, thin, full),
+				Timeout:       300 * time.Millisecond,
+				VerifyTimeout: 2 * time.Second,
+			}
+			tt.configure(c)
+
+			r, err := c.Race(context.Background(), "01001000")
+			if err != nil {
+				t.Fatalf("Race: %v", err)
+			}
+			defer r.Close()
+			if r.Result.API != tt.winner {
+				t.Errorf("vencedora = %s, esperada %s", r.Result.API, tt.winner)
+			}
+
+			// Todas as respostas já chegaram: Remaining não aguarda o VerifyTimeout
+			start := time.Now()
+			var remaining []string
+			for other, err := range r.Remaining() {
+				if err != nil {
+					t.Errorf("Remaining: erro inesperado: %v", err)
+					continue
+				}
+				remaining = append(remaining, other.API)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("Remaining levou %s, esperado retorno imediato", elapsed)
+			}
+			if len(remaining) != len(tt.remaining) || (len(remaining) > 0 && remaining[0] != tt.remaining[0]) {
+				t.Errorf("Remaining = %v, esperado %v", remaining, tt.remaining)
+			}
+		})
+	}
+}
+
+// Escolhido o vencedor, a requisição da API mais lenta é cancelada, e não
+// mantida até o fim do prazo
+func TestLoserRequestCancelled(t *testing.T) {
+	tests := []struct {
+		name   string
+		lookup func(c *Client) error
+	}{
+		{"Lookup", func(c *Client) error {
+			_, err := c.Lookup(context.Background(), "01001000")
+			return err
+		}},
+		{"Race e Close", func(c *Client) error {
+			r, err := c.Race(context.Background(), "01001000")
+			if err != nil {
+				return err
+			}
+			r.Close()
+			return nil
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cancelled := make(chan struct{})
+			slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+					close(cancelled)
+				case <-time.After(5 * time.Second):
+				}
+			}))
+			defer slow.Close()
+			// O atraso garante que a requisição da perdedora já chegou ao stub
+			fast := newJSONStub(t, 50*time.Millisecond, http.StatusOK, viaCEPPracaDaSe)
+
+			c := &Client{Providers: stubProviders([]string{"Fast", "Slow"}, fast, slow), Timeout: 10 * time.Second}
+			if err := tt.lookup(c); err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			select {
+			case <-cancelled:
+			case <-time.After(time.Second):
+				t.Fatal("a requisição da API perdedora não foi cancelada")
+			}
+		})
+	}
+}
+
+// LookupAll aguarda todas as APIs, inclusive as mais lentas, dentro do prazo
+func TestLookupAll(t *testing.T) {
+	tests := []struct {
+		name    string
+		delays  []time.Duration
+		results int
+		timeout bool
+	}{
+		{"todas respondem", []time.Duration{0, 50 * time.Millisecond}, 2, false},
+		{"uma excede o prazo", []time.Duration{0, time.Second}, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var servers []*httptest.Server
+			for _, delay := range tt.delays {
+				servers = append(servers, newJSONStub(t, delay, http.StatusOK, viaCEPPracaDaSe))
+			}
+			c := &Client{Providers: stubProviders([]string{"A", "B"}, servers...), Timeout: 200 * time.Millisecond}
+
+			all, err := c.LookupAll(context.Background(), "01001000")
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if len(all.Results) != tt.results || all.Timeout != tt.timeout {
+				t.Errorf("%d resultados (timeout %v), esperados %d (timeout %v)", len(all.Results), all.Timeout, tt.results, tt.timeout)
+			}
+			if all.Results[0].API != "A" {
+				t.Errorf("primeiro resultado de %s, esperado o da mais rápida (A)", all.Results[0].API)
+			}
+		})
+	}
+}
+
+// Stub que conta as requisições recebidas
+type countedStub struct {
+	*httptest.Server
+	requests atomic.Int32
+}
+
+func newCountedStub(t *testing.T, delay time.Duration, status int, body any) *countedStub {
+	t.Helper()
+	stub := &countedStub{}
+	inner := newJSONStub(t, delay, status, body)
+	stub.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stub.requests.Add(1)
+		inner.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+func TestStrategies(t *testing.T) {
+	brasilia := ViaCEPResponse{CEP: "01001-000", Logradouro: "Outra Rua", Bairro: "Sé", Localidade: "São Paulo", UF: "SP"}
+	type stub struct {
+		delay  time.Duration
+		status int
+		body   any
+	}
+	ok := func(delay time.Duration) stub { return stub{delay, http.StatusOK, viaCEPPracaDaSe} }
+	failing := stub{0, http.StatusInternalServerError, nil}
+	tests := []struct {
+		name      string
+		configure func(c *Client)
+		stubs     []stub // APIs A, B e C, nessa ordem de prioridade
+		winner    string
+		requests  []int32 // Requisições esperadas em cada stub, nil não verifica
+		quorumErr bool
+	}{
+		{
+			name:     "race: vence a mais rápida",
+			stubs:    []stub{ok(60 * time.Millisecond), ok(0), ok(30 * time.Millisecond)},
+			winner:   "B",
+			requests: []int32{1, 1, 1},
+		},
+		{
+			name:      "fallback: a primeira responde, as demais não são consultadas",
+			configure: func(c *Client) { c.Strategy = StrategyFallback },
+			stubs:     []stub{ok(30 * time.Millisecond), ok(0), ok(0)},
+			winner:    "A",
+			requests:  []int32{1, 0, 0},
+		},
+		{
+			name:      "fallback: passa à seguinte no erro",
+			configure: func(c *Client) { c.Strategy = StrategyFallback },
+			stubs:     []stub{failing, ok(30 * time.Millisecond), ok(0)},
+			winner:    "B",
+			requests:  []int32{1, 1, 0},
+		},
+		{
+			name: "fallback: passa à seguinte no timeout da API",
+			configure: func(c *Client) {
+				c.Strategy = StrategyFallback
+				c.ProviderTimeouts = map[string]time.Duration{"A": 50 * time.Millisecond}
+			},
+			stubs:    []stub{ok(time.Second), ok(20 * time.Millisecond), ok(0)},
+			winner:   "B",
+			requests: []int32{1, 1, 0},
+		},
+		{
+			name: "hedge: a seguinte só após o intervalo",
+			configure: func(c *Client) {
+				c.HedgeDelay = 100 * time.Millisecond
+			},
+			stubs:    []stub{ok(20 * time.Millisecond), ok(0), ok(0)},
+			winner:   "A",
+			requests: []int32{1, 0, 0},
+		},
+		{
+			name:      "quorum: duas concordam",
+			configure: func(c *Client) { c.Strategy = StrategyQuorum },
+			stubs:     []stub{{0, http.StatusOK, brasilia}, ok(20 * time.Millisecond), ok(40 * time.Millisecond)},
+			winner:    "B",
+		},
+		{
+			name:      "quorum: sem acordo",
+			configure: func(c *Client) { c.Strategy = StrategyQuorum },
+			stubs:     []stub{{0, http.StatusOK, brasilia}, ok(0), failing},
+			quorumErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stubs []*countedStub
+			var servers []*httptest.Server
+			for _, s := range tt.stubs {
+				stub := newCountedStub(t, s.delay, s.status, s.body)
+				stubs = append(stubs, stub)
+				servers = append(servers, stub.Server)
+			}
+			c := &Client{Providers: stubProviders([]string{"A", "B", "C"}, servers...), Timeout: 500 * time.Millisecond}
+			if tt.configure != nil {
+				tt.configure(c)
+			}
+
+			result, err := c.Lookup(context.Background(), "01001000")
+			if tt.quorumErr {
+				var quorumErr *QuorumError
+				if !errors.As(err, &quorumErr) || !errors.Is(err, ErrNoQuorum) {
+					t.Fatalf("erro = %v, esperado *QuorumError", err)
+				}
+				if len(quorumErr.Results) != 2 || len(quorumErr.Errs) != 1 {
+					t.Errorf("conflito com %d resultados e %d erros, esperados 2 e 1", len(quorumErr.Results), len(quorumErr.Errs))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if result.API != tt.winner {
+				t.Errorf("vencedora = %s, esperada %s", result.API, tt.winner)
+			}
+
+			// As requisições canceladas podem chegar aos stubs após a escolha
+			time.Sleep(20 * time.Millisecond)
+			for i, want := range tt.requests {
+				if got := stubs[i].requests.Load(); got != want {
+					t.Errorf("API %c: %d requisições, esperadas %d", 'A'+i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSelectors(t *testing.T) {
+	full := &Result{API: "Full", Logradouro: "Praça da Sé", Bairro: "Sé", Cidade: "São Paulo", Estado: "SP", CEP: "01001-000"}
+	thin := &Result{API: "Thin", Cidade: "São Paulo", Estado: "SP", CEP: "01001-000"}
+	other := &Result{API: "Other", Logradouro: "Outra Rua", Bairro: "Sé", Cidade: "São Paulo", Estado: "SP", CEP: "01001-000"}
+	fullCopy := *full
+	fullCopy.API = "FullCopy"
+	failure := errors.New("falha")
+
+	tests := []struct {
+		name     string
+		selector Selector
+		arrivals []any // Respostas na ordem de chegada: *Result ou error
+		winner   string
+		received int
+		errs     int
+	}{
+		{"mais rápida", &fastestSelector{}, []any{failure, thin, full}, "Thin", 1, 1},
+		{"mais rápida, todas falham", &fastestSelector{}, []any{failure, failure}, "", 0, 2},
+		{"RetryOnEmptyFields prefere o completo", &fastestSelector{retryOnEmptyFields: true}, []any{thin, full}, "Full", 2, 0},
+		{"RetryOnEmptyFields sem alternativa", &fastestSelector{retryOnEmptyFields: true}, []any{thin, failure}, "Thin", 1, 1},
+		{"PreferComplete fica com o mais completo", &completeSelector{window: time.Second}, []any{thin, full}, "Full", 2, 0},
+		{"PreferComplete com o primeiro completo", &completeSelector{window: time.Second}, []any{full, thin}, "Full", 1, 0},
+		{"quórum de 2", &quorumSelector{quorum: 2}, []any{other, full, &fullCopy}, "Full", 3, 0},
+		{"quórum sem acordo", &quorumSelector{quorum: 2}, []any{other, full, failure}, "", 2, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chResult := make(chan *Result, len(tt.arrivals))
+			chError := make(chan error, len(tt.arrivals))
+			go func() {
+				for _, a := range tt.arrivals {
+					if err, ok := a.(error); ok {
+						chError <- err
+					} else {
+						chResult <- a.(*Result)
+					}
+					time.Sleep(5 * time.Millisecond)
+				}
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			result, received, errs := tt.selector.Select(ctx, len(tt.arrivals), chResult, chError)
+			winner := ""
+			if result != nil {
+				winner = result.API
+			}
+			if winner != tt.winner || len(received) != tt.received || len(errs) != tt.errs {
+				t.Errorf("vencedor %q, %d recebidos e %d erros; esperados %q, %d e %d", winner, len(received), len(errs), tt.winner, tt.received, tt.errs)
+			}
+		})
+	}
+}
+
+// Sem respostas até o fim do prazo, a seleção retorna o melhor já recebido
+func TestSelectorsTimeout(t *testing.T) {
+	thin := &Result{API: "Thin", Cidade: "São Paulo", Estado: "SP"}
+	for _, selector := range []Selector{&fastestSelector{retryOnEmptyFields: true}, &completeSelector{window: time.Hour}} {
+		chResult := make(chan *Result, 1)
+		chResult <- thin
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		result, received, _ := selector.Select(ctx, 2, chResult, make(chan error))
+		cancel()
+		if result != thin || len(received) != 1 {
+			t.Errorf("%T: resultado %v com %d recebidos, esperado o incompleto", selector, result, len(received))
+		}
+	}
+}
+
+// Com VerifyTimeout, Remaining entrega as respostas que chegam após o
+// Timeout da seleção, dentro do prazo adicional
+func TestRaceRemainingVerifyTimeout(t *testing.T) {
+	divergent := ViaCEPResponse{CEP: "01001-000", Logradouro: "Outra Rua", Bairro: "Sé", Localidade: "São Paulo", UF: "SP"}
+	tests := []struct {
+		name          string
+		slowDelay     time.Duration
+		verifyTimeout time.Duration
+		remaining     []string
+		timeout       bool // Remaining termina no fim do prazo, sem a resposta da lenta
+	}{
+		{"divergente dentro do prazo", 150 * time.Millisecond, time.Second, []string{"Slow"}, false},
+		{"lenta além do prazo", time.Second, 100 * time.Millisecond, nil, true},
+		{"sem VerifyTimeout", 150 * time.Millisecond, 0, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fast := newJSONStub(t, 0, http.StatusOK, viaCEPPracaDaSe)
+			slow := newJSONStub(t, tt.slowDelay, http.StatusOK, divergent)
+			failing := newJSONStub(t, 0, http.StatusInternalServerError, nil)
+			c := &Client{
+				Providers:     stubProviders([]string{"Fast", "Slow", "Failing"}, fast, slow, failing),
+				Timeout:       100 * time.Millisecond,
+				VerifyTimeout: tt.verifyTimeout,
+			}
+
+			r, err := c.Race(context.Background(), "01001000")
+			if err != nil {
+				t.Fatalf("Race: %v", err)
+			}
+			defer r.Close()
+			if r.Result.API != "Fast" {
+				t.Fatalf("vencedora = %s, esperada Fast", r.Result.API)
+			}
+
+			var remaining []string
+			errs := 0
+			for other, err := range r.Remaining() {
+				if err != nil {
+					errs++
+					continue
+				}
+				remaining = append(remaining, other.API)
+				if diffs := Diff(r.Result, other); len(diffs) != 1 {
+					t.Errorf("Diff = %v, esperada a divergência no logradouro", diffs)
+				}
+			}
+			// O erro da Failing pode chegar antes ou depois da seleção
+			if len(remaining) != len(tt.remaining) || errs > 1 {
+				t.Errorf("Remaining = %v com %d erros, esperado %v", remaining, errs, tt.remaining)
+			}
+			if tt.timeout && r.ctx.Err() == nil {
+				t.Error("Remaining terminou antes do fim do prazo da corrida")
+			}
+		})
+	}
+}
+
+// A API com a latência média acima de SlowThreshold sai da corrida por
+// SlowCooldown, sem receber requisições, e volta com a média zerada; a
+// última API na corrida nunca é retirada
+func TestSlowThreshold(t *testing.T) {
+	fast := newCountedStub(t, 0, http.StatusOK, viaCEPPracaDaSe)
+	slow := newCountedStub(t, 30*time.Millisecond, http.StatusOK, viaCEPPracaDaSe)
+	logger := &recordingLogger{}
+	c := &Client{Providers: stubProviders([]string{"A", "B"}, fast.Server, slow.Server), Timeout: time.Second,
+		SlowThreshold: 10 * time.Millisecond, SlowCooldown: 100 * time.Millisecond, Logger: logger}
+	ctx := context.Background()
+
+	for range slowMinSamples {
+		if _, err := c.LookupAll(ctx, "01001000"); err != nil {
+			t.Fatalf("LookupAll: %v", err)
+		}
+	}
+	latencies := c.ProviderLatencies()
+	if len(latencies) != 2 || latencies[0].Deselected || !latencies[1].Deselected {
+		t.Fatalf("latências = %+v, esperada apenas B fora da corrida", latencies)
+	}
+	if latencies[1].Average < 25*time.Millisecond || latencies[1].Samples != slowMinSamples {
+		t.Errorf("média de B = %v em %d medições, esperada perto de 30ms em %d", latencies[1].Average, latencies[1].Samples, slowMinSamples)
+	}
+
+	requests := slow.requests.Load()
+	all, err := c.LookupAll(ctx, "01001000")
+	if err != nil || len(all.Results) != 1 || !errors.Is(all.Errs[0], ErrSlowProvider) {
+		t.Fatalf("LookupAll com B fora = %+v, %v, esperado o erro ErrSlowProvider de B", all, err)
+	}
+	if slow.requests.Load() != requests {
+		t.Error("API fora da corrida recebeu requisição")
+	}
+
+	time.Sleep(c.SlowCooldown)
+	if _, err := c.LookupAll(ctx, "01001000"); err != nil {
+		t.Fatalf("LookupAll após o cooldown: %v", err)
+	}
+	if slow.requests.Load() != requests+1 {
+		t.Error("API não voltou à corrida após o cooldown")
+	}
+	if latencies := c.ProviderLatencies(); latencies[1].Deselected || latencies[1].Samples != 1 {
+		t.Errorf("B após o cooldown = %+v, esperada na corrida com a média recomeçada", latencies[1])
+	}
+	logger.mu.Lock()
+	entries := strings.Join(logger.entries, "\n")
+	logger.mu.Unlock()
+	if !strings.Contains(entries, "WARN latência apiBacaoretirada da corrida") || !strings.Contains(entries, "INFO latência apiBacaode volta à corrida") {
+		t.Errorf("log sem a retirada e o retorno de B:\n%s", entries)
+	}
+
+	// Com todas acima do limite, uma segue na corrida
+	c = &Client{Providers: stubProviders([]string{"A", "B"}, slow.Server, slow.Server), Timeout: time.Second, SlowThreshold: time.Millisecond}
+	for range slowMinSamples + 1 {
+		c.LookupAll(ctx, "01001000")
+	}
+	deselected := 0
+	for _, l := range c.ProviderLatencies() {
+		if l.Deselected {
+			deselected++
+		}
+	}
+	if deselected != 1 {
+		t.Errorf("%d APIs fora da corrida, esperada 1 com a outra mantida", deselected)
+	}
+}
+
+func TestLatencyEMA(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name    string
+		alpha   float64
+		elapsed []time.Duration
+		errs    []error // Erro de cada medição, nil se a API respondeu
+		want    time.Duration
+	}{
+		{"primeira medição", 0.2, []time.Duration{100 * ms}, nil, 100 * ms},
+		{"peso 0.5", 0.5, []time.Duration{100 * ms, 200 * ms}, nil, 150 * ms},
+		{"peso 1 usa a última", 1, []time.Duration{100 * ms, 200 * ms, 40 * ms}, nil, 40 * ms},
+		{"cancelada abaixo da média", 0.5, []time.Duration{100 * ms, 20 * ms}, []error{nil, context.Canceled}, 100 * ms},
+		{"cancelada acima da média", 0.5, []time.Duration{100 * ms, 300 * ms}, []error{nil, context.Canceled}, 200 * ms},
+		{"limite do Client", 0.5, []time.Duration{100 * ms, time.Second}, []error{nil, ErrRateLimited}, 100 * ms},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var g latencyGroup
+			var average time.Duration
+			for i, elapsed := range tt.elapsed {
+				var err error
+				if tt.errs != nil {
+					err = tt.errs[i]
+				}
+				average, _ = g.observe("A", elapsed, err, time.Hour, tt.alpha)
+			}
+			if average != tt.want {
+				t.Errorf("média = %v, esperada %v", average, tt.want)
+			}
+		})
+	}
+}
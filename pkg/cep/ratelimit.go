@@ -0,0 +1,138 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Erro retornado quando o limite de requisições da API se esgota (ver
+// Client.RateLimits), sem que a requisição seja enviada
+var ErrRateLimited = errors.New("limite de requisições atingido")
+
+// Limite de requisições de uma API, como um token bucket: a API aceita
+// rajadas de até Burst requisições e, em seguida, PerSecond por segundo
+type RateLimit struct {
+	PerSecond float64 // Requisições por segundo
+	Burst     int     // Requisições em rajada, 0 usa 1
+	FailFast  bool    // Falha na hora quando o limite se esgota, em vez de aguardar a vez (dentro do prazo)
+}
+
+// Token buckets das APIs de um Client, por nome da API, compartilhados entre
+// as consultas
+type limiterGroup struct {
+	mu       sync.Mutex
+	limiters map[string]*limiter
+}
+
+// Retorna o token bucket da API, criando-o cheio na primeira consulta
+func (g *limiterGroup) get(name string, limit RateLimit) *limiter {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.limiters == nil {
+		g.limiters = make(map[string]*limiter)
+	}
+	l, ok := g.limiters[name]
+	if !ok {
+		burst := float64(max(limit.Burst, 1))
+		l = &limiter{rate: limit.PerSecond, burst: burst, tokens: burst, last: time.Now()}
+		g.limiters[name] = l
+	}
+	return l
+}
+
+// Token bucket de uma API
+type limiter struct {
+	rate  float64 // Tokens repostos por segundo
+	burst float64 // Capacidade do bucket
+
+	mu     sync.Mutex
+	tokens float64 // Tokens disponíveis; negativo quando há requisições aguardando
+	last   time.Time
+}
+
+// Repõe os tokens proporcionalmente ao tempo decorrido. Deve ser chamada com
+// mu travado.
+func (l *limiter) refill() {
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+}
+
+// Reserva um token e retorna a espera até ele estar disponível. Com failFast,
+// não reserva se não houver token disponível agora.
+func (l *limiter) reserve(failFast bool) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill()
+	if failFast && l.tokens < 1 {
+		return 0, false
+	}
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0, true
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second)), true
+}
+
+// Devolve um token reservado que não chegou a ser usado
+func (l *limiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = min(l.burst, l.tokens+1)
+}
+
+// API com limite de requisições: cada requisição, inclusive as novas
+// tentativas, aguarda um token do bucket
+type rateLimitedProvider struct {
+	Provider
+	limiter  *limiter
+	failFast bool
+}
+
+func (p *rateLimitedProvider) Fetch(ctx context.Context, cep string) (*Result, error) {
+	if err := p.limiter.wait(ctx, p.Name(), p.failFast); err != nil {
+		return nil, err
+	}
+	return p.Provider.Fetch(ctx, cep)
+}
+
+// Aguarda a vez da requisição à API no bucket, dentro do prazo do contexto
+func (l *limiter) wait(ctx context.Context, api string, failFast bool) error {
+	wait, ok := l.reserve(failFast)
+	if !ok {
+		return fmt.Errorf("%s: %w", api, ErrRateLimited)
+	}
+	if wait == 0 {
+		return nil
+	}
+
+	// Aguardar além do prazo seria inútil: desiste e devolve o token
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+		l.release()
+		return fmt.Errorf("%s: %w (a vez chegaria após o prazo)", api, ErrRateLimited)
+	}
+
+	timer := time.NewTimer(wait)
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		timer.Stop()
+		l.release()
+		return fmt.Errorf("%s: %w", api, ctx.Err())
+	}
+}
+
+// Aplica o limite de requisições da API, quando configurado
+func (c *Client) withRateLimit(p Provider) Provider {
+	limit, ok := c.RateLimits[p.Name()]
+	if !ok || limit.PerSecond <= 0 {
+		return p
+	}
+	return &rateLimitedProvider{Provider: p, limiter: c.limiters.get(p.Name(), limit), failFast: limit.FailFast}
+}
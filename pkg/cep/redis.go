@@ -0,0 +1,409 @@
+package cep
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Parâmetros das conexões com o Redis
+const (
+	redisDefaultPort = "6379"
+	redisTimeout     = 500 * time.Millisecond // Tempo máximo de cada comando, inclusive a conexão
+	redisMaxIdle     = 16                     // Conexões ociosas mantidas para reuso
+)
+
+// Cache dos resultados em um servidor Redis, compartilhado entre as
+// instâncias que usam o mesmo namespace. Cada resultado é gravado em JSON na
+// chave "<namespace>:<cep>" e expira no próprio Redis após o TTL. Fala o
+// protocolo RESP diretamente, sem dependências, e reaproveita as conexões.
+// Seguro para uso concorrente.
+type RedisCache struct {
+	addr      string
+	tls       *tls.Config // Conexão TLS (rediss://), nil usa TCP simples
+	username  string
+	password  string
+	db        int
+	namespace string
+	ttl       time.Duration
+
+	mu     sync.Mutex
+	idle   []*redisConn
+	closed bool
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// Cria o cache no Redis da URL informada, no formato
+// redis://[usuário:senha@]host[:porta][/db] (rediss:// usa TLS). As chaves
+// recebem o prefixo namespace (vazio não usa prefixo) e cada resultado vale
+// pelo ttl (0 não expira). A conexão é estabelecida na primeira consulta.
+func NewRedisCache(rawURL, namespace string, ttl time.Duration) (*RedisCache, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") || u.Hostname() == "" {
+		return nil, fmt.Errorf("redis: URL inválida: %q (use redis://host:porta/db)", rawURL)
+	}
+	r := &RedisCache{namespace: namespace, ttl: ttl}
+
+	port := u.Port()
+	if port == "" {
+		port = redisDefaultPort
+	}
+	r.addr = net.JoinHostPort(u.Hostname(), port)
+	if u.Scheme == "rediss" {
+		r.tls = &tls.Config{ServerName: u.Hostname()}
+	}
+	if u.User != nil {
+		r.username = u.User.Username()
+		r.password, _ = u.User.Password()
+		// Apenas a senha (redis://:senha@host) autentica o usuário padrão
+		if _, ok := u.User.Password(); !ok {
+			r.username, r.password = "", r.username
+		}
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		r.db, err = strconv.Atoi(db)
+		if err != nil || r.db < 0 {
+			return nil, fmt.Errorf("redis: banco inválido na URL: %q", db)
+		}
+	}
+	return r, nil
+}
+
+// Chave do CEP no Redis
+func (r *RedisCache) key(cep string) string {
+	if r.namespace == "" {
+		return cep
+	}
+	return r.namespace + ":" + cep
+}
+
+// Retorna o resultado armazenado, marcado como vindo do cache. Uma falha de
+// comunicação com o Redis conta como falha do cache.
+func (r *RedisCache) Get(ctx context.Context, cep string) (*Result, bool, error) {
+	reply, err := r.do(ctx, "GET", r.key(cep))
+	if err != nil || reply == nil {
+		r.misses.Add(1)
+		return nil, false, err
+	}
+	var result Result
+	if err := json.Unmarshal([]byte(reply.(string)), &result); err != nil {
+		r.misses.Add(1)
+		return nil, false, fmt.Errorf("redis: resultado armazenado inválido: %v", err)
+	}
+	r.hits.Add(1)
+	result.Cached = true
+	return &result, true, nil
+}
+
+// Grava o resultado com expiração pelo TTL
+func (r *RedisCache) Set(ctx context.Context, cep string, result *Result) error {
+	stored := *result
+	stored.Cached = false
+	data, err := json.Marshal(&stored)
+	if err != nil {
+		return fmt.Errorf("redis: erro ao gerar o JSON: %v", err)
+	}
+	args := []string{"SET", r.key(cep), string(data)}
+	if r.ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(max(r.ttl.Milliseconds(), 1), 10))
+	}
+	_, err = r.do(ctx, args...)
+	return err
+}
+
+// Retorna quantas consultas foram respondidas pelo cache (hits) e quantas não
+// encontraram um resultado válido (misses) nesta instância desde a criação
+func (r *RedisCache) Stats() (hits, misses uint64) {
+	return r.hits.Load(), r.misses.Load()
+}
+
+// Retorna a quantidade e o tamanho em JSON dos resultados do namespace,
+// percorridos com SCAN. O Redis não guarda o instante de armazenamento: ele
+// é estimado pelo tempo restante de cada chave (PTTL) e pelo TTL do cache,
+// e fica zero sem TTL. Exige um namespace, que distingue as chaves do cache
+// das demais chaves do banco.
+func (r *RedisCache) Info(ctx context.Context) (CacheInfo, error) {
+	var info CacheInfo
+	now := time.Now()
+	err := r.scan(ctx, func(keys []string) error {
+		for _, key := range keys {
+			size, err := r.do(ctx, "STRLEN", key)
+			if err != nil {
+				return err
+			}
+			remaining, err := r.do(ctx, "PTTL", key)
+			if err != nil {
+				return err
+			}
+			n, _ := size.(int64)
+			if n == 0 {
+				continue // Expirada ou removida durante o SCAN
+			}
+			info.Entries++
+			info.Size += n
+			if ms, _ := remaining.(int64); r.ttl > 0 && ms > 0 {
+				stored := now.Add(time.Duration(ms)*time.Millisecond - r.ttl)
+				if info.Oldest.IsZero() || stored.Before(info.Oldest) {
+					info.Oldest = stored
+				}
+				if stored.After(info.Newest) {
+					info.Newest = stored
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return CacheInfo{}, err
+	}
+	return info, nil
+}
+
+// Remove os resultados do namespace (SCAN e DEL), retornando quantos foram
+// removidos. Exige um namespace, para não apagar as demais chaves do banco.
+func (r *RedisCache) Clear(ctx context.Context) (int, error) {
+	removed := 0
+	err := r.scan(ctx, func(keys []string) error {
+		reply, err := r.do(ctx, append([]string{"DEL"}, keys...)...)
+		if err != nil {
+			return err
+		}
+		n, _ := reply.(int64)
+		removed += int(n)
+		return nil
+	})
+	return removed, err
+}
+
+// Percorre as chaves do namespace com SCAN, chamando fn com cada página
+func (r *RedisCache) scan(ctx context.Context, fn func(keys []string) error) error {
+	if r.namespace == "" {
+		return errors.New("redis: o cache sem namespace não se distingue das demais chaves do banco")
+	}
+	pattern := redisGlobEscaper.Replace(r.namespace) + ":*"
+	for cursor := "0"; ; {
+		reply, err := r.do(ctx, "SCAN", cursor, "MATCH", pattern, "COUNT", "500")
+		if err != nil {
+			return err
+		}
+		page, ok := reply.([]any)
+		if !ok || len(page) != 2 {
+			return fmt.Errorf("redis: resposta inválida do SCAN: %v", reply)
+		}
+		cursor, _ = page[0].(string)
+		items, _ := page[1].([]any)
+		keys := make([]string, 0, len(items))
+		for _, item := range items {
+			if key, ok := item.(string); ok {
+				keys = append(keys, key)
+			}
+		}
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+		if cursor == "0" || cursor == "" {
+			return nil
+		}
+	}
+}
+
+// Escapa os caracteres especiais do padrão do SCAN no namespace
+var redisGlobEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+
+// Verifica a conexão com o Redis (PING)
+func (r *RedisCache) Ping(ctx context.Context) error {
+	_, err := r.do(ctx, "PING")
+	return err
+}
+
+// Encerra as conexões ociosas; as consultas seguintes abrem novas conexões
+// sem mantê-las
+func (r *RedisCache) Close() error {
+	r.mu.Lock()
+	idle := r.idle
+	r.idle, r.closed = nil, true
+	r.mu.Unlock()
+	for _, conn := range idle {
+		conn.Close()
+	}
+	return nil
+}
+
+// Erro informado pelo Redis na resposta (ex: "WRONGPASS ..."): a conexão
+// continua utilizável
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// Conexão com o Redis, com leitura e escrita bufferizadas
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+// Executa o comando e retorna a resposta: string, int64, []any (arrays,
+// como a do SCAN) ou nil (chave inexistente). O prazo é o do contexto, limitado a redisTimeout.
+func (r *RedisCache) do(ctx context.Context, args ...string) (any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(redisTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	conn, err := r.conn(ctx, deadline)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := conn.command(deadline, args...)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		conn.Close()
+		return nil, err
+	}
+	r.release(conn)
+	return reply, err
+}
+
+// Conexão ociosa ou, sem nenhuma, uma nova, autenticada e no banco da URL
+func (r *RedisCache) conn(ctx context.Context, deadline time.Time) (*redisConn, error) {
+	r.mu.Lock()
+	if n := len(r.idle); n > 0 {
+		conn := r.idle[n-1]
+		r.idle = r.idle[:n-1]
+		r.mu.Unlock()
+		return conn, nil
+	}
+	r.mu.Unlock()
+
+	dialer := &net.Dialer{Deadline: deadline}
+	var nc net.Conn
+	var err error
+	if r.tls != nil {
+		nc, err = (&tls.Dialer{NetDialer: dialer, Config: r.tls}).DialContext(ctx, "tcp", r.addr)
+	} else {
+		nc, err = dialer.DialContext(ctx, "tcp", r.addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis: erro ao conectar em %s: %v", r.addr, err)
+	}
+	conn := &redisConn{Conn: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+
+	if r.password != "" {
+		args := []string{"AUTH", r.password}
+		if r.username != "" {
+			args = []string{"AUTH", r.username, r.password}
+		}
+		if _, err := conn.command(deadline, args...); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if r.db != 0 {
+		if _, err := conn.command(deadline, "SELECT", strconv.Itoa(r.db)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// Devolve a conexão para reuso, ou a encerra acima do limite de ociosas
+func (r *RedisCache) release(conn *redisConn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed || len(r.idle) >= redisMaxIdle {
+		conn.Close()
+		return
+	}
+	r.idle = append(r.idle, conn)
+}
+
+// Envia o comando como um array de bulk strings e lê a resposta
+func (c *redisConn) command(deadline time.Time, args ...string) (any, error) {
+	c.SetDeadline(deadline)
+	fmt.Fprintf(c.w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(c.w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if err := c.w.Flush(); err != nil {
+		return nil, fmt.Errorf("redis: erro ao enviar o comando %s: %v", args[0], err)
+	}
+	reply, err := c.readReply()
+	if err != nil {
+		var replyErr redisError
+		if !errors.As(err, &replyErr) {
+			err = fmt.Errorf("redis: erro ao ler a resposta do comando %s: %v", args[0], err)
+		}
+		return nil, err
+	}
+	return reply, nil
+}
+
+// Lê uma resposta simples (+), de erro (-), inteira (:), bulk string ($) ou
+// array (*) dessas respostas
+func (c *redisConn) readReply() (any, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+	if line == "" {
+		return nil, errors.New("resposta vazia")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("tamanho inválido: %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("tamanho inválido: %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = c.readReply(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("resposta não suportada: %q", line)
+}
@@ -0,0 +1,81 @@
+package cep
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Cabeçalhos, parâmetros de query e token de autenticação incluídos em todas
+// as requisições a uma API (ex: a chave de API de um provedor comercial),
+// configurados por nome da API em Client.ProviderRequests. Transform adapta
+// o corpo de um espelho quase compatível (ex: outro nome de campo) ao formato
+// esperado pelo parse da API, sem um mapeamento completo.
+type RequestOptions struct {
+	Headers http.Header // Cabeçalhos adicionais, substituindo os de mesmo nome (ex: User-Agent)
+	Query   url.Values  // Parâmetros acrescentados à query da URL (ex: key=...)
+	Token   string      // Token enviado no cabeçalho "Authorization: Bearer <token>"
+
+	// Aplicada ao corpo de cada resposta com sucesso antes do parse. O erro
+	// (ou pânico) dela falha a API com ErrInvalidResponse.
+	Transform func([]byte) ([]byte, error)
+}
+
+// Inclui na requisição os cabeçalhos, os parâmetros e o token configurados
+// para a API
+func (c *Client) applyRequestOptions(req *http.Request, api string) {
+	opts, ok := c.ProviderRequests[api]
+	if !ok {
+		return
+	}
+	for name, values := range opts.Headers {
+		req.Header.Del(name)
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+	if opts.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+opts.Token)
+	}
+	if len(opts.Query) > 0 {
+		query := req.URL.Query()
+		for name, values := range opts.Query {
+			for _, v := range values {
+				query.Add(name, v)
+			}
+		}
+		req.URL.RawQuery = query.Encode()
+	}
+}
+
+// Aplica ao corpo da resposta o Transform configurado para a API, quando
+// houver, recuperando um pânico como erro
+func (c *Client) transformBody(api string, body []byte) (out []byte, err error) {
+	transform := c.ProviderRequests[api].Transform
+	if transform == nil {
+		return body, nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			out, err = nil, fmt.Errorf("%s: %w: pânico na transformação: %v", api, ErrInvalidResponse, r)
+		}
+	}()
+	out, err = transform(body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %v", api, ErrInvalidResponse, err)
+	}
+	return out, nil
+}
+
+// Substitui, no erro do client HTTP, a URL da requisição pela configurada,
+// sem os parâmetros de ProviderRequests: as chaves de API não aparecem nas
+// mensagens de erro nem nos logs. Com Client.MaskCEP, rawURL chega com o CEP
+// mascarado.
+func redactURLError(err error, rawURL string) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = rawURL
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/url"
+	"time"
+)
+
+// Intervalo padrão antes da primeira nova tentativa, dobrado a cada tentativa seguinte
+const retryBackoff = 100 * time.Millisecond
+
+// Erro de status HTTP inesperado retornado por uma API
+type httpStatusError struct {
+	code int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("status %d", e.code)
+}
+
+// Indica se o erro é temporário e vale uma nova tentativa: falhas de rede
+// e respostas 5xx. CEP não encontrado e demais erros são definitivos.
+func isTransient(err error) bool {
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.code >= 500
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// API que repete a busca em falhas temporárias, dentro do prazo do contexto
+type retryProvider struct {
+	Provider
+	retries int
+	backoff time.Duration
+}
+
+func (p *retryProvider) Fetch(ctx context.Context, cep string) (*Result, error) {
+	backoff := p.backoff
+	for attempt := 0; ; attempt++ {
+		result, err := p.Provider.Fetch(ctx, cep)
+		if err == nil || attempt >= p.retries || !isTransient(err) || ctx.Err() != nil {
+			return result, err
+		}
+
+		// Desistir já quando a espera passaria do prazo libera a corrida
+		// para as demais APIs em vez de segurar a goroutine até o fim
+		wait := jitter(backoff)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return nil, err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+			backoff *= 2
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		}
+	}
+}
+
+// Sorteia a espera entre metade e o intervalo inteiro, para que as novas
+// tentativas de consultas simultâneas não cheguem juntas à API
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + rand.N(d-half)
+}
@@ -0,0 +1,130 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Validadores HTTP da resposta de uma API (cabeçalhos ETag e Last-Modified),
+// guardados com o resultado no cache para a revalidação condicional (ver
+// Client.Revalidate)
+type Validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// Indica se a API não informou nenhum validador
+func (v Validators) IsZero() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+// CacheBackend que mantém, além do TTL, os resultados com validadores, para
+// que a consulta seguinte à expiração pergunte à API se o resultado mudou
+// (ver Client.Revalidate). O *Cache em memória a implementa; o RedisCache,
+// em que as chaves expiram no servidor, não.
+type RevalidatingCache interface {
+	CacheBackend
+	Validated(ctx context.Context, cep string) (*Result, bool, error) // Cópia do resultado armazenado com validadores, mesmo expirado
+}
+
+// Resposta 304 (Not Modified) da API à requisição condicional
+var errNotModified = errors.New("resultado não modificado")
+
+type revalidationKey struct{}
+type staleResultKey struct{}
+
+// Estado da revalidação em uma busca de uma API, compartilhado com Client.get
+// pelo contexto
+type revalidation struct {
+	stale      *Result    // Resultado armazenado da própria API, nil faz a requisição incondicional
+	validators Validators // Validadores da última resposta recebida
+}
+
+// Inclui na requisição os cabeçalhos condicionais dos validadores do
+// resultado armazenado, quando houver
+func (h *revalidation) prepare(req *http.Request) {
+	if h == nil || h.stale == nil {
+		return
+	}
+	if v := h.stale.Validators; v.ETag != "" {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	if v := h.stale.Validators; v.LastModified != "" {
+		req.Header.Set("If-Modified-Since", v.LastModified)
+	}
+}
+
+// Guarda os validadores da resposta, indicando se ela é o 304 da requisição
+// condicional, que confirma o resultado armazenado
+func (h *revalidation) observe(resp *http.Response) (notModified bool) {
+	if h == nil {
+		return false
+	}
+	h.validators = Validators{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+	return h.stale != nil && resp.StatusCode == http.StatusNotModified
+}
+
+// API cujas respostas guardam os validadores no resultado e que, com o
+// resultado armazenado dela no cache, faz a requisição condicional: o 304
+// reaproveita o resultado armazenado sem baixá-lo de novo. As APIs que não
+// informam validadores seguem com as requisições incondicionais.
+type revalidatingProvider struct {
+	Provider
+	c *Client
+}
+
+func (p *revalidatingProvider) Fetch(ctx context.Context, cep string) (*Result, error) {
+	h := &revalidation{}
+	if stale, ok := ctx.Value(staleResultKey{}).(*Result); ok && stale.API == p.Name() {
+		h.stale = stale
+	}
+
+	start := time.Now()
+	result, err := p.Provider.Fetch(context.WithValue(ctx, revalidationKey{}, h), cep)
+	if errors.Is(err, errNotModified) {
+		p.c.logger().Debug(ctx, "cache", "api", p.Name(), "acao", "revalidado")
+		revalidated := *h.stale
+		if !h.validators.IsZero() {
+			revalidated.Validators = h.validators
+		}
+		revalidated.Elapsed = time.Since(start)
+		return &revalidated, nil
+	}
+	if err == nil {
+		result.Validators = h.validators
+	}
+	return result, err
+}
+
+// Aplica a revalidação condicional, quando ativa em um cache que a suporta
+func (c *Client) withRevalidation(p Provider) Provider {
+	if _, ok := c.Cache.(RevalidatingCache); !ok || !c.Revalidate {
+		return p
+	}
+	return &revalidatingProvider{Provider: p, c: c}
+}
+
+// Contexto da corrida com o resultado armazenado com validadores, quando o
+// cache o tiver, para a requisição condicional à API que o retornou
+func (c *Client) withStaleResult(ctx context.Context, cep string) context.Context {
+	cache, ok := c.Cache.(RevalidatingCache)
+	if !ok || !c.Revalidate {
+		return ctx
+	}
+	stale, ok, err := cache.Validated(ctx, cep)
+	if err != nil {
+		c.logCacheError(ctx, err)
+	}
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, staleResultKey{}, stale)
+}
+
+// Estado da revalidação da busca em andamento, nil fora dela
+func revalidationFrom(ctx context.Context) *revalidation {
+	h, _ := ctx.Value(revalidationKey{}).(*revalidation)
+	return h
+}
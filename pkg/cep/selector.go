@@ -0,0 +1,107 @@
+package cep
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Política de escolha do resultado vencedor entre as respostas das APIs.
+// Consome até "pending" respostas dos canais e retorna o resultado escolhido
+// (nil se nenhum for aceito), todos os resultados consumidos (inclusive o
+// escolhido), na ordem de chegada, e os erros recebidos até então. Os
+// resultados consumidos e não escolhidos são os primeiros entregues em
+// Race.Remaining.
+type Selector interface {
+	Select(ctx context.Context, pending int, chResultCEP <-chan *Result, chError <-chan error) (result *Result, received []*Result, errs []error)
+}
+
+// Escolhe o primeiro resultado com sucesso (comportamento padrão)
+type fastestSelector struct {
+	retryOnEmptyFields bool // Aguarda um resultado mais completo quando o primeiro vier sem logradouro e bairro
+}
+
+func (s *fastestSelector) Select(ctx context.Context, pending int, chResultCEP <-chan *Result, chError <-chan error) (*Result, []*Result, []error) {
+	var thin *Result
+	var received []*Result
+	var errs []error
+	for ; pending > 0; pending-- {
+		select {
+		case result := <-chResultCEP:
+			received = append(received, result)
+			// Resultado com campos vazios aguarda uma resposta mais completa, se configurado
+			if s.retryOnEmptyFields && result.isThin() {
+				if thin == nil {
+					thin = result
+				}
+				continue
+			}
+			return result, received, errs
+
+		case err := <-chError:
+			// Se houver falha de uma API, aguarda receber o resultado da outra
+			errs = append(errs, err)
+
+		case <-ctx.Done():
+			// Timeout atingido: usa o resultado incompleto, se houver
+			return thin, received, errs
+		}
+	}
+	return thin, received, errs
+}
+
+// Aguarda uma janela adicional após o primeiro resultado e escolhe o mais
+// completo recebido; sem resultado melhor, mantém o mais rápido
+type completeSelector struct {
+	window time.Duration
+}
+
+func (s *completeSelector) Select(ctx context.Context, pending int, chResultCEP <-chan *Result, chError <-chan error) (*Result, []*Result, []error) {
+	var best *Result
+	var received []*Result
+	var errs []error
+	var windowDone <-chan time.Time
+	for ; pending > 0; pending-- {
+		select {
+		case result := <-chResultCEP:
+			received = append(received, result)
+			if best == nil {
+				// A janela começa a contar a partir do primeiro resultado
+				timer := time.NewTimer(s.window)
+				defer timer.Stop()
+				windowDone = timer.C
+			}
+			if best == nil || completeness(result) > completeness(best) {
+				best = result
+			}
+			// Nenhum resultado pode ser mais completo que este
+			if completeness(best) == maxCompleteness {
+				return best, received, errs
+			}
+
+		case err := <-chError:
+			errs = append(errs, err)
+
+		case <-windowDone:
+			return best, received, errs
+
+		case <-ctx.Done():
+			return best, received, errs
+		}
+	}
+	return best, received, errs
+}
+
+// Quantidade de campos de endereço considerados no cálculo de completude
+const maxCompleteness = 5
+
+// Conta quantos campos de endereço do resultado estão preenchidos
+func completeness(r *Result) int {
+	n := 0
+	for _, field := range []string{r.CEP, r.Logradouro, r.Bairro, r.Cidade, r.Estado} {
+		if strings.TrimSpace(field) != "" {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,175 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Erro retornado, sem consultar a API, enquanto ela está fora da corrida por
+// latência alta (ver Client.SlowThreshold)
+var ErrSlowProvider = errors.New("API lenta fora da corrida")
+
+const (
+	slowCooldown   = time.Minute // Tempo padrão fora da corrida de uma API lenta
+	slowMinSamples = 5           // Medições antes que a média possa retirar a API da corrida
+	latencyAlpha   = 0.2         // Peso padrão de cada nova medição na média móvel exponencial
+)
+
+// Latência média das APIs de um Client, por nome, compartilhada entre as
+// consultas. Ao contrário do circuit breaker, que reage a falhas, retira da
+// corrida a API que responde, mas devagar, para que ela não pese na cauda da
+// latência nas estratégias que aguardam mais de uma API. Nunca retira a
+// última API ainda na corrida.
+type latencyGroup struct {
+	mu       sync.Mutex
+	trackers map[string]*latencyTracker
+}
+
+// Média móvel exponencial da latência de uma API
+type latencyTracker struct {
+	average      time.Duration
+	samples      int
+	deselectedAt time.Time // Saída da corrida, zero se a API participa
+}
+
+// Retorna o registro da API, criando-o na primeira consulta. Exige g.mu.
+func (g *latencyGroup) tracker(name string) *latencyTracker {
+	if g.trackers == nil {
+		g.trackers = make(map[string]*latencyTracker)
+	}
+	t, ok := g.trackers[name]
+	if !ok {
+		t = &latencyTracker{}
+		g.trackers[name] = t
+	}
+	return t
+}
+
+// Indica se a API pode ser consultada. Fora da corrida, retorna também quanto
+// falta para o retorno; reselected indica que o cooldown acabou de terminar,
+// e a média recomeça do zero.
+func (g *latencyGroup) allow(name string, cooldown time.Duration) (wait time.Duration, ok, reselected bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	t := g.tracker(name)
+	if t.deselectedAt.IsZero() {
+		return 0, true, false
+	}
+	if wait := cooldown - time.Since(t.deselectedAt); wait > 0 {
+		return wait, false, false
+	}
+	*t = latencyTracker{}
+	return 0, true, true
+}
+
+// Registra o tempo de uma consulta liberada por allow, com o peso alpha na
+// média móvel, retornando a nova média e se ela retirou a API da corrida. As consultas canceladas (outra
+// API venceu) só contam quando superam a média, já que a resposta levaria
+// no mínimo esse tempo; as recusadas pelo próprio Client (limite de
+// requisições ou circuito aberto) não contam.
+func (g *latencyGroup) observe(name string, elapsed time.Duration, err error, threshold time.Duration, alpha float64) (average time.Duration, deselected bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	t := g.tracker(name)
+	switch {
+	case errors.Is(err, ErrRateLimited) || errors.Is(err, ErrCircuitOpen):
+		return t.average, false
+	case errors.Is(err, context.Canceled) && elapsed <= t.average:
+		return t.average, false
+	}
+	if t.samples == 0 {
+		t.average = elapsed
+	} else {
+		t.average = time.Duration(alpha*float64(elapsed) + (1-alpha)*float64(t.average))
+	}
+	t.samples++
+
+	if t.samples < slowMinSamples || t.average <= threshold || !t.deselectedAt.IsZero() || !g.othersSelected(name) {
+		return t.average, false
+	}
+	t.deselectedAt = time.Now()
+	return t.average, true
+}
+
+// Indica se alguma outra API segue na corrida. Exige g.mu.
+func (g *latencyGroup) othersSelected(name string) bool {
+	for other, t := range g.trackers {
+		if other != name && t.deselectedAt.IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// Latência média de uma API na corrida (ver Client.ProviderLatencies)
+type ProviderLatency struct {
+	API        string
+	Average    time.Duration // Média móvel exponencial do tempo de resposta, incluindo as novas tentativas
+	Samples    int           // Medições desde a criação ou o último retorno à corrida
+	Deselected bool          // Fora da corrida por latência alta
+}
+
+// Retorna a latência média das APIs já consultadas, em ordem de nome, com
+// SlowThreshold ativo (nil sem ele)
+func (c *Client) ProviderLatencies() []ProviderLatency {
+	c.latencies.mu.Lock()
+	defer c.latencies.mu.Unlock()
+
+	var out []ProviderLatency
+	for _, name := range slices.Sorted(maps.Keys(c.latencies.trackers)) {
+		t := c.latencies.trackers[name]
+		out = append(out, ProviderLatency{API: name, Average: t.average, Samples: t.samples, Deselected: !t.deselectedAt.IsZero()})
+	}
+	return out
+}
+
+// API retirada da corrida enquanto a latência média estiver acima do limite
+type slowProvider struct {
+	Provider
+	c *Client
+}
+
+func (p *slowProvider) Fetch(ctx context.Context, cep string) (*Result, error) {
+	name := p.Name()
+	cooldown := p.c.SlowCooldown
+	if cooldown <= 0 {
+		cooldown = slowCooldown
+	}
+	alpha := p.c.LatencyAlpha
+	if alpha <= 0 || alpha > 1 {
+		alpha = latencyAlpha
+	}
+	wait, ok, reselected := p.c.latencies.allow(name, cooldown)
+	if !ok {
+		precision := time.Second
+		if wait < time.Second {
+			precision = time.Millisecond
+		}
+		return nil, fmt.Errorf("%s: %w, de volta em %s", name, ErrSlowProvider, wait.Round(precision))
+	}
+	if reselected {
+		p.c.logger().Info(ctx, "latência", "api", name, "acao", "de volta à corrida")
+	}
+
+	start := time.Now()
+	result, err := p.Provider.Fetch(ctx, cep)
+	if average, deselected := p.c.latencies.observe(name, time.Since(start), err, p.c.SlowThreshold, alpha); deselected {
+		p.c.logger().Warn(ctx, "latência", "api", name, "acao", "retirada da corrida", "media", roundElapsed(average).String(), "limite", p.c.SlowThreshold.String(), "retorno_em", cooldown.String())
+	}
+	return result, err
+}
+
+// Aplica a retirada por latência alta, quando configurada
+func (c *Client) withSlowCheck(p Provider) Provider {
+	if c.SlowThreshold <= 0 {
+		return p
+	}
+	return &slowProvider{Provider: p, c: c}
+}
@@ -0,0 +1,103 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Erro retornado quando o estado informado pela API não corresponde à faixa
+// do CEP (ver Client.ValidateState)
+var ErrStateMismatch = errors.New("estado inconsistente com a faixa do CEP")
+
+// Faixas de prefixos de CEP de cada estado, usadas na validação do estado
+// dos resultados (Client.ValidateState) e na base offline quando o CEP não
+// pertence a nenhuma faixa de município conhecida (resultado apenas com o
+// estado)
+var stateRanges = []municipalityRange{
+	{1000, 19999, "", "SP"},
+	{20000, 28999, "", "RJ"},
+	{29000, 29999, "", "ES"},
+	{30000, 39999, "", "MG"},
+	{40000, 48999, "", "BA"},
+	{49000, 49999, "", "SE"},
+	{50000, 56999, "", "PE"},
+	{57000, 57999, "", "AL"},
+	{58000, 58999, "", "PB"},
+	{59000, 59999, "", "RN"},
+	{60000, 63999, "", "CE"},
+	{64000, 64999, "", "PI"},
+	{65000, 65999, "", "MA"},
+	{66000, 68899, "", "PA"},
+	{68900, 68999, "", "AP"},
+	{69000, 69299, "", "AM"},
+	{69300, 69399, "", "RR"},
+	{69400, 69899, "", "AM"},
+	{69900, 69999, "", "AC"},
+	{70000, 72799, "", "DF"},
+	{72800, 72999, "", "GO"},
+	{73000, 73699, "", "DF"},
+	{73700, 76799, "", "GO"},
+	{76800, 76999, "", "RO"},
+	{77000, 77999, "", "TO"},
+	{78000, 78899, "", "MT"},
+	{79000, 79999, "", "MS"},
+	{80000, 87999, "", "PR"},
+	{88000, 89999, "", "SC"},
+	{90000, 99999, "", "RS"},
+}
+
+// Estado (UF) a que pertence o CEP pela faixa de prefixos (ex: "SP" para
+// 01001-000). Retorna false para CEPs inválidos ou fora das faixas.
+func StateOf(cep string) (string, bool) {
+	normalized, err := Normalize(cep)
+	if err != nil {
+		return "", false
+	}
+	prefix, _ := strconv.Atoi(normalized[:5])
+	for _, r := range stateRanges {
+		if prefix >= r.start && prefix <= r.end {
+			return r.estado, true
+		}
+	}
+	return "", false
+}
+
+// Verifica se o estado do resultado corresponde à faixa do CEP consultado.
+// Resultados sem estado, ou CEPs fora das faixas, não são recusados.
+func checkState(result *Result, cep string) error {
+	want, ok := StateOf(cep)
+	got := strings.ToUpper(strings.TrimSpace(result.Estado))
+	if !ok || got == "" || got == want {
+		return nil
+	}
+	return fmt.Errorf("%s: %w: %s (esperado %s)", result.API, ErrStateMismatch, got, want)
+}
+
+// API cujos resultados com o estado inconsistente com a faixa do CEP são
+// tratados como falha
+type stateCheckProvider struct {
+	Provider
+}
+
+func (p *stateCheckProvider) Fetch(ctx context.Context, cep string) (*Result, error) {
+	result, err := p.Provider.Fetch(ctx, cep)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkState(result, cep); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Aplica a validação do estado, quando configurada. Fica por fora do
+// circuit breaker: dados inconsistentes não abrem o circuito da API.
+func (c *Client) withStateCheck(p Provider) Provider {
+	if !c.ValidateState {
+		return p
+	}
+	return &stateCheckProvider{Provider: p}
+}
@@ -0,0 +1,117 @@
+package cep
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+)
+
+// Endereço sugerido por Client.SuggestAddress para um logradouro parcial,
+// com a similaridade entre o texto informado e o logradouro encontrado
+type Suggestion struct {
+	*Result
+	Score float64 `json:"similaridade"` // De 0 a 1, maior quanto mais o logradouro se aproxima do texto informado
+}
+
+// Sugere endereços para um logradouro parcial ou com erros de digitação
+// (ex: "domingos morais" em SP/São Paulo), para autocompletar formulários:
+// busca no ViaCEP (ver SearchAddress) e ordena os candidatos pela
+// similaridade com o texto informado, ignorando caixa, acentos e espaços
+// extras. Sem nenhum candidato, repete a busca apenas com a palavra mais
+// longa do logradouro. Retorna até limit sugestões (0 retorna todas).
+func (c *Client) SuggestAddress(ctx context.Context, a Address, limit int) ([]Suggestion, error) {
+	results, err := c.SearchAddress(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	if word := longestWord(a.Logradouro); len(results) == 0 && word != a.Logradouro && len([]rune(word)) >= 3 {
+		retry := a
+		retry.Logradouro = word
+		if results, err = c.SearchAddress(ctx, retry); err != nil {
+			return nil, err
+		}
+	}
+
+	suggestions := make([]Suggestion, 0, len(results))
+	for _, result := range results {
+		suggestions = append(suggestions, Suggestion{Result: result, Score: streetSimilarity(a.Logradouro, result.Logradouro)})
+	}
+	slices.SortStableFunc(suggestions, func(x, y Suggestion) int {
+		return cmp.Or(cmp.Compare(y.Score, x.Score), cmp.Compare(x.CEP, y.CEP))
+	})
+	if limit > 0 && len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
+
+// Palavra mais longa do texto (a primeira, em caso de empate)
+func longestWord(text string) string {
+	var longest string
+	for _, word := range strings.Fields(text) {
+		if len([]rune(word)) > len([]rune(longest)) {
+			longest = word
+		}
+	}
+	return longest
+}
+
+// Preposições e artigos ignorados na comparação dos logradouros, muitas
+// vezes omitidos ao digitar (ex: "Domingos Morais" para "Domingos de Morais")
+var streetConnectives = []string{"a", "o", "e", "da", "das", "de", "do", "dos"}
+
+// Logradouro em minúsculas, sem acentos e sem preposições e artigos
+func foldStreet(name string) []rune {
+	words := strings.Fields(foldName(name))
+	words = slices.DeleteFunc(words, func(w string) bool { return slices.Contains(streetConnectives, w) })
+	return []rune(strings.Join(words, " "))
+}
+
+// Similaridade, de 0 a 1, entre o texto digitado e o logradouro: a melhor
+// correspondência do texto com um trecho do logradouro iniciado em alguma
+// palavra (o texto costuma omitir o tipo, como "Rua", ou o final do nome),
+// pela distância de edição, com um peso menor para a fração do logradouro
+// coberta pelo texto
+func streetSimilarity(query, street string) float64 {
+	q, s := foldStreet(query), foldStreet(street)
+	if len(q) == 0 || len(s) == 0 {
+		return 0
+	}
+
+	best := 0.0
+	for i := range s {
+		if i > 0 && s[i-1] != ' ' {
+			continue
+		}
+		for n, d := range prefixDistances(q, s[i:]) {
+			if n > 0 {
+				best = max(best, 1-float64(d)/float64(max(len(q), n)))
+			}
+		}
+	}
+	coverage := float64(min(len(q), len(s))) / float64(len(s))
+	return 0.9*best + 0.1*coverage
+}
+
+// Distância de edição (inserções, remoções e substituições) entre a e cada
+// prefixo de b: o elemento n é a distância até b[:n]
+func prefixDistances(a, b []rune) []int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev
+}
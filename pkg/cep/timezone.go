@@ -0,0 +1,47 @@
+package cep
+
+import "strings"
+
+// Fuso horário IANA de cada estado
+type ufTimeZone struct {
+	zone      string
+	ambiguous bool // Estado com mais de um fuso: zone é o predominante
+}
+
+// Fusos por UF. AM (America/Eirunepe no oeste), PA (America/Santarem no
+// oeste) e PE (America/Noronha em Fernando de Noronha) usam o predominante.
+var ufTimeZones = map[string]ufTimeZone{
+	"AC": {zone: "America/Rio_Branco"},
+	"AL": {zone: "America/Maceio"},
+	"AM": {zone: "America/Manaus", ambiguous: true},
+	"AP": {zone: "America/Belem"},
+	"BA": {zone: "America/Bahia"},
+	"CE": {zone: "America/Fortaleza"},
+	"DF": {zone: "America/Sao_Paulo"},
+	"ES": {zone: "America/Sao_Paulo"},
+	"GO": {zone: "America/Sao_Paulo"},
+	"MA": {zone: "America/Fortaleza"},
+	"MG": {zone: "America/Sao_Paulo"},
+	"MS": {zone: "America/Campo_Grande"},
+	"MT": {zone: "America/Cuiaba"},
+	"PA": {zone: "America/Belem", ambiguous: true},
+	"PB": {zone: "America/Fortaleza"},
+	"PE": {zone: "America/Recife", ambiguous: true},
+	"PI": {zone: "America/Fortaleza"},
+	"PR": {zone: "America/Sao_Paulo"},
+	"RJ": {zone: "America/Sao_Paulo"},
+	"RN": {zone: "America/Fortaleza"},
+	"RO": {zone: "America/Porto_Velho"},
+	"RR": {zone: "America/Boa_Vista"},
+	"RS": {zone: "America/Sao_Paulo"},
+	"SC": {zone: "America/Sao_Paulo"},
+	"SE": {zone: "America/Maceio"},
+	"SP": {zone: "America/Sao_Paulo"},
+	"TO": {zone: "America/Araguaina"},
+}
+
+// Indica se o estado possui mais de um fuso horário. Nesse caso, o fuso
+// preenchido em Result.TimeZone é o predominante.
+func TimeZoneAmbiguous(uf string) bool {
+	return ufTimeZones[strings.ToUpper(uf)].ambiguous
+}
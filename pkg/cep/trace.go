@@ -0,0 +1,144 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// Dados de uma busca coletados para o log detalhado (Client.Logger)
+type fetchTrace struct {
+	status int    // Status HTTP da última resposta recebida, 0 se nenhuma
+	cep    string // CEP consultado
+	logCEP string // CEP exibido no log, mascarado com Client.MaskCEP
+}
+
+type fetchTraceKey struct{}
+
+// Associa o registro da busca ao contexto da requisição
+func withFetchTrace(ctx context.Context, trace *fetchTrace) context.Context {
+	return context.WithValue(ctx, fetchTraceKey{}, trace)
+}
+
+// Registra o status HTTP da resposta na busca do contexto, se houver
+func traceStatus(ctx context.Context, status int) {
+	if trace, ok := ctx.Value(fetchTraceKey{}).(*fetchTrace); ok {
+		trace.status = status
+	}
+}
+
+// Registra no Logger, em nível debug, cada requisição às APIs (inclusive as
+// novas tentativas), com o CEP da URL mascarado conforme Client.MaskCEP
+func (c *Client) logAttempt(ctx context.Context, api, url string, status int, elapsed time.Duration, err error) {
+	if !logEnabled(ctx, c.Logger, slog.LevelDebug) {
+		return
+	}
+	attrs := []any{"api", api, "url", c.maskText(ctx, url)}
+	if status != 0 {
+		attrs = append(attrs, "status", status)
+	}
+	attrs = append(attrs, "tempo", roundElapsed(elapsed).String())
+	if err != nil {
+		attrs = append(attrs, "erro", err.Error())
+	}
+	c.Logger.Debug(ctx, "requisição", attrs...)
+}
+
+// Desfecho de uma API na corrida, entregue a Client.OnOutcome
+type Outcome struct {
+	API     string        // Nome da API (ex: "ViaCEP")
+	CEP     string        // CEP consultado, mascarado com Client.MaskCEP
+	Status  int           // Status HTTP da última resposta recebida, 0 se nenhuma
+	Elapsed time.Duration // Tempo da busca, incluindo as novas tentativas
+	Result  string        // "venceu", "perdeu", "cancelada" ou "erro"
+	Err     error         // Erro retornado pela API, com o CEP mascarado com Client.MaskCEP; nil se respondeu
+
+	Context context.Context // Contexto da consulta, com o span do Tracer (ex: para associar o desfecho ao trace)
+}
+
+// Indica se o desfecho de cada API é registrado no Logger ou em OnOutcome
+func (r *Race) reportsOutcomes() bool {
+	return r.logger != nil || r.onOutcome != nil
+}
+
+// Indica se cada busca registra os dados em fetchTrace: para o desfecho ou
+// para mascarar o CEP nas requisições registradas no Logger, nos spans e nos
+// erros retornados
+func (r *Race) tracesFetches() bool {
+	return r.reportsOutcomes() || r.traced || r.maskCEP
+}
+
+// Registra o desfecho de uma API na corrida: venceu, perdeu (respondeu,
+// mas outro resultado foi escolhido), cancelada (após a escolha do vencedor)
+// ou erro. Aguarda a escolha do vencedor para classificar as respostas.
+func (r *Race) reportOutcome(p Provider, trace *fetchTrace, elapsed time.Duration, result *Result, err error) {
+	defer r.logs.Done()
+	<-r.decided
+
+	if r.maskCEP {
+		err = maskError(trace.cep, err)
+	}
+	outcome := Outcome{API: p.Name(), CEP: r.logCEP, Status: trace.status, Elapsed: elapsed, Result: "erro", Err: err, Context: r.ctx}
+	switch {
+	case err == nil && result == r.Result:
+		outcome.Result = "venceu"
+	case err == nil:
+		outcome.Result = "perdeu"
+	case r.Result != nil && errors.Is(err, context.Canceled):
+		outcome.Result = "cancelada"
+	}
+
+	if r.onOutcome != nil {
+		r.onOutcome(outcome)
+	}
+	if r.logger != nil {
+		r.logOutcome(outcome)
+	}
+}
+
+// Registra o desfecho no Logger, em uma linha estruturada por API: o vencedor
+// em nível info, as APIs que falharam em warn e as demais (inclusive as que
+// informaram que o CEP não existe) em debug
+func (r *Race) logOutcome(o Outcome) {
+	level := slog.LevelDebug
+	switch {
+	case o.Result == "venceu":
+		level = slog.LevelInfo
+	case o.Result == "erro" && !errors.Is(o.Err, ErrCEPNotFound):
+		level = slog.LevelWarn
+	}
+	if !logEnabled(r.ctx, r.logger, level) {
+		return
+	}
+
+	attrs := []any{"api", o.API, "cep", o.CEP}
+	if o.Status != 0 {
+		attrs = append(attrs, "status", o.Status)
+	}
+	attrs = append(attrs, "tempo", roundElapsed(o.Elapsed).String(), "resultado", o.Result)
+
+	// Quem perdeu é registrado ao lado do tempo do vencedor, indicando por
+	// quanto a corrida foi decidida
+	if o.Result == "perdeu" && !r.Result.StartedAt.IsZero() {
+		winner := r.Result.FinishedAt.Sub(r.Result.StartedAt)
+		diff := "+" + roundElapsed(o.Elapsed-winner).String()
+		if o.Elapsed < winner {
+			diff = "-" + roundElapsed(winner-o.Elapsed).String()
+		}
+		attrs = append(attrs, "vencedor", r.Result.API, "tempo_vencedor", roundElapsed(winner).String(), "diferenca", diff)
+	}
+	if o.Result == "erro" {
+		attrs = append(attrs, "erro", o.Err.Error())
+	}
+	logAt(r.ctx, r.logger, level, "consulta", attrs...)
+}
+
+// Arredonda o tempo de resposta para milissegundos, mantendo a precisão de
+// microssegundos abaixo de 1ms (ex: respostas reproduzidas de fixtures)
+func roundElapsed(d time.Duration) time.Duration {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond)
+	}
+	return d.Round(time.Millisecond)
+}
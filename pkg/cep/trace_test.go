@@ -0,0 +1,122 @@
+package cep
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMaskCEPInErrors(t *testing.T) {
+	var logs bytes.Buffer
+	var mu sync.Mutex
+	var outcomes []Outcome
+	c := &Client{
+		URLs:    map[string]string{"viacep": "http://127.0.0.1:1/%s"},
+		Timeout: time.Second,
+		MaskCEP: true,
+		Logger:  NewSlogLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+		OnOutcome: func(o Outcome) {
+			mu.Lock()
+			defer mu.Unlock()
+			outcomes = append(outcomes, o)
+		},
+	}
+	p, err := NewProvider("viacep", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Providers = []Provider{p}
+
+	_, err = c.Lookup(context.Background(), "01001-000")
+	if !errors.Is(err, ErrAllProvidersFailed) {
+		t.Fatalf("Lookup: erro = %v, esperado ErrAllProvidersFailed", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(outcomes) != 1 {
+		t.Fatalf("OnOutcome chamada %d vezes, esperada 1", len(outcomes))
+	}
+	texts := map[string]string{"erro retornado": err.Error(), "log": logs.String()}
+	for _, o := range outcomes {
+		texts["OnOutcome"] = o.Err.Error()
+	}
+	for name, text := range texts {
+		if strings.Contains(text, "01001000") || strings.Contains(text, "01001-000") {
+			t.Errorf("%s expõe o CEP: %s", name, text)
+		}
+		if !strings.Contains(text, "01001-***") {
+			t.Errorf("%s sem o CEP mascarado: %s", name, text)
+		}
+	}
+}
+
+// Logger de outra biblioteca, sem o método Enabled: recebe todos os níveis
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []string // Nível, mensagem e atributos de cada registro
+}
+
+func (l *recordingLogger) record(level, msg string, args []any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, level+" "+msg+" "+fmt.Sprint(args...))
+}
+
+func (l *recordingLogger) Debug(_ context.Context, msg string, args ...any) {
+	l.record("DEBUG", msg, args)
+}
+
+func (l *recordingLogger) Info(_ context.Context, msg string, args ...any) {
+	l.record("INFO", msg, args)
+}
+
+func (l *recordingLogger) Warn(_ context.Context, msg string, args ...any) {
+	l.record("WARN", msg, args)
+}
+
+func (l *recordingLogger) Error(_ context.Context, msg string, args ...any) {
+	l.record("ERROR", msg, args)
+}
+
+func TestCustomLogger(t *testing.T) {
+	srv := newJSONStub(t, 0, http.StatusOK, viaCEPPracaDaSe)
+	c := newViaCEPClient(t, srv)
+	logger := &recordingLogger{}
+	c.Logger = logger
+
+	if _, err := c.Lookup(context.Background(), "01001000"); err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	want := []string{"DEBUG requisição", "INFO consulta"}
+	if len(logger.entries) != len(want) {
+		t.Fatalf("registros = %q, esperados %q", logger.entries, want)
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(logger.entries[i], prefix) {
+			t.Errorf("registro %d = %q, esperado %q", i, logger.entries[i], prefix)
+		}
+	}
+	if !strings.Contains(logger.entries[1], "venceu") {
+		t.Errorf("desfecho sem o resultado em pares chave-valor: %q", logger.entries[1])
+	}
+}
+
+func TestNopLogger(t *testing.T) {
+	srv := newJSONStub(t, 0, http.StatusOK, viaCEPPracaDaSe)
+	c := newViaCEPClient(t, srv)
+	c.Logger = NopLogger
+	if _, err := c.Lookup(context.Background(), "01001000"); err != nil {
+		t.Fatalf("Lookup com NopLogger: %v", err)
+	}
+}
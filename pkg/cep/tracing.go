@@ -0,0 +1,109 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+)
+
+// Tipo do span, como no OpenTelemetry: uma operação interna (a consulta) ou
+// uma requisição de saída (cada tentativa em uma API)
+type SpanKind int
+
+const (
+	SpanInternal SpanKind = iota
+	SpanClient
+)
+
+// Rastreamento distribuído das consultas (ex: um adaptador para o
+// OpenTelemetry): um span por consulta e, como filhos, um por requisição às
+// APIs, inclusive as novas tentativas. O span pai é o do contexto recebido.
+type Tracer interface {
+	Start(ctx context.Context, name string, kind SpanKind) (context.Context, Span)
+}
+
+// Span iniciado pelo Tracer, encerrado uma única vez com End
+type Span interface {
+	SetAttributes(attrs ...slog.Attr)
+	End(err error) // err não nil marca o span com erro
+}
+
+// Span descartado quando Client.Tracer não está configurado
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...slog.Attr) {}
+func (noopSpan) End(error)                  {}
+
+// Inicia um span no Client.Tracer, se configurado, com os atributos
+func (c *Client) startSpan(ctx context.Context, name string, kind SpanKind, attrs ...slog.Attr) (context.Context, Span) {
+	if c.Tracer == nil {
+		return ctx, noopSpan{}
+	}
+	ctx, span := c.Tracer.Start(ctx, name, kind)
+	span.SetAttributes(attrs...)
+	return ctx, span
+}
+
+// CEP exibido no Logger, em OnOutcome e nos spans, mascarado com Client.MaskCEP
+func (c *Client) logCEP(cep string) string {
+	if c.MaskCEP {
+		return Mask(cep)
+	}
+	return cep
+}
+
+// Texto (ex: URL ou erro) com o CEP mascarado conforme Client.MaskCEP
+func (c *Client) maskCEPIn(cep, text string) string {
+	if !c.MaskCEP {
+		return text
+	}
+	return maskCEPText(cep, text)
+}
+
+// Substitui o CEP, com e sem hífen, pela versão mascarada
+func maskCEPText(cep, text string) string {
+	return strings.NewReplacer(Format(cep), Mask(cep), cep, Mask(cep)).Replace(text)
+}
+
+// Erro com o CEP mascarado na mensagem, mantendo o original para errors.Is
+// e errors.As
+type maskedError struct {
+	err  error
+	text string
+}
+
+func (e *maskedError) Error() string { return e.text }
+func (e *maskedError) Unwrap() error { return e.err }
+
+// Erro com o CEP mascarado na mensagem, ou o próprio erro se ela não contém o CEP
+func maskError(cep string, err error) error {
+	if err == nil {
+		return nil
+	}
+	text := maskCEPText(cep, err.Error())
+	if text == err.Error() {
+		return err
+	}
+	return &maskedError{err: err, text: text}
+}
+
+// Texto da requisição (URL ou erro) com o CEP da busca do contexto mascarado
+func (c *Client) maskText(ctx context.Context, text string) string {
+	if trace, ok := ctx.Value(fetchTraceKey{}).(*fetchTrace); ok {
+		return c.maskCEPIn(trace.cep, text)
+	}
+	return text
+}
+
+// Encerra o span da consulta com a API vencedora ou o erro, com o CEP
+// mascarado
+func (c *Client) endLookupSpan(span Span, cep string, result *Result, err error) {
+	if result != nil {
+		span.SetAttributes(slog.String("cep.api", result.API), slog.Bool("cep.cache_hit", result.Cached))
+	}
+	if err != nil {
+		err = errors.New(c.maskCEPIn(cep, err.Error()))
+	}
+	span.End(err)
+}
@@ -0,0 +1,105 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Cria o transport HTTP que conecta ao socket Unix em vez de abrir conexões TCP
+func NewUnixSocketTransport(socketPath string) *http.Transport {
+	return &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
+		},
+	}
+}
+
+// Cria a API de um serviço local de CEP exposto em um socket Unix (sidecar).
+// path é o caminho HTTP no serviço (%s é substituído pelo CEP) e client, o
+// client HTTP que conecta pelo socket (nil cria um com NewUnixSocketTransport).
+// O serviço deve responder no formato unificado: cep, logradouro, bairro,
+// cidade e estado.
+func NewUnixSocketProvider(c *Client, socketPath, path string, client *http.Client) Provider {
+	if client == nil {
+		client = &http.Client{Transport: NewUnixSocketTransport(socketPath)}
+	}
+	return &unixSocketProvider{c: c, socketPath: socketPath, path: path, client: client}
+}
+
+// Serviço local de CEP exposto em um socket Unix
+type unixSocketProvider struct {
+	c          *Client
+	socketPath string
+	path       string
+	client     *http.Client
+}
+
+func (p *unixSocketProvider) Name() string {
+	return "Unix socket"
+}
+
+// Função para busca do cep no serviço local via socket Unix
+func (p *unixSocketProvider) Fetch(ctx context.Context, cep string) (*Result, error) {
+	// URL: o host é ignorado, a conexão é feita pelo socket
+	url := "http://unix" + fmt.Sprintf(p.path, cep)
+
+	// Chamada com contexto
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Unix socket: erro na requisição: %v", err)
+	}
+	userAgent := p.c.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+	p.c.applyRequestOptions(req, p.Name())
+
+	// Executa a requisição, medindo o tempo até o fim do parse da resposta
+	start := time.Now()
+	resp, err := p.client.Do(req)
+	if err != nil {
+		if _, statErr := os.Stat(p.socketPath); errors.Is(statErr, os.ErrNotExist) {
+			return nil, fmt.Errorf("Unix socket: socket %s não encontrado", p.socketPath)
+		}
+		return nil, fmt.Errorf("Unix socket: erro HTTP: %w", redactURLError(err, url))
+	}
+	defer resp.Body.Close()
+	traceStatus(ctx, resp.StatusCode)
+
+	// Checa o status code da requisição
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("Unix socket: %w", ErrCEPNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unix socket: %w", &httpStatusError{code: resp.StatusCode})
+	}
+
+	// Realiza leitura e parse das respostas
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Unix socket: erro na leitura: %v", err)
+	}
+	if body, err = p.c.transformBody("Unix socket", body); err != nil {
+		return nil, err
+	}
+
+	var result Result
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("Unix socket: erro no parse: %v", err)
+	}
+	result.API = "Unix socket"
+	result.CEP = Format(result.CEP)
+	result.Origem = "unix"
+	result.Elapsed = time.Since(start)
+
+	return &result, nil
+}
@@ -0,0 +1,188 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// URL do ViaCEP (%s é substituído pelo CEP)
+const viaCEPURL = "https://viacep.com.br/ws/%s/json/"
+
+// Estrutura para parse de respostas da API - Via CEP
+type ViaCEPResponse struct {
+	CEP         string     `json:"cep"`
+	Logradouro  string     `json:"logradouro"`
+	Complemento string     `json:"complemento"`
+	Bairro      string     `json:"bairro"`
+	Localidade  string     `json:"localidade"`
+	UF          string     `json:"uf"`
+	IBGE        flexString `json:"ibge"`
+	GIA         flexString `json:"gia"`
+	DDD         flexString `json:"ddd"`
+	Siafi       flexString `json:"siafi"`
+	Erro        flexBool   `json:"erro"` // Presente quando o CEP não existe
+}
+
+// Função para busca do cep utilizando a API ViaCEP
+func (c *Client) fetchViaCEP(ctx context.Context, cep string) (*Result, error) {
+	// Executa a requisição, medindo o tempo até o fim do parse da resposta
+	resp, start, err := c.get(ctx, c.httpClient(), "ViaCEP", fmt.Sprintf(c.url("viacep"), cep))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Checa o status code da requisição
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ViaCEP: %w", &httpStatusError{code: resp.StatusCode})
+	}
+
+	// Realiza leitura e parse das respostas
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("ViaCEP: erro na leitura: %v", err)
+	}
+	if body, err = c.transformBody("ViaCEP", body); err != nil {
+		return nil, err
+	}
+
+	var apiResponse ViaCEPResponse
+	if err := json.Unmarshal(body, &apiResponse); err != nil {
+		return nil, fmt.Errorf("ViaCEP: erro no parse: %v", err)
+	}
+
+	// Verifica se o CEP foi localizado: o ViaCEP sinaliza com "erro": true,
+	// e o CEP vazio é mantido como verificação adicional
+	if apiResponse.Erro || apiResponse.CEP == "" {
+		return nil, fmt.Errorf("ViaCEP: %w", ErrCEPNotFound)
+	}
+
+	// Resultado unificado
+	result := apiResponse.toResult()
+	result.Elapsed = time.Since(start)
+
+	return result, nil
+}
+
+// Converte a resposta do ViaCEP no resultado unificado
+func (r *ViaCEPResponse) toResult() *Result {
+	return &Result{
+		API:        "ViaCEP",
+		CEP:        Format(r.CEP),
+		Logradouro: r.Logradouro,
+		Bairro:     r.Bairro,
+		Cidade:     r.Localidade,
+		Estado:     r.UF,
+		Origem:     "viacep",
+		IBGE:       string(r.IBGE),
+		SIAFI:      string(r.Siafi),
+		DDD:        string(r.DDD),
+	}
+}
+
+// Endereço da busca reversa: "UF/Cidade/Logradouro"
+type Address struct {
+	UF, Cidade, Logradouro string
+}
+
+// Faz o parse de um endereço no formato "UF/Cidade/Logradouro" (ex:
+// "SP/São Paulo/Domingos de Morais"). O ViaCEP exige cidade e logradouro
+// com pelo menos 3 caracteres.
+func ParseAddress(value string) (Address, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) != 3 {
+		return Address{}, fmt.Errorf("endereço inválido: %q (use UF/Cidade/Logradouro)", value)
+	}
+	return NewAddress(parts[0], parts[1], parts[2])
+}
+
+// Monta o endereço da busca reversa a partir das partes já separadas (ex: as
+// colunas de um CSV), com as mesmas validações de ParseAddress
+func NewAddress(uf, cidade, logradouro string) (Address, error) {
+	a := Address{
+		UF:         strings.ToUpper(strings.TrimSpace(uf)),
+		Cidade:     strings.TrimSpace(cidade),
+		Logradouro: strings.TrimSpace(logradouro),
+	}
+	if len(a.UF) != 2 {
+		return Address{}, fmt.Errorf("UF inválida: %q", uf)
+	}
+	if len([]rune(a.Cidade)) < 3 || len([]rune(a.Logradouro)) < 3 {
+		return Address{}, errors.New("cidade e logradouro devem ter pelo menos 3 caracteres")
+	}
+	return a, nil
+}
+
+// Endereço no formato "UF/Cidade/Logradouro" de ParseAddress
+func (a Address) String() string {
+	return a.UF + "/" + a.Cidade + "/" + a.Logradouro
+}
+
+// Caminho da busca no ViaCEP, com cada parte codificada (acentos e espaços)
+func (a Address) path() string {
+	return url.PathEscape(a.UF) + "/" + url.PathEscape(a.Cidade) + "/" + url.PathEscape(a.Logradouro)
+}
+
+// Busca reversa por endereço no ViaCEP, que retorna a lista de CEPs
+// correspondentes (vazia se nenhum for encontrado). Os CEPs seguem o formato
+// da consulta por CEP (Format, ex: 01001-000), e as entradas repetidas de um
+// mesmo CEP (como os trechos de números pares e ímpares de uma rua) são
+// reduzidas à primeira. A busca segue o limite de requisições do ViaCEP
+// (RateLimits), compartilhado com as consultas por CEP. As demais APIs não
+// oferecem essa busca.
+func (c *Client) SearchAddress(ctx context.Context, a Address) ([]*Result, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	const api = "ViaCEP"
+	if limit, ok := c.RateLimits[api]; ok && limit.PerSecond > 0 {
+		if err := c.limiters.get(api, limit).wait(ctx, api, limit.FailFast); err != nil {
+			return nil, err
+		}
+	}
+
+	// URL: o template do ViaCEP recebe o endereço no lugar do CEP
+	resp, start, err := c.get(ctx, c.httpClient(), "ViaCEP", fmt.Sprintf(c.url("viacep"), a.path()))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ViaCEP: %w", &httpStatusError{code: resp.StatusCode})
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("ViaCEP: erro na leitura: %v", err)
+	}
+	if body, err = c.transformBody("ViaCEP", body); err != nil {
+		return nil, err
+	}
+
+	var apiResponse []ViaCEPResponse
+	if err := json.Unmarshal(body, &apiResponse); err != nil {
+		return nil, fmt.Errorf("ViaCEP: erro no parse: %v", err)
+	}
+
+	elapsed := time.Since(start)
+	results := make([]*Result, 0, len(apiResponse))
+	seen := make(map[string]bool, len(apiResponse))
+	for i := range apiResponse {
+		result := apiResponse[i].toResult()
+		if seen[result.CEP] {
+			continue
+		}
+		seen[result.CEP] = true
+		result.Elapsed = elapsed
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -0,0 +1,147 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestViaCEPFlexibleFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		ibge     string
+		ddd      string
+		parseErr bool
+	}{
+		{
+			name: "texto",
+			body: `{"cep": "01001-000", "localidade": "São Paulo", "uf": "SP", "ibge": "3550308", "ddd": "11"}`,
+			ibge: "3550308",
+			ddd:  "11",
+		},
+		{
+			name: "número",
+			body: `{"cep": "01001-000", "localidade": "São Paulo", "uf": "SP", "ibge": 3550308, "ddd": 11}`,
+			ibge: "3550308",
+			ddd:  "11",
+		},
+		{
+			name: "nulo",
+			body: `{"cep": "01001-000", "localidade": "São Paulo", "uf": "SP", "ibge": null, "ddd": null}`,
+		},
+		{
+			name:     "tipo inesperado",
+			body:     `{"cep": "01001-000", "localidade": "São Paulo", "uf": "SP", "ibge": {"codigo": 3550308}}`,
+			parseErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newJSONStub(t, 0, http.StatusOK, json.RawMessage(tt.body))
+			c := newViaCEPClient(t, srv)
+
+			result, err := c.fetchViaCEP(context.Background(), "01001000")
+			if tt.parseErr {
+				if err == nil {
+					t.Fatalf("esperado erro no parse, resultado %+v", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if result.IBGE != tt.ibge || result.DDD != tt.ddd {
+				t.Errorf("IBGE = %q, DDD = %q; esperados %q e %q", result.IBGE, result.DDD, tt.ibge, tt.ddd)
+			}
+		})
+	}
+}
+
+func TestViaCEPNotFound(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		notFound bool
+	}{
+		{"erro true", `{"erro": true}`, true},
+		{"erro como texto", `{"erro": "true"}`, true},
+		{"erro true com CEP preenchido", `{"cep": "01001-000", "erro": true}`, true},
+		{"sem erro e sem CEP", `{}`, true},
+		{"erro false", `{"cep": "01001-000", "localidade": "São Paulo", "uf": "SP", "erro": false}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newJSONStub(t, 0, http.StatusOK, json.RawMessage(tt.body))
+			c := newViaCEPClient(t, srv)
+
+			result, err := c.fetchViaCEP(context.Background(), "01001000")
+			if tt.notFound {
+				if !errors.Is(err, ErrCEPNotFound) {
+					t.Fatalf("erro = %v, resultado %+v; esperado ErrCEPNotFound", err, result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+		})
+	}
+}
+
+func TestSearchAddressDeduplicates(t *testing.T) {
+	body := `[
+		{"cep": "04014-000", "logradouro": "Rua Domingos de Morais", "complemento": "até 1000 - lado par", "bairro": "Vila Mariana", "localidade": "São Paulo", "uf": "SP"},
+		{"cep": "04010-100", "logradouro": "Rua Domingos de Morais", "complemento": "até 999 - lado ímpar", "bairro": "Vila Mariana", "localidade": "São Paulo", "uf": "SP"},
+		{"cep": "04014000", "logradouro": "Rua Domingos de Morais", "complemento": "de 1001 ao fim - lado par", "bairro": "Vila Mariana", "localidade": "São Paulo", "uf": "SP"},
+		{"cep": "04010-100", "logradouro": "Rua Domingos de Morais", "bairro": "Vila Mariana", "localidade": "São Paulo", "uf": "SP"},
+		{"cep": "04036-100", "logradouro": "Rua Domingos de Morais", "bairro": "Vila Mariana", "localidade": "São Paulo", "uf": "SP"}
+	]`
+	srv := newJSONStub(t, 0, http.StatusOK, json.RawMessage(body))
+	c := newViaCEPClient(t, srv)
+
+	results, err := c.SearchAddress(context.Background(), Address{UF: "SP", Cidade: "São Paulo", Logradouro: "Domingos de Morais"})
+	if err != nil {
+		t.Fatalf("SearchAddress: %v", err)
+	}
+	want := []string{"04014-000", "04010-100", "04036-100"}
+	if len(results) != len(want) {
+		t.Fatalf("%d resultados, esperados %d (um por CEP): %+v", len(results), len(want), results)
+	}
+	for i, r := range results {
+		if r.CEP != want[i] {
+			t.Errorf("resultado %d: CEP = %q, esperado %q (formatado, na ordem da resposta)", i, r.CEP, want[i])
+		}
+	}
+}
+
+func TestSearchAddressRateLimit(t *testing.T) {
+	srv := newJSONStub(t, 0, http.StatusOK, json.RawMessage(`[]`))
+	c := newViaCEPClient(t, srv)
+	c.RateLimits = map[string]RateLimit{"ViaCEP": {PerSecond: 0.1, FailFast: true}}
+	a := Address{UF: "SP", Cidade: "São Paulo", Logradouro: "Domingos de Morais"}
+
+	if _, err := c.SearchAddress(context.Background(), a); err != nil {
+		t.Fatalf("primeira busca: %v", err)
+	}
+	if _, err := c.SearchAddress(context.Background(), a); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("segunda busca: erro = %v, esperado ErrRateLimited (limite do ViaCEP compartilhado com as consultas por CEP)", err)
+	}
+}
+
+func TestNewAddress(t *testing.T) {
+	a, err := NewAddress(" sp ", "São Paulo", "Rua 25 de Março/Centro")
+	if err != nil {
+		t.Fatalf("NewAddress: %v", err)
+	}
+	if a.UF != "SP" || a.Logradouro != "Rua 25 de Março/Centro" {
+		t.Errorf("endereço = %+v, esperada a UF em maiúsculas e a barra mantida no logradouro", a)
+	}
+	for _, parts := range [][3]string{{"S", "São Paulo", "Sé"}, {"SP", "SP", "Praça da Sé"}, {"SP", "São Paulo", "Sé"}} {
+		if _, err := NewAddress(parts[0], parts[1], parts[2]); err == nil {
+			t.Errorf("NewAddress(%q): esperado erro de validação", parts)
+		}
+	}
+}